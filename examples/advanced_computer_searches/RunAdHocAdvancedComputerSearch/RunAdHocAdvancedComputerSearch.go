@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Define the path to the JSON configuration file
+	configFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"
+
+	// Initialize the Jamf Pro client with the HTTP client configuration
+	client, err := jamfpro.BuildClientWithConfigFile(configFilePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	criteria, err := jamfpro.NewSharedContainerCriteria(jamfpro.CriteriaTargetComputer, `"Last Check-in" more than 30 days ago`)
+	if err != nil {
+		log.Fatalf("Invalid criteria: %v", err)
+	}
+
+	// The search is created, run and deleted again
+	search := &jamfpro.ResourceAdvancedComputerSearch{
+		Criteria: criteria,
+		DisplayFields: []jamfpro.DisplayField{
+			{Name: "Computer Name"},
+			{Name: "Serial Number"},
+			{Name: "Last Check-in"},
+			{Name: "Total RAM MB"},
+		},
+		Site: &jamfpro.SharedResourceSite{ID: -1, Name: "None"},
+	}
+
+	table, err := client.RunAdHocAdvancedComputerSearch(search)
+	if err != nil {
+		log.Fatalf("Error running advanced computer search: %v", err)
+	}
+
+	for _, column := range table.Columns {
+		fmt.Printf("%s (%s)\n", column.Name, column.Type)
+	}
+	fmt.Printf("%d computers found\n", len(table.Rows))
+
+	// Export the display field columns to CSV
+	if err := table.WriteCSV(os.Stdout); err != nil {
+		log.Fatalf("Error writing CSV: %v", err)
+	}
+}
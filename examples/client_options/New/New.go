@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Trust a private CA used by a TLS-inspecting proxy
+	caCert, err := os.ReadFile("/Users/dafyddwatkins/localtesting/jamfpro/proxy-ca.pem")
+	if err != nil {
+		log.Fatalf("Failed to read CA certificate: %v", err)
+	}
+	rootCAs := x509.NewCertPool()
+	rootCAs.AppendCertsFromPEM(caCert)
+
+	config := &jamfpro.ConfigContainer{
+		AuthMethod:               "oauth2",
+		ClientID:                 "your-client-id",
+		ClientSecret:             "your-client-secret",
+		MaxRetryAttempts:         3,
+		TotalRetryDuration:       60,
+		TokenRefreshBufferPeriod: 300,
+		RetryEligiableRequests:   true,
+	}
+
+	// Build the client, logging through the application's slog handler
+	client, err := jamfpro.New(
+		jamfpro.WithConfig(config),
+		jamfpro.WithBaseURL("https://yourinstance.jamfcloud.com"),
+		jamfpro.WithSlogHandler(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})),
+		jamfpro.WithProxy("http://proxy.example.com:3128"),
+		jamfpro.WithTLSConfig(&tls.Config{RootCAs: rootCAs}),
+		jamfpro.WithUserAgent("inventory-sync/1.0"),
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	version, err := client.GetJamfProVersion()
+	if err != nil {
+		log.Fatalf("Error fetching Jamf Pro version: %v", err)
+	}
+
+	fmt.Printf("Jamf Pro version: %s\n", *version.Version)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	loader := &jamfpro.ConfigLoader{File: "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"}
+	client, err := jamfpro.New(
+		jamfpro.WithConfigLoader(loader),
+		jamfpro.WithAdaptiveRateLimit(jamfpro.AdaptiveLimitOptions{
+			InitialLimit:     4,
+			MaxLimit:         16,
+			LatencyThreshold: 3 * time.Second,
+		}),
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	computers, err := client.GetComputers()
+	if err != nil {
+		log.Fatalf("Error fetching computers: %v", err)
+	}
+
+	// Report the limiter's state while the inventory is fetched
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fmt.Println("Rate limiter:", client.RateLimiter().State())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// Goroutines share the client's limiter, so at most the current limit are sent at once
+	var wg sync.WaitGroup
+	for _, computer := range computers.Results {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			if _, err := client.GetComputerByID(fmt.Sprint(id)); err != nil {
+				log.Printf("Error fetching computer %d: %v", id, err)
+			}
+		}(computer.ID)
+	}
+	wg.Wait()
+	close(done)
+
+	state := client.RateLimiter().State()
+	fmt.Printf("Done: %d requests, %d throttled, final limit %.2f\n", state.Requests, state.Throttled, state.Limit)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Cache lookups on disk between runs; categories change rarely, inventory often
+	diskCache, err := jamfpro.NewDiskCache("/Users/dafyddwatkins/localtesting/jamfpro/cache")
+	if err != nil {
+		log.Fatalf("Failed to create cache: %v", err)
+	}
+
+	loader := &jamfpro.ConfigLoader{File: "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"}
+	client, err := jamfpro.New(
+		jamfpro.WithConfigLoader(loader),
+		jamfpro.WithCache(jamfpro.CacheOptions{
+			Backend:    diskCache,
+			DefaultTTL: 10 * time.Minute,
+			ResourceTTLs: map[string]time.Duration{
+				"categories":          time.Hour,
+				"computers-inventory": time.Minute,
+			},
+		}),
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// Repeated lookups are served from the cache
+	for _, name := range []string{"Utilities", "Productivity", "Utilities"} {
+		category, err := client.GetCategoryByName(name)
+		if err != nil {
+			log.Printf("Error fetching category %s: %v", name, err)
+			continue
+		}
+		fmt.Printf("Category %s has ID %s\n", category.Name, category.Id)
+	}
+
+	stats := client.Cache().Stats()
+	fmt.Printf("Cache hits: %d, misses: %d, hit ratio: %.2f\n", stats.Hits, stats.Misses, stats.HitRatio())
+}
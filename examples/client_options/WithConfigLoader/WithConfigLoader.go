@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Layer ~/.jamfpro/config profiles, a config file, JAMFPRO_ environment variables and flags
+	loader := &jamfpro.ConfigLoader{
+		File:      "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.yaml",
+		EnvPrefix: "JAMFPRO_",
+	}
+	profile := flag.String("profile", "", "profile from ~/.jamfpro/config")
+	loader.BindFlags(flag.CommandLine, "jamf-")
+	flag.Parse()
+	loader.Profile = *profile
+
+	// Show where each setting came from
+	loaded, err := loader.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	for _, source := range loaded.SortedSources() {
+		fmt.Println(source)
+	}
+
+	client, err := jamfpro.New(jamfpro.WithConfig(loaded.Config))
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	version, err := client.GetJamfProVersion()
+	if err != nil {
+		log.Fatalf("Error fetching Jamf Pro version: %v", err)
+	}
+
+	fmt.Printf("Jamf Pro version: %s\n", *version.Version)
+}
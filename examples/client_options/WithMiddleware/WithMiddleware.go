@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	var calls, failures atomic.Int64
+
+	// Count and log every request the SDK sends
+	audit := jamfpro.AfterResponse(func(req *jamfpro.Request, resp *jamfpro.Response, err error) {
+		calls.Add(1)
+		if err != nil {
+			failures.Add(1)
+		}
+		log.Printf("%s: %s %s -> %d in %s (%d attempts)", req.Operation, req.Method, req.Endpoint, resp.StatusCode, resp.Duration.Round(time.Millisecond), resp.Attempts)
+	})
+
+	// Refuse deletions outside a weekday change window, 18:00 to 22:00
+	changeWindow := jamfpro.BeforeRequest(func(req *jamfpro.Request) error {
+		now := time.Now()
+		open := now.Weekday() != time.Saturday && now.Weekday() != time.Sunday && now.Hour() >= 18 && now.Hour() < 22
+		if req.Method == http.MethodDelete && !open {
+			return fmt.Errorf("%s refused: deletions are only allowed in the change window", req.Operation)
+		}
+		return nil
+	})
+
+	// Tag every request with a change reference
+	changeReference := jamfpro.BeforeRequest(func(req *jamfpro.Request) error {
+		req.Header.Set("X-Change-Reference", "CHG0012345")
+		return nil
+	})
+
+	loader := &jamfpro.ConfigLoader{File: "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"}
+	client, err := jamfpro.New(
+		jamfpro.WithConfigLoader(loader),
+		jamfpro.WithMiddleware(audit, changeWindow, changeReference),
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	categories, err := client.GetCategories("")
+	if err != nil {
+		log.Fatalf("Error fetching categories: %v", err)
+	}
+	fmt.Printf("Found %d categories\n", categories.TotalCount)
+
+	if err := client.DeleteCategoryByID("1"); err != nil {
+		fmt.Println("Delete refused:", err)
+	}
+
+	fmt.Printf("%d requests, %d failed\n", calls.Load(), failures.Load())
+}
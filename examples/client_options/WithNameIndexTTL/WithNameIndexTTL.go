@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	loader := &jamfpro.ConfigLoader{File: "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"}
+	client, err := jamfpro.New(
+		jamfpro.WithConfigLoader(loader),
+		jamfpro.WithNameIndexTTL(15*time.Minute),
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// The category list is fetched once and reused for each lookup
+	for _, name := range []string{"Applications", "Utilities", "Security"} {
+		category, err := client.GetCategoryByName(name)
+
+		var duplicate *jamfpro.DuplicateNameError
+		switch {
+		case errors.As(err, &duplicate):
+			fmt.Printf("%q is ambiguous, use one of category IDs %v\n", name, duplicate.IDs)
+		case errors.Is(err, jamfpro.ErrNameNotFound):
+			fmt.Printf("No category named %q\n", name)
+		case err != nil:
+			log.Printf("Error fetching category %s: %v", name, err)
+		default:
+			fmt.Printf("Category %s has ID %s\n", category.Name, category.Id)
+		}
+	}
+
+	// Resolve a name to an ID without fetching the resource
+	id, err := client.LookupIDByName("computergroups", "All Managed Clients")
+	if err != nil {
+		log.Fatalf("Error looking up computer group: %v", err)
+	}
+	fmt.Printf("Computer group ID: %s\n", id)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	loader := &jamfpro.ConfigLoader{File: "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"}
+	client, err := jamfpro.New(
+		jamfpro.WithConfigLoader(loader),
+		jamfpro.WithSessionAffinity(jamfpro.SessionAffinityOptions{
+			VerifyReadAfterWrite: true,
+			ReadRetries:          5,
+		}),
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// Create a category and read it straight back; a 404 while the write reaches the node is retried
+	created, err := client.CreateCategory(&jamfpro.ResourceCategory{Name: "Session Affinity Example", Priority: 9})
+	if err != nil {
+		log.Fatalf("Error creating category: %v", err)
+	}
+
+	category, err := client.GetCategoryByID(created.ID)
+	if err != nil {
+		log.Fatalf("Error fetching category %s: %v", created.ID, err)
+	}
+	fmt.Printf("Read back category %q\n", category.Name)
+
+	state := client.SessionAffinity().State()
+	fmt.Printf("Pinned to node %s since %s\n", state.Node, state.PinnedAt.Format("15:04:05"))
+	fmt.Printf("Re-pins: %d (last: %q), rotations: %d, node failures: %d\n", state.Repins, state.LastRepinReason, state.Rotations, state.NodeFailures)
+	fmt.Printf("Read-after-write retries: %d, failures: %d\n", state.ReadAfterWriteRetries, state.ReadAfterWriteFailures)
+
+	if err := client.DeleteCategoryByID(created.ID); err != nil {
+		log.Fatalf("Error deleting category %s: %v", created.ID, err)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Define the path to the YAML file listing every tenant
+	tenantsFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/tenants.yaml"
+
+	// Load the tenant configurations; clients are only built when first used
+	pool, err := jamfpro.LoadClientPoolFromYAML(tenantsFilePath)
+	if err != nil {
+		log.Fatalf("Failed to load tenant configurations: %v", err)
+	}
+
+	// Find which production tenants have a given policy
+	policyName := "Install Google Chrome" // Replace with the policy name to look for
+	results := jamfpro.RunAcrossTenants(context.Background(), pool, jamfpro.TenantSelector{Tags: []string{"prod"}},
+		func(ctx context.Context, tenant jamfpro.TenantConfig, client *jamfpro.Client) (bool, error) {
+			policies, err := client.GetPolicies()
+			if err != nil {
+				return false, err
+			}
+			for _, policy := range policies.Policy {
+				if policy.Name == policyName {
+					return true, nil
+				}
+			}
+			return false, nil
+		})
+
+	for _, result := range results {
+		if result.Err != nil {
+			fmt.Printf("%s: error: %v\n", result.Tenant, result.Err)
+			continue
+		}
+		fmt.Printf("%s: has policy %q: %t\n", result.Tenant, policyName, result.Value)
+	}
+}
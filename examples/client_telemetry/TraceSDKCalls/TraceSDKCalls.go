@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+	"go.opentelemetry.io/otel"
+)
+
+func main() {
+	// Configure the client, handing it the tracer and meter providers registered by the service.
+	// Register providers with exporters, e.g. OTLP, before this point for the spans to be exported.
+	config := &jamfpro.ConfigContainer{
+		LogLevel:                 "warn",
+		InstanceDomain:           "https://yourinstance.jamfcloud.com",
+		AuthMethod:               "oauth2",
+		ClientID:                 "your-client-id",
+		ClientSecret:             "your-client-secret",
+		MaxRetryAttempts:         3,
+		TotalRetryDuration:       60,
+		TokenRefreshBufferPeriod: 300,
+		RetryEligiableRequests:   true,
+		TracerProvider:           otel.GetTracerProvider(),
+		MeterProvider:            otel.GetMeterProvider(),
+	}
+
+	client, err := jamfpro.BuildClient(config)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// Start a span for the work; SDK calls made with its context become its children
+	ctx, span := otel.Tracer("example").Start(context.Background(), "report-policies")
+	defer span.End()
+
+	policies, err := client.WithContext(ctx).GetPolicies()
+	if err != nil {
+		log.Fatalf("Error fetching policies: %v", err)
+	}
+
+	fmt.Printf("Fetched %d policies\n", policies.Size)
+}
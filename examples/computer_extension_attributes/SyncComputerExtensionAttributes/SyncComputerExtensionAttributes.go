@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Define the path to the JSON configuration file
+	configFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"
+
+	// Initialize the Jamf Pro client with the HTTP client configuration
+	client, err := jamfpro.BuildClientWithConfigFile(configFilePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// Preview the changes needed to match the local scripts, disabling managed EAs which were removed
+	actions, err := client.SyncComputerExtensionAttributes("/Users/dafyddwatkins/localtesting/jamfpro/extension_attributes", jamfpro.ExtensionAttributeSyncOptions{
+		DisableMissing:       true,
+		DisableMissingPrefix: "EA - ",
+		DryRun:               true,
+	})
+	if err != nil {
+		log.Fatalf("Error syncing extension attributes: %v", err)
+	}
+	for _, action := range actions {
+		fmt.Printf("%-9s %4d %s %v\n", action.Action, action.ID, action.Name, action.Err)
+	}
+
+	// Report extension attributes which no smart group or advanced search refers to
+	report, err := client.ComputerExtensionAttributeUsageReport()
+	if err != nil {
+		log.Fatalf("Error building usage report: %v", err)
+	}
+	for _, usage := range report {
+		if usage.Unused() {
+			fmt.Printf("Unused: %d %s (enabled: %t)\n", usage.ID, usage.Name, usage.Enabled)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Define the path to the JSON configuration file
+	configFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"
+
+	// Initialize the Jamf Pro client with the HTTP client configuration
+	client, err := jamfpro.BuildClientWithConfigFile(configFilePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// Analyze the policy logs of every computer
+	computers, err := client.GetComputers()
+	if err != nil {
+		log.Fatalf("Error fetching computers: %v", err)
+	}
+	var computerIDs []int
+	for _, computer := range computers.Results {
+		computerIDs = append(computerIDs, computer.ID)
+	}
+
+	report, err := client.AnalyzePolicyLogs(context.Background(), computerIDs, jamfpro.PolicyLogAnalyticsOptions{
+		Since:        time.Now().AddDate(0, 0, -30),
+		IncludeScope: true,
+	})
+	if err != nil {
+		log.Fatalf("Error analyzing policy logs: %v", err)
+	}
+
+	for _, policy := range report.Policies {
+		fmt.Printf("%s (ID %d): %d succeeded, %d failed, %d pending, %d of %d in scope never ran\n",
+			policy.PolicyName, policy.PolicyID, policy.Succeeded, policy.Failed, policy.Pending, len(policy.NeverRan), policy.InScope)
+		if len(policy.FailedOn) > 0 {
+			fmt.Printf("    latest run failed on computers %v\n", policy.FailedOn)
+		}
+		for _, failure := range policy.Failures {
+			fmt.Printf("    %dx %q on %d computers\n", failure.Count, failure.Message, len(failure.Computers))
+		}
+	}
+	for computerID, err := range report.Errors {
+		fmt.Printf("Could not read computer %d: %v\n", computerID, err)
+	}
+}
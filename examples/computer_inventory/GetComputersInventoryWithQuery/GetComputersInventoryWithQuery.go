@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Define the path to the JSON configuration file
+	configFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"
+
+	// Initialize the Jamf Pro client with the HTTP client configuration
+	client, err := jamfpro.BuildClientWithConfigFile(configFilePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// Request only the sections needed, filtered and sorted server side
+	query := jamfpro.ComputerInventoryQuery{
+		Sections: []jamfpro.ComputerInventorySection{
+			jamfpro.ComputerInventorySectionGeneral,
+			jamfpro.ComputerInventorySectionHardware,
+			jamfpro.ComputerInventorySectionOperatingSystem,
+		},
+		Filter:   `operatingSystem.version=lt="14"`,
+		Sort:     []string{"general.name:asc"},
+		PageSize: 1000,
+	}
+
+	// Stream the results page by page rather than loading the whole fleet
+	total, err := client.ForEachComputerInventory(query, func(inventory jamfpro.ResourceComputerInventory) error {
+		fmt.Printf("%s\t%s\t%s\n", inventory.General.Name, inventory.Hardware.SerialNumber, inventory.OperatingSystem.Version)
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Error fetching computer inventory: %v", err)
+	}
+
+	fmt.Printf("%d computers matched\n", total)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/export"
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Define the path to the JSON configuration file
+	configFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"
+
+	// Initialize the Jamf Pro client with the HTTP client configuration
+	client, err := jamfpro.BuildClientWithConfigFile(configFilePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// Select the columns to export. Only the inventory sections they need are requested.
+	columns := []export.Column{
+		{Header: "Name", Path: "general.name"},
+		{Header: "Serial Number", Path: "hardware.serialNumber"},
+		{Header: "macOS", Path: "operatingSystem.version"},
+		{Header: "Department", Path: "extensionAttributes[name=Department].values"},
+		{Header: "Application", Path: "applications.name"},
+		{Header: "Application Version", Path: "applications.version"},
+	}
+
+	file, err := os.Create("computer_applications.csv")
+	if err != nil {
+		log.Fatalf("Failed to create export file: %v", err)
+	}
+	defer file.Close()
+
+	// One row per installed application, streamed page by page
+	exporter := &export.Exporter{
+		Columns: columns,
+		Format:  export.FormatCSV,
+		Explode: "applications",
+	}
+
+	source := export.ComputerSource(client, jamfpro.ComputerInventoryQuery{PageSize: 500}, columns)
+	rows, err := exporter.Export(file, source)
+	if err != nil {
+		log.Fatalf("Error exporting computer inventory: %v", err)
+	}
+
+	fmt.Printf("Exported %d rows\n", rows)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Define the path to the JSON configuration file
+	configFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"
+
+	// Initialize the Jamf Pro client with the HTTP client configuration
+	client, err := jamfpro.BuildClientWithConfigFile(configFilePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// Rotate the credentials of selected API integrations and distribute the new secrets
+	rotator := &jamfpro.CredentialRotator{
+		Client:           client,
+		IntegrationNames: []string{"Terraform Integration"}, // Replace with the API integration names to rotate
+		Sinks: []jamfpro.CredentialSink{
+			&jamfpro.EnvFileCredentialSink{Path: "/etc/jamfpro/terraform.env"},
+			&jamfpro.VaultCredentialSink{
+				Address: "http://127.0.0.1:8200",
+				Token:   os.Getenv("VAULT_TOKEN"),
+				Path:    "jamfpro/integrations/%d",
+			},
+		},
+		Audit: jamfpro.JSONLinesAuditLogger(os.Stdout),
+	}
+
+	records, err := rotator.RotateOnce()
+	if err != nil {
+		log.Fatalf("Error rotating client credentials: %v", err)
+	}
+
+	for _, record := range records {
+		fmt.Printf("Integration %s (ID %d): %s\n", record.IntegrationName, record.IntegrationID, record.Status)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Define the path to the JSON configuration file
+	configFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"
+
+	// Initialize the Jamf Pro client with the HTTP client configuration
+	client, err := jamfpro.BuildClientWithConfigFile(configFilePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// Describe the policy, referring to other resources by name
+	builder := jamfpro.NewPolicyBuilder("jamfpro-sdk-example-builder-policy").
+		Category("Applications").
+		TriggerCustom("install-example-app").
+		Frequency(jamfpro.PolicyFrequencyOngoing).
+		Script("tf-ghatest-add-or-remove-group-membership-v4.0", jamfpro.PolicyScriptAfter, "thing", "thing").
+		Recon().
+		ScopeComputerGroups("All Managed Clients").
+		SelfService("Example App", "Installs the example app").
+		SelfServiceCategory("Applications", false)
+
+	// Check the policy for invalid combinations before contacting Jamf Pro
+	if err := builder.Validate(); err != nil {
+		log.Fatalf("Invalid policy: %v", err)
+	}
+
+	// Resolve the names and create the policy
+	created, err := builder.Create(client)
+	if err != nil {
+		log.Fatalf("Error creating policy: %v", err)
+	}
+
+	fmt.Printf("Created policy ID: %d\n", created.ID)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Define the path to the JSON configuration file
+	configFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"
+
+	// Initialize the Jamf Pro client with the HTTP client configuration
+	client, err := jamfpro.BuildClientWithConfigFile(configFilePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// Lint every script with shellcheck and check for secrets before uploading
+	options := jamfpro.ScriptSyncOptions{
+		Validators: []jamfpro.ScriptValidator{
+			jamfpro.ShebangValidator{Allowed: []string{"/bin/zsh", "/bin/bash", "/bin/sh", "/usr/bin/env"}},
+			jamfpro.CommandValidator{Command: "shellcheck", Args: []string{"--severity=warning"}, Extensions: []string{".sh", ".bash"}},
+			jamfpro.SecretsValidator{AllowMarker: "# secrets:allow"},
+		},
+		DryRun: true,
+	}
+
+	actions, err := client.SyncScripts("/Users/dafyddwatkins/localtesting/jamfpro/scripts", options)
+	if err != nil {
+		log.Fatalf("Error syncing scripts: %v", err)
+	}
+	for _, action := range actions {
+		fmt.Printf("%-9s %4s %s %v\n", action.Action, action.ID, action.Name, action.Changes)
+		if action.Err != nil {
+			fmt.Printf("          %v\n", action.Err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Define the path to the JSON configuration file
+	configFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"
+
+	// Initialize the Jamf Pro client with the HTTP client configuration
+	client, err := jamfpro.BuildClientWithConfigFile(configFilePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// Parse and validate the criteria locally. "Department Code" is an extension attribute,
+	// so it is passed as an additional known criterion name.
+	criteria, err := jamfpro.NewComputerGroupCriteria(
+		`("Operating System Version" like "14." and "Last Check-in" less than 7 days ago) or "Department Code" is "IT"`,
+		"Department Code",
+	)
+	if err != nil {
+		log.Fatalf("Invalid criteria: %v", err)
+	}
+
+	newSmartGroup := &jamfpro.ResourceComputerGroup{
+		Name:    "Sonoma checked in this week or IT",
+		IsSmart: true,
+		Site: &jamfpro.SharedResourceSite{
+			ID:   -1,
+			Name: "None",
+		},
+		Criteria: criteria,
+	}
+
+	createdGroup, err := client.CreateComputerGroup(newSmartGroup)
+	if err != nil {
+		log.Fatalf("Error creating Computer Group: %v", err)
+	}
+
+	// Render the group's criteria back into an expression
+	group, err := client.GetComputerGroupByID(strconv.Itoa(createdGroup.ID))
+	if err != nil {
+		log.Fatalf("Error fetching Computer Group: %v", err)
+	}
+	if group.Criteria != nil && group.Criteria.Criterion != nil {
+		fmt.Println("Criteria:", jamfpro.FormatCriteria(*group.Criteria.Criterion))
+	}
+
+	// Pretty print the group in XML
+	groupXML, err := xml.MarshalIndent(group, "", "    ") // Indent with 4 spaces
+	if err != nil {
+		log.Fatalf("Error marshaling Computer Group data: %v", err)
+	}
+	fmt.Println("Created Computer Group:\n", string(groupXML))
+}
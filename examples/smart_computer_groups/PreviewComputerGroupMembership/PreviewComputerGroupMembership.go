@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+func main() {
+	// Define the path to the JSON configuration file
+	configFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"
+
+	// Initialize the Jamf Pro client with the HTTP client configuration
+	client, err := jamfpro.BuildClientWithConfigFile(configFilePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// The proposed criteria for the smart group
+	criteria, err := jamfpro.ParseCriteria(`"Operating System Version" less than "14" and "Last Check-in" more than 30 days ago`)
+	if err != nil {
+		log.Fatalf("Invalid criteria: %v", err)
+	}
+
+	// Fetch the inventory sections the criteria refer to
+	var computers []jamfpro.ResourceComputerInventory
+	query := jamfpro.ComputerInventoryQuery{
+		Sections: []jamfpro.ComputerInventorySection{
+			jamfpro.ComputerInventorySectionGeneral,
+			jamfpro.ComputerInventorySectionHardware,
+			jamfpro.ComputerInventorySectionOperatingSystem,
+		},
+		PageSize: 1000,
+	}
+	_, err = client.ForEachComputerInventory(query, func(inventory jamfpro.ResourceComputerInventory) error {
+		computers = append(computers, inventory)
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Error fetching computer inventory: %v", err)
+	}
+
+	// Compare the predicted membership with the group's current members
+	groupID := "42"
+	preview, err := client.PreviewComputerGroupMembership(groupID, criteria, computers, nil)
+	if err != nil {
+		log.Fatalf("Error previewing group membership: %v", err)
+	}
+
+	fmt.Printf("%s: %d predicted members, %d unchanged\n", preview.GroupName, len(preview.Predicted), preview.Unchanged)
+	for _, computer := range preview.Added {
+		fmt.Printf("+ %d %s (%s)\n", computer.ID, computer.Name, computer.SerialNumber)
+	}
+	for _, computer := range preview.Removed {
+		fmt.Printf("- %d %s (%s)\n", computer.ID, computer.Name, computer.SerialNumber)
+	}
+}
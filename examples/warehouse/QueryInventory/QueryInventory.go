@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/warehouse"
+)
+
+func main() {
+	// Open the inventory store read-only so the syncing process keeps its write lock
+	store, err := warehouse.Open("/Users/dafyddwatkins/localtesting/jamfpro/inventory.db", &warehouse.Options{
+		ReadOnly: true,
+		Timeout:  5 * time.Second,
+	})
+	if err != nil {
+		log.Fatalf("Failed to open inventory store: %v", err)
+	}
+	defer store.Close()
+
+	lastSync, err := store.LastSync(warehouse.KindComputers)
+	if err != nil {
+		log.Fatalf("Error reading sync state: %v", err)
+	}
+	fmt.Printf("Computers last synced at %s\n", lastSync.Format(time.RFC3339))
+
+	// Query the local mirror without touching the Jamf Pro API
+	unencrypted, err := store.QueryComputers(func(computer *jamfpro.ResourceComputerInventory) bool {
+		return len(computer.DiskEncryption.FileVault2EnabledUserNames) == 0
+	})
+	if err != nil {
+		log.Fatalf("Error querying computers: %v", err)
+	}
+
+	for _, computer := range unencrypted {
+		fmt.Printf("%s\t%s\n", computer.General.Name, computer.Hardware.SerialNumber)
+	}
+}
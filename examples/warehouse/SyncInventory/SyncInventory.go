@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/warehouse"
+)
+
+func main() {
+	// Define the path to the JSON configuration file
+	configFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"
+
+	// Initialize the Jamf Pro client with the HTTP client configuration
+	client, err := jamfpro.BuildClientWithConfigFile(configFilePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// Open the local inventory store. This process is the single writer.
+	store, err := warehouse.Open("/Users/dafyddwatkins/localtesting/jamfpro/inventory.db", nil)
+	if err != nil {
+		log.Fatalf("Failed to open inventory store: %v", err)
+	}
+	defer store.Close()
+
+	syncer := &warehouse.Syncer{
+		Client:       client,
+		Store:        store,
+		ChangeField:  "general.reportDate",
+		PruneDeleted: true,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// Sync now and every 15 minutes until interrupted
+	err = syncer.Run(ctx, 15*time.Minute, func(results []warehouse.SyncResult, err error) {
+		for _, result := range results {
+			fmt.Printf("%s: full=%t upserted=%d unchanged=%d deleted=%d in %s\n",
+				result.Kind, result.Full, result.Upserted, result.Unchanged, result.Deleted, result.Duration)
+		}
+		if err != nil {
+			fmt.Printf("Sync failed: %v\n", err)
+		}
+	})
+	if err != nil && err != context.Canceled {
+		log.Fatalf("Sync stopped: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/webhooks"
+)
+
+func main() {
+	// Accept deliveries from webhooks configured with AuthenticationType "BASIC"
+	receiver := webhooks.NewReceiver(webhooks.BasicAuth{
+		Username: "Sample User",
+		Password: "SamplePassword",
+	})
+
+	receiver.OnError = func(r *http.Request, err error) {
+		log.Printf("Rejected webhook from %s: %v", r.RemoteAddr, err)
+	}
+
+	// Typed handlers are selected by their payload type
+	webhooks.On(receiver, func(ctx context.Context, webhook webhooks.Webhook, event *webhooks.ComputerAddedEvent) error {
+		log.Printf("Computer added: %s (serial %s)", event.DeviceName, event.SerialNumber)
+		return nil
+	})
+
+	webhooks.On(receiver, func(ctx context.Context, webhook webhooks.Webhook, event *webhooks.SmartGroupComputerMembershipChangeEvent) error {
+		log.Printf("Smart group %s: added %v, removed %v", event.Name, event.GroupAddedDevicesIds, event.GroupRemovedDevicesIds)
+		return nil
+	})
+
+	// Catch-all handler for every event, including types without a typed payload
+	receiver.HandleAny(func(ctx context.Context, event *webhooks.Event) error {
+		log.Printf("Received %s from webhook %q", event.Type(), event.Webhook.Name)
+		return nil
+	})
+
+	http.Handle("/jamf/webhooks", receiver)
+	log.Fatal(http.ListenAndServe(":8080", nil))
+}
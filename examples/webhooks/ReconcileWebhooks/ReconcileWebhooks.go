@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/webhooks"
+)
+
+func main() {
+	// Define the path to the JSON configuration file
+	configFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"
+
+	// Initialize the Jamf Pro client with the HTTP client configuration
+	client, err := jamfpro.BuildClientWithConfigFile(configFilePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
+	}
+
+	// The receiver defines which events are needed and how deliveries are authenticated
+	auth := webhooks.BasicAuth{Username: "Sample User", Password: "SamplePassword"}
+	receiver := webhooks.NewReceiver(auth)
+	webhooks.On(receiver, func(ctx context.Context, webhook webhooks.Webhook, event *webhooks.ComputerCheckInEvent) error {
+		return nil
+	})
+	webhooks.On(receiver, func(ctx context.Context, webhook webhooks.Webhook, event *webhooks.SmartGroupComputerMembershipChangeEvent) error {
+		return nil
+	})
+
+	// Register the receiver's events in Jamf Pro and disable webhooks left over from the old listener
+	actions, err := webhooks.Reconcile(client, webhooks.ReconcileSpec{
+		URL:    "https://hooks.example.com/jamf/webhooks",
+		Events: receiver.EventTypes(),
+		Auth:   auth,
+		SmartGroupIDs: map[string][]int{
+			webhooks.EventSmartGroupComputerMembershipChange: {1},
+		},
+		StaleURLPrefixes: []string{"https://old-hooks.example.com/"},
+		Stale:            webhooks.StaleDisable,
+	})
+	if err != nil {
+		log.Fatalf("Error reconciling webhooks: %v", err)
+	}
+
+	for _, action := range actions {
+		if action.Err != nil {
+			fmt.Printf("%s %s failed: %v\n", action.Action, action.Name, action.Err)
+			continue
+		}
+		fmt.Printf("%s %s (ID %d)\n", action.Action, action.Name, action.WebhookID)
+	}
+}
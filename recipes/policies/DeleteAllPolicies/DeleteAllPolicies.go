@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"strconv"
@@ -9,6 +11,9 @@ import (
 )
 
 func main() {
+	dryRun := flag.Bool("dry-run", false, "list the policies which would be deleted without deleting them")
+	flag.Parse()
+
 	// Define the path to the JSON configuration file
 	configFilePath := "/Users/dafyddwatkins/localtesting/jamfpro/clientconfig.json"
 
@@ -18,27 +23,38 @@ func main() {
 		log.Fatalf("Failed to initialize Jamf Pro client: %v", err)
 	}
 
-	// Fetch all macOS configuration profiles
-	extAtts, err := client.GetPolicies()
+	// Fetch all policies
+	policies, err := client.GetPolicies()
 	if err != nil {
-		log.Fatalf("Error fetching macOS configuration profiles: %v", err)
+		log.Fatalf("Error fetching policies: %v", err)
 	}
 
-	fmt.Println("macOS configuration profiles fetched. Starting deletion process:")
-
-	// Iterate over each macOS configuration profile and delete
-	for _, extAtt := range extAtts.Policy {
-		fmt.Printf("Deleting macOS configuration profile ID: %d, Name: %s\n", extAtt.ID, extAtt.Name)
+	items := make([]jamfpro.BulkItem[struct{}], len(policies.Policy))
+	for i, policy := range policies.Policy {
+		items[i] = jamfpro.BulkItem[struct{}]{Action: jamfpro.BulkDelete, ID: strconv.Itoa(policy.ID), Name: policy.Name}
+	}
 
-		err = client.DeletePolicyByID(strconv.Itoa(extAtt.ID))
-		if err != nil {
-			log.Printf("Error deleting macOS configuration profile ID %d: %v\n", extAtt.ID, err)
-			continue // Move to the next macOS configuration profile if there's an error
-		}
+	fmt.Printf("%d policies fetched. Starting deletion process:\n", len(items))
 
-		fmt.Printf("macOS configuration profile ID %d deleted successfully.\n", extAtt.ID)
+	// Delete policies four at a time, at most five a second, carrying on past failures
+	options := jamfpro.BulkOptions{
+		Workers:         4,
+		RateLimit:       5,
+		ContinueOnError: true,
+		DryRun:          *dryRun,
 	}
+	report := jamfpro.RunBulk(context.Background(), client, items, options,
+		func(client *jamfpro.Client, item jamfpro.BulkItem[struct{}]) (string, error) {
+			return item.ID, client.DeletePolicyByID(item.ID)
+		})
 
-	fmt.Println("macOS configuration profile deletion process completed.")
+	for _, result := range report.Results {
+		fmt.Printf("%s (%d attempts, %s)\n", result, result.Attempts, result.Duration)
+	}
 
+	fmt.Printf("Policy deletion process completed in %s: %d deleted, %d failed, %d skipped, %d planned.\n",
+		report.Duration, report.Succeeded, report.Failed, report.Skipped, report.Planned)
+	if err := report.Err(); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,257 @@
+// export.go
+// Exporter streams SDK resources, such as ResourceComputerInventory and ResourceMobileDevice, into CSV,
+// JSON Lines or Parquet, selecting columns with dotted field paths. Records are read from a Source one at
+// a time and written immediately, so a fleet is never held in memory.
+//
+// Repeated sections are either joined into a single cell or, with Exporter.Explode, expanded into one
+// row per item.
+
+package export
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+const defaultSeparator = "; "
+
+// Format selects the output format of an export.
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatJSONL   Format = "jsonl"
+	FormatParquet Format = "parquet"
+)
+
+// Column is a single exported column.
+type Column struct {
+	Header string // defaults to Path
+	Path   string
+}
+
+// Columns creates columns headed by their field paths.
+func Columns(paths ...string) []Column {
+	columns := make([]Column, len(paths))
+	for i, path := range paths {
+		columns[i] = Column{Header: path, Path: path}
+	}
+	return columns
+}
+
+// Source emits records to be exported, one at a time. Any struct, or pointer to a struct, may be emitted.
+// A source for a warehouse store, for example, is
+//
+//	func(emit func(record interface{}) error) error {
+//		return store.ForEachComputer(func(computer *jamfpro.ResourceComputerInventory) error {
+//			return emit(computer)
+//		})
+//	}
+type Source func(emit func(record interface{}) error) error
+
+// ComputerSource streams computers from the computers-inventory endpoint page by page. When the query
+// requests no sections, the sections required by columns are requested, see ComputerSections.
+func ComputerSource(client *jamfpro.Client, query jamfpro.ComputerInventoryQuery, columns []Column) Source {
+	if len(query.Sections) == 0 {
+		query.Sections = ComputerSections(columns)
+	}
+
+	return func(emit func(record interface{}) error) error {
+		_, err := client.ForEachComputerInventory(query, func(computer jamfpro.ResourceComputerInventory) error {
+			return emit(&computer)
+		})
+		return err
+	}
+}
+
+// MobileDeviceSource lists mobile devices through the Classic API and fetches each full record in turn.
+func MobileDeviceSource(client *jamfpro.Client) Source {
+	return func(emit func(record interface{}) error) error {
+		list, err := client.GetMobileDevices()
+		if err != nil {
+			return err
+		}
+
+		for _, item := range list.MobileDevices {
+			device, err := client.GetMobileDeviceByID(strconv.Itoa(item.ID))
+			if err != nil {
+				return fmt.Errorf("failed to get mobile device %d: %w", item.ID, err)
+			}
+			if err := emit(device); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// ComputerSections returns the computer inventory sections addressed by the first segment of each column path.
+func ComputerSections(columns []Column) []jamfpro.ComputerInventorySection {
+	available := map[jamfpro.ComputerInventorySection]bool{}
+	for _, section := range jamfpro.AllComputerInventorySections {
+		available[section] = true
+	}
+
+	seen := map[jamfpro.ComputerInventorySection]bool{}
+	var sections []jamfpro.ComputerInventorySection
+	for _, column := range columns {
+		segments, err := parsePath(column.Path)
+		if err != nil {
+			continue
+		}
+		section := jamfpro.ComputerInventorySection(upperSnakeCase(segments[0].name))
+		if available[section] && !seen[section] {
+			seen[section] = true
+			sections = append(sections, section)
+		}
+	}
+
+	if len(sections) == 0 {
+		return []jamfpro.ComputerInventorySection{jamfpro.ComputerInventorySectionGeneral}
+	}
+	return sections
+}
+
+// Exporter writes records as rows of the selected columns.
+type Exporter struct {
+	Columns []Column
+	Format  Format // defaults to FormatCSV
+
+	// Explode names a repeated section, e.g. "applications", producing one row per item. Columns beneath
+	// it, e.g. "applications.name", are resolved against the item; other columns repeat on every row.
+	// Records without items produce a single row. When empty, each record produces one row.
+	Explode string
+
+	// Separator joins multiple values in a CSV or Parquet cell. Defaults to "; ".
+	Separator string
+}
+
+// exportColumn is a column with its parsed path.
+type exportColumn struct {
+	segments []pathSegment
+	relative bool // resolved against the exploded item
+}
+
+// Export reads every record from source and writes it to w, returning the number of rows written.
+func (e *Exporter) Export(w io.Writer, source Source) (int, error) {
+	if len(e.Columns) == 0 {
+		return 0, fmt.Errorf("export has no columns")
+	}
+
+	var explode []pathSegment
+	if e.Explode != "" {
+		var err error
+		explode, err = parsePath(e.Explode)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	columns := make([]exportColumn, len(e.Columns))
+	headers := make([]string, len(e.Columns))
+	for i, column := range e.Columns {
+		segments, err := parsePath(column.Path)
+		if err != nil {
+			return 0, err
+		}
+		columns[i].segments = segments
+		if explode != nil && hasPathPrefix(segments, explode) {
+			columns[i].segments, columns[i].relative = segments[len(explode):], true
+		}
+
+		headers[i] = column.Header
+		if headers[i] == "" {
+			headers[i] = column.Path
+		}
+	}
+
+	separator := e.Separator
+	if separator == "" {
+		separator = defaultSeparator
+	}
+
+	writer, err := newRowWriter(e.Format, w, headers, separator)
+	if err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	cells := make([][]interface{}, len(columns))
+	err = source(func(record interface{}) error {
+		value := reflect.ValueOf(record)
+
+		items := []reflect.Value{{}}
+		if explode != nil {
+			var expanded []reflect.Value
+			for _, section := range resolveItems(value, explode) {
+				if isList(section) {
+					for i := 0; i < section.Len(); i++ {
+						expanded = append(expanded, section.Index(i))
+					}
+				} else {
+					expanded = append(expanded, section)
+				}
+			}
+			if len(expanded) > 0 {
+				items = expanded
+			}
+		}
+
+		for _, item := range items {
+			for i, column := range columns {
+				switch {
+				case !column.relative:
+					cells[i] = resolveValues(value, column.segments)
+				case item.IsValid():
+					cells[i] = resolveValues(item, column.segments)
+				default:
+					cells[i] = nil
+				}
+			}
+			if err := writer.writeRow(cells); err != nil {
+				return err
+			}
+			rows++
+		}
+		return nil
+	})
+	if err != nil {
+		writer.close()
+		return rows, fmt.Errorf("export failed after %d rows: %w", rows, err)
+	}
+
+	return rows, writer.close()
+}
+
+// hasPathPrefix reports whether path starts with the segments of prefix.
+func hasPathPrefix(path, prefix []pathSegment) bool {
+	if len(path) <= len(prefix) {
+		return false
+	}
+	for i, segment := range prefix {
+		if !strings.EqualFold(path[i].name, segment.name) ||
+			path[i].filterKey != segment.filterKey ||
+			path[i].filterValue != segment.filterValue ||
+			path[i].index != segment.index {
+			return false
+		}
+	}
+	return true
+}
+
+// upperSnakeCase converts a camelCase JSON key to the UPPER_SNAKE_CASE used by section names.
+func upperSnakeCase(name string) string {
+	var builder strings.Builder
+	for i, r := range name {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			builder.WriteByte('_')
+		}
+		builder.WriteRune(r)
+	}
+	return strings.ToUpper(builder.String())
+}
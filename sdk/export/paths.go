@@ -0,0 +1,255 @@
+// paths.go
+// Field paths select values from SDK resource structs using dotted names, e.g. "general.name",
+// "hardware.serialNumber" or "extensionAttributes[name=Department].values".
+//
+// Each segment matches a struct field by its JSON key, its XML element name or its Go field name,
+// ignoring case, so the same syntax addresses Jamf Pro API (JSON) and Classic API (XML) resources.
+// A segment may be followed by a filter, [key=value], keeping only the list items whose field matches,
+// or by an index, [n]. Segments over lists without a filter or index address every item.
+
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// pathSegment is a single parsed segment of a field path.
+type pathSegment struct {
+	name        string
+	filterKey   string
+	filterValue string
+	index       int // -1 when unset
+}
+
+// parsePath splits a field path into segments.
+func parsePath(path string) ([]pathSegment, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("empty field path")
+	}
+
+	var segments []pathSegment
+	rest := path
+	for rest != "" {
+		segment := pathSegment{index: -1}
+
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		segment.name = rest[:end]
+		rest = rest[end:]
+
+		if strings.HasPrefix(rest, "[") {
+			closing := strings.Index(rest, "]")
+			if closing < 0 {
+				return nil, fmt.Errorf("unterminated [ in field path %q", path)
+			}
+			selector := rest[1:closing]
+			rest = rest[closing+1:]
+
+			if key, value, found := strings.Cut(selector, "="); found {
+				segment.filterKey, segment.filterValue = strings.TrimSpace(key), strings.TrimSpace(value)
+			} else {
+				index, err := strconv.Atoi(selector)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid selector [%s] in field path %q", selector, path)
+				}
+				segment.index = index
+			}
+		}
+
+		if segment.name == "" {
+			return nil, fmt.Errorf("empty segment in field path %q", path)
+		}
+		segments = append(segments, segment)
+
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if rest == "" {
+				return nil, fmt.Errorf("trailing . in field path %q", path)
+			}
+		} else if rest != "" {
+			return nil, fmt.Errorf("unexpected %q in field path %q", rest, path)
+		}
+	}
+
+	return segments, nil
+}
+
+// resolveValues returns the leaf values addressed by segments. Lists reached at the end of the path
+// contribute each of their items.
+func resolveValues(value reflect.Value, segments []pathSegment) []interface{} {
+	var out []interface{}
+	for _, item := range resolveItems(value, segments) {
+		out = appendLeaves(out, item)
+	}
+	return out
+}
+
+// resolveItems returns the values addressed by segments without expanding a final list.
+func resolveItems(value reflect.Value, segments []pathSegment) []reflect.Value {
+	value = indirect(value)
+	if !value.IsValid() {
+		return nil
+	}
+	if len(segments) == 0 {
+		return []reflect.Value{value}
+	}
+
+	if isList(value) {
+		var out []reflect.Value
+		for i := 0; i < value.Len(); i++ {
+			out = append(out, resolveItems(value.Index(i), segments)...)
+		}
+		return out
+	}
+
+	field := lookupField(value, segments[0].name)
+	if !field.IsValid() {
+		return nil
+	}
+
+	segment := segments[0]
+	switch {
+	case segment.filterKey != "":
+		field = indirect(field)
+		if !isList(field) {
+			return nil
+		}
+		var out []reflect.Value
+		for i := 0; i < field.Len(); i++ {
+			item := field.Index(i)
+			for _, key := range resolveValues(item, []pathSegment{{name: segment.filterKey, index: -1}}) {
+				if formatValue(key) == segment.filterValue {
+					out = append(out, resolveItems(item, segments[1:])...)
+					break
+				}
+			}
+		}
+		return out
+	case segment.index >= 0:
+		field = indirect(field)
+		if !isList(field) || segment.index >= field.Len() {
+			return nil
+		}
+		return resolveItems(field.Index(segment.index), segments[1:])
+	default:
+		return resolveItems(field, segments[1:])
+	}
+}
+
+// appendLeaves appends a value, or each item of a list, to out.
+func appendLeaves(out []interface{}, value reflect.Value) []interface{} {
+	value = indirect(value)
+	if !value.IsValid() {
+		return out
+	}
+	if isList(value) {
+		for i := 0; i < value.Len(); i++ {
+			out = appendLeaves(out, value.Index(i))
+		}
+		return out
+	}
+	return append(out, value.Interface())
+}
+
+// lookupField returns the field or map entry named by a path segment.
+func lookupField(value reflect.Value, name string) reflect.Value {
+	switch value.Kind() {
+	case reflect.Struct:
+		index, ok := fieldIndex(value.Type())[strings.ToLower(name)]
+		if !ok {
+			return reflect.Value{}
+		}
+		return value.Field(index)
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}
+		}
+		return value.MapIndex(reflect.ValueOf(name).Convert(value.Type().Key()))
+	default:
+		return reflect.Value{}
+	}
+}
+
+var fieldIndexCache sync.Map // reflect.Type -> map[string]int
+
+// fieldIndex maps the lower-cased JSON key, XML element name and Go name of each exported field to its index.
+func fieldIndex(t reflect.Type) map[string]int {
+	if cached, ok := fieldIndexCache.Load(t); ok {
+		return cached.(map[string]int)
+	}
+
+	index := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		names := []string{field.Name}
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+			names = append(names, tag)
+		}
+		if tag, _, _ := strings.Cut(field.Tag.Get("xml"), ","); tag != "" && tag != "-" {
+			element, _, _ := strings.Cut(tag, ">")
+			names = append(names, element)
+		}
+
+		for _, name := range names {
+			if _, exists := index[strings.ToLower(name)]; !exists {
+				index[strings.ToLower(name)] = i
+			}
+		}
+	}
+
+	fieldIndexCache.Store(t, index)
+	return index
+}
+
+// indirect follows pointers and interfaces.
+func indirect(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// isList reports whether a value is a slice or array other than a byte slice.
+func isList(value reflect.Value) bool {
+	kind := value.Kind()
+	return (kind == reflect.Slice || kind == reflect.Array) && value.Type().Elem().Kind() != reflect.Uint8
+}
+
+// formatValue renders a leaf value as text. Structs and maps are rendered as JSON.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Struct, reflect.Map:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,182 @@
+// writers.go
+// Row writers for the supported export formats. Rows are written as they are produced; the Parquet
+// writer buffers at most one row group.
+
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/snappy"
+)
+
+const parquetRowGroupSize = 10000
+
+// rowWriter writes exported rows. Each cell holds the values resolved for one column.
+type rowWriter interface {
+	writeRow(cells [][]interface{}) error
+	close() error
+}
+
+// newRowWriter creates the writer for a format.
+func newRowWriter(format Format, w io.Writer, headers []string, separator string) (rowWriter, error) {
+	switch format {
+	case FormatCSV, "":
+		writer := &csvRowWriter{writer: csv.NewWriter(w), separator: separator}
+		if err := writer.writer.Write(headers); err != nil {
+			return nil, err
+		}
+		return writer, nil
+	case FormatJSONL:
+		return &jsonlRowWriter{writer: bufio.NewWriter(w), headers: headers}, nil
+	case FormatParquet:
+		return newParquetRowWriter(w, headers, separator)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// joinCell renders the values of a cell as a single string.
+func joinCell(values []interface{}, separator string) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = formatValue(value)
+	}
+	return strings.Join(parts, separator)
+}
+
+// CSV
+
+// csvRowWriter writes one CSV record per row, joining multiple values with the separator.
+type csvRowWriter struct {
+	writer    *csv.Writer
+	separator string
+	record    []string
+}
+
+func (c *csvRowWriter) writeRow(cells [][]interface{}) error {
+	c.record = c.record[:0]
+	for _, values := range cells {
+		c.record = append(c.record, joinCell(values, c.separator))
+	}
+	return c.writer.Write(c.record)
+}
+
+func (c *csvRowWriter) close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// JSON Lines
+
+// jsonlRowWriter writes one JSON object per line with keys in column order. Cells without values are null,
+// cells with one value hold it directly and cells with several values hold an array.
+type jsonlRowWriter struct {
+	writer  *bufio.Writer
+	headers []string
+	buffer  bytes.Buffer
+}
+
+func (j *jsonlRowWriter) writeRow(cells [][]interface{}) error {
+	j.buffer.Reset()
+	j.buffer.WriteByte('{')
+	for i, values := range cells {
+		if i > 0 {
+			j.buffer.WriteByte(',')
+		}
+
+		key, err := json.Marshal(j.headers[i])
+		if err != nil {
+			return err
+		}
+		j.buffer.Write(key)
+		j.buffer.WriteByte(':')
+
+		var cell interface{}
+		switch len(values) {
+		case 0:
+		case 1:
+			cell = values[0]
+		default:
+			cell = values
+		}
+		value, err := json.Marshal(cell)
+		if err != nil {
+			return err
+		}
+		j.buffer.Write(value)
+	}
+	j.buffer.WriteString("}\n")
+
+	_, err := j.writer.Write(j.buffer.Bytes())
+	return err
+}
+
+func (j *jsonlRowWriter) close() error {
+	return j.writer.Flush()
+}
+
+// Parquet
+
+// parquetRowWriter writes every column as an optional UTF-8 string, joining multiple values with the
+// separator. Cells without values are null.
+type parquetRowWriter struct {
+	writer    *parquet.Writer
+	separator string
+	columns   []int // parquet column index of each export column
+	row       parquet.Row
+}
+
+func newParquetRowWriter(w io.Writer, headers []string, separator string) (*parquetRowWriter, error) {
+	group := parquet.Group{}
+	for _, header := range headers {
+		if _, exists := group[header]; exists {
+			return nil, fmt.Errorf("duplicate column header: %s", header)
+		}
+		group[header] = parquet.Optional(parquet.String())
+	}
+	schema := parquet.NewSchema("inventory", group)
+
+	// Parquet orders the columns of a group by name, so map each export column to its position.
+	columns := make([]int, len(headers))
+	for i, header := range headers {
+		leaf, _ := schema.Lookup(header)
+		columns[i] = leaf.ColumnIndex
+	}
+
+	return &parquetRowWriter{
+		writer: parquet.NewWriter(w,
+			schema,
+			parquet.Compression(&snappy.Codec{}),
+			parquet.MaxRowsPerRowGroup(parquetRowGroupSize),
+		),
+		separator: separator,
+		columns:   columns,
+		row:       make(parquet.Row, len(headers)),
+	}, nil
+}
+
+func (p *parquetRowWriter) writeRow(cells [][]interface{}) error {
+	for i, values := range cells {
+		column := p.columns[i]
+		if len(values) == 0 {
+			p.row[column] = parquet.NullValue().Level(0, 0, column)
+			continue
+		}
+		p.row[column] = parquet.ByteArrayValue([]byte(joinCell(values, p.separator))).Level(0, 1, column)
+	}
+
+	_, err := p.writer.WriteRows([]parquet.Row{p.row})
+	return err
+}
+
+func (p *parquetRowWriter) close() error {
+	return p.writer.Close()
+}
@@ -6,18 +6,26 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/deploymenttheory/go-api-http-client-integrations/jamf/jamfprointegration"
 	"github.com/deploymenttheory/go-api-http-client/httpclient"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 const jamfLoadBalancerCookieName = "jpro-ingress"
 
+// Client is a Jamf Pro API client.
+//
+// HTTP was a *httpclient.Client before request interceptors were added. HTTPClient embeds that client, so
+// its methods and fields are still reached through HTTP, but code which assigns HTTP or passes it where a
+// *httpclient.Client is expected must use HTTP.Client instead. Code which builds a Client by hand from its
+// own httpclient.ClientConfig should wrap it with NewHTTPClient.
 type Client struct {
-	HTTP *httpclient.Client
+	HTTP *HTTPClient
 }
 
 type ConfigContainer struct {
@@ -33,6 +41,11 @@ type ConfigContainer struct {
 	Password             string `json:"basic_auth_password"`
 	JamfLoadBalancerLock bool   `json:"jamf_load_balancer_lock"`
 
+	// CredentialProvider, or a provider declared by CredentialProviderConfig, supplies any
+	// credentials required by AuthMethod which are left empty above.
+	CredentialProvider       CredentialProvider        `json:"-"`
+	CredentialProviderConfig *CredentialProviderConfig `json:"credential_provider,omitempty"`
+
 	CustomCookies               []CustomCookie `json:"custom_cookies"`
 	MaxRetryAttempts            int            `json:"max_retry_attempts"`
 	MaxConcurrentRequests       int            `json:"max_concurrent_requests"`
@@ -45,6 +58,14 @@ type ConfigContainer struct {
 	EnableConcurrencyManagement bool           `json:"enable_concurrency_management"`
 	MandatoryRequestDelay       int            `json:"mandatory_request_delay_milliseconds"`
 	RetryEligiableRequests      bool           `json:"retry_eligiable_requests"`
+
+	// TracerProvider and MeterProvider enable OpenTelemetry spans and metrics for SDK calls when set.
+	TracerProvider trace.TracerProvider `json:"-"`
+	MeterProvider  metric.MeterProvider `json:"-"`
+
+	// loadProblems records values which could not be loaded, e.g. unparseable environment variables,
+	// for Validate to report.
+	loadProblems []ConfigProblem
 }
 
 type CustomCookie struct {
@@ -52,39 +73,106 @@ type CustomCookie struct {
 	Value string `json:"value"`
 }
 
+// BuildClient initializes a new Jamf Pro client from a configuration. It is equivalent to New(WithConfig(config)).
 func BuildClient(config *ConfigContainer) (*Client, error) {
-	var err error
+	return New(WithConfig(config))
+}
 
-	DefaultLoggerConfig := zap.NewProductionConfig()
-	DefaultLoggerConfig.Level, err = LogLevelStringtoZap(config.LogLevel)
+// New initializes a new Jamf Pro client from functional options.
+func New(opts ...Option) (*Client, error) {
+	options, err := newClientOptions(opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to set log level: %v", err)
+		return nil, fmt.Errorf("invalid client option: %w", err)
 	}
 
-	if config.LogExportPath != "" {
-		DefaultLoggerConfig.OutputPaths = append(DefaultLoggerConfig.OutputPaths, config.LogExportPath)
+	config := options.resolvedConfig()
+	problems, warnings := config.validate(options.logger == nil)
+	if len(problems) > 0 {
+		return nil, &ConfigError{Problems: problems}
 	}
 
-	logger, err := DefaultLoggerConfig.Build()
+	logger, err := options.buildLogger(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build logger: %v", err)
+		return nil, err
 	}
 
 	Sugar := logger.Sugar()
+	for _, warning := range warnings {
+		Sugar.Warnw("Configuration warning", "setting", warning.String())
+	}
+
+	provider, err := config.credentialProvider()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve credentials: %w", err)
+	}
+	unresolved := config
+	config, err = config.withProvidedCredentials(provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve credentials: %w", err)
+	}
+
+	telemetry, err := newClientTelemetry(config.TracerProvider, config.MeterProvider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize telemetry: %w", err)
+	}
+
+	tracker := newRequestTracker()
+	if telemetry != nil {
+		tracker.use(telemetry.interceptAttempt)
+	}
+	if options.userAgent != "" {
+		tracker.use(setUserAgent(options.userAgent))
+	}
+	if provider != nil {
+		tracker.use(refreshProvidedCredentials(unresolved, provider, Sugar))
+	}
+	if len(options.middleware) > 0 {
+		tracker.use(applyCallHeaders)
+	}
+	tracker.use(recordBulkResponses)
+	// Session affinity sits outside the limiter, as re-pinning sends requests of its own.
+	var affinity *SessionAffinity
+	if config.JamfLoadBalancerLock || options.affinity != nil {
+		affinityOptions := SessionAffinityOptions{}
+		if options.affinity != nil {
+			affinityOptions = *options.affinity
+		}
+		affinity = newSessionAffinity(affinityOptions, Sugar)
+		tracker.use(affinity.interceptAttempt)
+	}
+	var limiter *AdaptiveLimiter
+	if options.adaptiveLimit != nil {
+		limiter = NewAdaptiveLimiter(*options.adaptiveLimit)
+		tracker.use(limiter.intercept)
+	}
+
+	integrationExecutor, err := options.newExecutor()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize HTTP executor: %w", err)
+	}
 
-	integration, err := initializeAPIIntegration(config, Sugar)
+	integration, err := initializeAPIIntegration(config, Sugar, &trackedExecutor{HTTPExecutor: integrationExecutor, tracker: tracker, integration: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize integration: %w", err)
 	}
 
-	customCookies, err := handleLoadBalancerLock(config, integration, convertCustomCookies(config.CustomCookies), Sugar)
+	executor, err := options.newExecutor()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to initialize HTTP executor: %w", err)
+	}
+
+	customCookies := convertCustomCookies(config.CustomCookies)
+	if affinity != nil {
+		if err := affinity.attach(integration, executor); err != nil {
+			return nil, err
+		}
+		customCookies = affinity.pinCookies(customCookies)
 	}
 
 	httpClientConfig := &httpclient.ClientConfig{
 		Sugar:                       Sugar,
-		Integration:                 integration,
+		Integration:                 &trackedIntegration{APIIntegration: integration, tracker: tracker},
+		HTTPExecutor:                &trackedExecutor{HTTPExecutor: executor, tracker: tracker},
 		HideSensitiveData:           config.HideSensitiveData,
 		CustomCookies:               customCookies,
 		MaxRetryAttempts:            config.MaxRetryAttempts,
@@ -92,7 +180,7 @@ func BuildClient(config *ConfigContainer) (*Client, error) {
 		EnableDynamicRateLimiting:   config.EnableDynamicRateLimiting,
 		CustomTimeout:               time.Duration(config.CustomTimeout) * time.Second,
 		TokenRefreshBufferPeriod:    time.Duration(config.TokenRefreshBufferPeriod) * time.Second,
-		TotalRetryDuration:          time.Duration(config.TotalRetryDuration) * time.Second,
+		TotalRetryDuration:          totalRetryDuration(config),
 		MaxRedirects:                config.MaxRedirects,
 		EnableConcurrencyManagement: config.EnableConcurrencyManagement,
 		MandatoryRequestDelay:       time.Duration(config.MandatoryRequestDelay) * time.Millisecond,
@@ -103,9 +191,33 @@ func BuildClient(config *ConfigContainer) (*Client, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to build HTTP client: %w", err)
 	}
+	options.reapplyHTTPClient(executor)
 
 	// Wrap into SDK & return
-	return &Client{HTTP: httpClient}, nil
+	client := &Client{HTTP: newHTTPClient(httpClient, tracker)}
+	client.HTTP.limiter = limiter
+	client.HTTP.affinity = affinity
+	client.HTTP.use(bulkDryRun)
+	if telemetry != nil {
+		client.HTTP.telemetry = telemetry
+		client.HTTP.use(telemetry.interceptCall)
+	}
+	for _, middleware := range options.middleware {
+		client.HTTP.use(middlewareInterceptor(middleware))
+	}
+	if options.cache != nil {
+		client.HTTP.cache = newResponseCache(*options.cache, config.InstanceDomain, cacheIdentity(config))
+		client.HTTP.use(client.HTTP.cache.intercept)
+	}
+	if options.nameIndexTTL != nil {
+		client.HTTP.names = newNameIndex(*options.nameIndexTTL)
+	}
+	client.HTTP.use(client.HTTP.names.intercept)
+	if affinity != nil && affinity.options.VerifyReadAfterWrite {
+		client.HTTP.use(affinity.interceptCall)
+	}
+
+	return client, nil
 }
 
 // BuildClientWithConfigFile initializes a new Jamf Pro client using a configuration file for the HTTP client, logger, and integration.
@@ -130,11 +242,10 @@ func BuildClientWithEnv() (*Client, error) {
 }
 
 // initializeAPIIntegration initializes the API integration based on the configuration
-func initializeAPIIntegration(config *ConfigContainer, Sugar *zap.SugaredLogger) (httpclient.APIIntegration, error) {
+func initializeAPIIntegration(config *ConfigContainer, Sugar *zap.SugaredLogger, executor httpclient.HTTPExecutor) (httpclient.APIIntegration, error) {
 	var integration *jamfprointegration.Integration
 	var err error
 
-	prodExecutor := &httpclient.ProdExecutor{Client: &http.Client{}}
 	switch config.AuthMethod {
 	case "oauth2":
 		integration, err = jamfprointegration.BuildWithOAuth(
@@ -144,7 +255,7 @@ func initializeAPIIntegration(config *ConfigContainer, Sugar *zap.SugaredLogger)
 			config.ClientID,
 			config.ClientSecret,
 			config.HideSensitiveData,
-			prodExecutor,
+			executor,
 		)
 	case "basic":
 		integration, err = jamfprointegration.BuildWithBasicAuth(
@@ -154,7 +265,7 @@ func initializeAPIIntegration(config *ConfigContainer, Sugar *zap.SugaredLogger)
 			config.Username,
 			config.Password,
 			config.HideSensitiveData,
-			prodExecutor,
+			executor,
 		)
 	default:
 		return nil, fmt.Errorf("invalid auth method supplied")
@@ -191,45 +302,66 @@ func loadConfigFromJSONFile(configFilePath string) (*ConfigContainer, error) {
 
 // loadConfigFromEnv loads the configuration from environment variables
 func loadConfigFromEnv() (*ConfigContainer, error) {
+	defaults := DefaultConfig()
+	env := &envConfigReader{}
 	config := &ConfigContainer{
-		LogLevel: getEnv("LOG_LEVEL", "warning"),
-		// ExportLogs:                  getEnvAsBool("EXPORT_LOGS", false),
-		HideSensitiveData:           getEnvAsBool("HIDE_SENSITIVE_DATA", true),
+		LogLevel: getEnv("LOG_LEVEL", defaults.LogLevel),
+		// ExportLogs:                  env.bool("EXPORT_LOGS", false),
+		HideSensitiveData:           env.bool("HIDE_SENSITIVE_DATA", defaults.HideSensitiveData),
 		InstanceDomain:              getEnv("INSTANCE_DOMAIN", ""),
 		AuthMethod:                  getEnv("AUTH_METHOD", ""),
 		ClientID:                    getEnv("CLIENT_ID", ""),
 		ClientSecret:                getEnv("CLIENT_SECRET", ""),
 		Username:                    getEnv("BASIC_AUTH_USERNAME", ""),
 		Password:                    getEnv("BASIC_AUTH_PASSWORD", ""),
-		JamfLoadBalancerLock:        getEnvAsBool("JAMF_LOAD_BALANCER_LOCK", false),
-		MaxRetryAttempts:            getEnvAsInt("MAX_RETRY_ATTEMPTS", 3),
-		EnableDynamicRateLimiting:   getEnvAsBool("ENABLE_DYNAMIC_RATE_LIMITING", false),
-		MaxConcurrentRequests:       getEnvAsInt("MAX_CONCURRENT_REQUESTS", 1),
-		TokenRefreshBufferPeriod:    getEnvAsInt("TOKEN_REFRESH_BUFFER_PERIOD_SECONDS", 300),
-		TotalRetryDuration:          getEnvAsInt("TOTAL_RETRY_DURATION_SECONDS", 60),
-		CustomTimeout:               getEnvAsInt("CUSTOM_TIMEOUT_SECONDS", 60),
-		FollowRedirects:             getEnvAsBool("FOLLOW_REDIRECTS", true),
-		MaxRedirects:                getEnvAsInt("MAX_REDIRECTS", 5),
-		EnableConcurrencyManagement: getEnvAsBool("ENABLE_CONCURRENCY_MANAGEMENT", true),
-		CustomCookies:               convertCustomCookiesFromEnv(getEnv("CUSTOM_COOKIES", "")),
-		MandatoryRequestDelay:       getEnvAsInt("MANDATORY_REQUEST_DELAY_MILLISECONDS", 0),
-		RetryEligiableRequests:      getEnvAsBool("RETRY_ELIGIABLE_REQUESTS", true),
+		JamfLoadBalancerLock:        env.bool("JAMF_LOAD_BALANCER_LOCK", defaults.JamfLoadBalancerLock),
+		MaxRetryAttempts:            env.int("MAX_RETRY_ATTEMPTS", defaults.MaxRetryAttempts),
+		EnableDynamicRateLimiting:   env.bool("ENABLE_DYNAMIC_RATE_LIMITING", defaults.EnableDynamicRateLimiting),
+		MaxConcurrentRequests:       env.int("MAX_CONCURRENT_REQUESTS", defaults.MaxConcurrentRequests),
+		TokenRefreshBufferPeriod:    env.int("TOKEN_REFRESH_BUFFER_PERIOD_SECONDS", defaults.TokenRefreshBufferPeriod),
+		TotalRetryDuration:          env.int("TOTAL_RETRY_DURATION_SECONDS", defaults.TotalRetryDuration),
+		CustomTimeout:               env.int("CUSTOM_TIMEOUT_SECONDS", defaults.CustomTimeout),
+		FollowRedirects:             env.bool("FOLLOW_REDIRECTS", defaults.FollowRedirects),
+		MaxRedirects:                env.int("MAX_REDIRECTS", defaults.MaxRedirects),
+		EnableConcurrencyManagement: env.bool("ENABLE_CONCURRENCY_MANAGEMENT", defaults.EnableConcurrencyManagement),
+		CustomCookies:               env.cookies("CUSTOM_COOKIES"),
+		MandatoryRequestDelay:       env.int("MANDATORY_REQUEST_DELAY_MILLISECONDS", defaults.MandatoryRequestDelay),
+		RetryEligiableRequests:      env.bool("RETRY_ELIGIABLE_REQUESTS", defaults.RetryEligiableRequests),
+		CredentialProviderConfig:    credentialProviderConfigFromEnv(""),
 	}
+	config.loadProblems = env.problems
 	return config, nil
 }
 
-// convertCustomCookiesFromEnv converts environment variable string to custom cookie configuration
-func convertCustomCookiesFromEnv(customCookiesStr string) []CustomCookie {
-	var customCookies []CustomCookie
-	if customCookiesStr == "" {
-		return customCookies
+// credentialProviderConfigFromEnv builds a credential provider configuration when CREDENTIAL_PROVIDER, with the given prefix, is set
+func credentialProviderConfigFromEnv(prefix string) *CredentialProviderConfig {
+	providerType := getEnv(prefix+"CREDENTIAL_PROVIDER", "")
+	if providerType == "" {
+		return nil
 	}
-	err := json.Unmarshal([]byte(customCookiesStr), &customCookies)
-	if err != nil {
-		fmt.Printf("Error parsing custom cookies from environment: %v\n", err)
+	return &CredentialProviderConfig{
+		Type:         providerType,
+		Path:         getEnv(prefix+"CREDENTIAL_PROVIDER_PATH", ""),
+		Command:      credentialProviderCommandFromEnv(getEnv(prefix+"CREDENTIAL_PROVIDER_COMMAND", "")),
+		Service:      getEnv(prefix+"CREDENTIAL_PROVIDER_SERVICE", ""),
+		FallbackPath: getEnv(prefix+"CREDENTIAL_PROVIDER_FALLBACK_PATH", ""),
+	}
+}
+
+// credentialProviderCommandFromEnv parses CREDENTIAL_PROVIDER_COMMAND. A JSON array gives the command and
+// its arguments; any other value is the path of the command, which may contain spaces.
+func credentialProviderCommandFromEnv(value string) []string {
+	value = strings.TrimSpace(value)
+	if value == "" {
 		return nil
 	}
-	return customCookies
+	if strings.HasPrefix(value, "[") {
+		var command []string
+		if err := json.Unmarshal([]byte(value), &command); err == nil {
+			return command
+		}
+	}
+	return []string{value}
 }
 
 // getEnv gets the environment variable or returns a default value
@@ -241,30 +373,13 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
-// getEnvAsBool gets the environment variable as a boolean or returns a default value
-func getEnvAsBool(key string, defaultValue bool) bool {
-	valueStr := getEnv(key, "")
-	if valueStr == "" {
-		return defaultValue
+// totalRetryDuration returns how long requests may be retried. The underlying client sends no retryable
+// request at all when the duration is 0, so its default is used instead.
+func totalRetryDuration(config *ConfigContainer) time.Duration {
+	if config.RetryEligiableRequests && config.TotalRetryDuration == 0 {
+		return httpclient.DefaultTotalRetryDuration
 	}
-	value, err := strconv.ParseBool(valueStr)
-	if err != nil {
-		return defaultValue
-	}
-	return value
-}
-
-// getEnvAsInt gets the environment variable as an integer or returns a default value
-func getEnvAsInt(key string, defaultValue int) int {
-	valueStr := getEnv(key, "")
-	if valueStr == "" {
-		return defaultValue
-	}
-	value, err := strconv.Atoi(valueStr)
-	if err != nil {
-		return defaultValue
-	}
-	return value
+	return time.Duration(config.TotalRetryDuration) * time.Second
 }
 
 // convertCustomCookies converts custom cookie configuration into http.Cookie objects for client build
@@ -279,43 +394,16 @@ func convertCustomCookies(customCookies []CustomCookie) []*http.Cookie {
 	return cookies
 }
 
-// handleLoadBalancerLock handles the load balancer lock by adding appropriate cookies if enabled
-func handleLoadBalancerLock(config *ConfigContainer, integration httpclient.APIIntegration, customCookies []*http.Cookie, Sugar *zap.SugaredLogger) ([]*http.Cookie, error) {
-	if config.JamfLoadBalancerLock {
-		jamfIntegration, ok := integration.(*jamfprointegration.Integration)
-		if !ok {
-			return nil, fmt.Errorf("integration is not of type *jamfprointegration.Integration")
-		}
-		cookies, err := jamfIntegration.GetSessionCookies()
-		if err != nil {
-			Sugar.Error("Failed to get session cookies for load balancer lock", zap.Error(err))
-			return customCookies, nil
-		}
-
-		for _, cookie := range cookies {
-			if cookie.Name == jamfLoadBalancerCookieName {
-				for i, customCookie := range customCookies {
-					if customCookie.Name == jamfLoadBalancerCookieName {
-						customCookies = append(customCookies[:i], customCookies[i+1:]...)
-						break
-					}
-				}
-				customCookies = append(customCookies, cookie)
-			}
-		}
-	}
-	return customCookies, nil
-}
-
 // LogLevelStringtoZap takes a string log level and converts it to a zap level
 func LogLevelStringtoZap(stringLevel string) (zap.AtomicLevel, error) {
 	levelMap := map[string]zap.AtomicLevel{
-		"debug":  zap.NewAtomicLevelAt(zap.DebugLevel),
-		"info":   zap.NewAtomicLevelAt(zap.InfoLevel),
-		"warn":   zap.NewAtomicLevelAt(zap.WarnLevel),
-		"dpanic": zap.NewAtomicLevelAt(zap.DPanicLevel),
-		"error":  zap.NewAtomicLevelAt(zap.ErrorLevel),
-		"fatal":  zap.NewAtomicLevelAt(zap.FatalLevel),
+		"debug":   zap.NewAtomicLevelAt(zap.DebugLevel),
+		"info":    zap.NewAtomicLevelAt(zap.InfoLevel),
+		"warn":    zap.NewAtomicLevelAt(zap.WarnLevel),
+		"warning": zap.NewAtomicLevelAt(zap.WarnLevel),
+		"dpanic":  zap.NewAtomicLevelAt(zap.DPanicLevel),
+		"error":   zap.NewAtomicLevelAt(zap.ErrorLevel),
+		"fatal":   zap.NewAtomicLevelAt(zap.FatalLevel),
 	}
 
 	outLevel, ok := levelMap[stringLevel]
@@ -0,0 +1,502 @@
+// api_client_cache.go
+// ResponseCache is an opt-in cache of GET responses, enabled with the WithCache option. Responses are
+// stored as their decoded values, so repeated lookups such as GetPolicyByName or GetCategoryByName are
+// served without listing the resource again. Each resource can have its own TTL, entries live in memory
+// (LRU) or on disk, and creating, updating or deleting a resource through the same client invalidates
+// its cached entries.
+//
+// Writes made by other clients or in the Jamf Pro console are not seen until entries expire, so TTLs
+// should reflect how stale a lookup may be. Entries are keyed by the client's identity as well as the
+// request, as accounts with different privileges see different responses, so a backend such as a disk
+// cache may be shared between clients.
+package jamfpro
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheTTL        = 5 * time.Minute
+	defaultCacheMaxEntries = 1000
+
+	// CacheStatusHeader is set to "hit" on responses served from the cache.
+	CacheStatusHeader = "X-Jamfpro-Cache"
+)
+
+// CacheOptions configures a ResponseCache.
+type CacheOptions struct {
+	// Backend stores entries. Defaults to an LRU cache of 1000 entries.
+	Backend CacheBackend
+
+	// DefaultTTL applies to resources without an entry in ResourceTTLs. Defaults to 5 minutes.
+	DefaultTTL time.Duration
+
+	// ResourceTTLs sets the TTL of a resource, named as in its endpoint, e.g. "policies",
+	// "categories" or "computers-inventory". A TTL of zero or less disables caching of the resource.
+	ResourceTTLs map[string]time.Duration
+}
+
+// CacheEntry is a cached response.
+type CacheEntry struct {
+	Resource  string          `json:"resource"`
+	Value     json.RawMessage `json:"value"`
+	StoredAt  time.Time       `json:"stored_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// CacheBackend stores cache entries. Implementations must be safe for concurrent use.
+type CacheBackend interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Delete(key string)
+	// DeleteResources deletes the entries of every resource matched, returning the number deleted.
+	DeleteResources(match func(resource string) bool) int
+}
+
+// CacheStats counts cache activity since the cache was created.
+type CacheStats struct {
+	Hits          int
+	Misses        int
+	Stores        int
+	Expired       int
+	Invalidations int // entries deleted by writes
+	Resources     map[string]CacheResourceStats
+}
+
+// CacheResourceStats counts cache activity for a single resource.
+type CacheResourceStats struct {
+	Hits   int
+	Misses int
+}
+
+// HitRatio returns the proportion of lookups served from the cache.
+func (s CacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// ResponseCache caches the responses of a client's GET requests.
+type ResponseCache struct {
+	options  CacheOptions
+	instance string
+	identity string
+
+	mu    sync.Mutex
+	stats CacheStats
+}
+
+type cacheBypassKey struct{}
+
+// WithCache enables response caching.
+func WithCache(options CacheOptions) Option {
+	return func(o *clientOptions) error {
+		o.cache = &options
+		return nil
+	}
+}
+
+// BypassCache returns a context whose requests skip the cache; use it with Client.WithContext to
+// force a fresh read. The fresh response still replaces any cached entry.
+func BypassCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+// Cache returns the client's response cache, or nil when caching is not enabled.
+func (c *Client) Cache() *ResponseCache {
+	return c.HTTP.cache
+}
+
+// newResponseCache creates a cache for requests to an instance made as identity, the OAuth client ID or
+// basic auth username.
+func newResponseCache(options CacheOptions, instance, identity string) *ResponseCache {
+	if options.Backend == nil {
+		options.Backend = NewLRUCache(defaultCacheMaxEntries)
+	}
+	if options.DefaultTTL == 0 {
+		options.DefaultTTL = defaultCacheTTL
+	}
+	return &ResponseCache{
+		options:  options,
+		instance: instance,
+		identity: identity,
+		stats:    CacheStats{Resources: map[string]CacheResourceStats{}},
+	}
+}
+
+// Stats returns a snapshot of the cache statistics.
+func (rc *ResponseCache) Stats() CacheStats {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	stats := rc.stats
+	stats.Resources = make(map[string]CacheResourceStats, len(rc.stats.Resources))
+	for resource, resourceStats := range rc.stats.Resources {
+		stats.Resources[resource] = resourceStats
+	}
+	return stats
+}
+
+// Invalidate deletes the cached entries of a resource and those related to it, returning the number
+// deleted.
+func (rc *ResponseCache) Invalidate(resource string) int {
+	deleted := rc.options.Backend.DeleteResources(func(cached string) bool {
+		return relatedCacheResources(resource, cached)
+	})
+
+	rc.mu.Lock()
+	rc.stats.Invalidations += deleted
+	rc.mu.Unlock()
+
+	return deleted
+}
+
+// Clear deletes every cached entry.
+func (rc *ResponseCache) Clear() int {
+	return rc.options.Backend.DeleteResources(func(string) bool { return true })
+}
+
+// intercept serves GET requests from the cache and invalidates resources written through the client.
+func (rc *ResponseCache) intercept(call *requestCall, next requestHandler) (*http.Response, error) {
+	if call.method != http.MethodGet {
+		resp, err := next(call)
+		rc.Invalidate(call.resource)
+		return resp, err
+	}
+
+	ttl := rc.ttl(call.resource)
+	if ttl <= 0 || call.out == nil {
+		return next(call)
+	}
+
+	key := rc.key(call)
+	if bypass, _ := call.ctx.Value(cacheBypassKey{}).(bool); !bypass {
+		if entry, ok := rc.options.Backend.Get(key); ok {
+			if time.Now().Before(entry.ExpiresAt) && json.Unmarshal(entry.Value, call.out) == nil {
+				rc.record(call.resource, true)
+				return cachedResponse(call), nil
+			}
+			rc.options.Backend.Delete(key)
+			rc.mu.Lock()
+			rc.stats.Expired++
+			rc.mu.Unlock()
+		}
+		rc.record(call.resource, false)
+	}
+
+	resp, err := next(call)
+	if err != nil || resp == nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp, err
+	}
+
+	if value, marshalErr := json.Marshal(call.out); marshalErr == nil {
+		now := time.Now()
+		rc.options.Backend.Set(key, &CacheEntry{Resource: call.resource, Value: value, StoredAt: now, ExpiresAt: now.Add(ttl)})
+		rc.mu.Lock()
+		rc.stats.Stores++
+		rc.mu.Unlock()
+	}
+
+	return resp, err
+}
+
+func (rc *ResponseCache) ttl(resource string) time.Duration {
+	if ttl, ok := rc.options.ResourceTTLs[resource]; ok {
+		return ttl
+	}
+	return rc.options.DefaultTTL
+}
+
+// key identifies a request by identity, instance, endpoint and the type it is decoded into.
+func (rc *ResponseCache) key(call *requestCall) string {
+	return fmt.Sprintf("%s@%s%s|%s", rc.identity, rc.instance, call.endpoint, reflect.TypeOf(call.out))
+}
+
+// cacheIdentity returns the account a configuration authenticates as.
+func cacheIdentity(config *ConfigContainer) string {
+	if config.AuthMethod == "basic" {
+		return config.Username
+	}
+	return config.ClientID
+}
+
+func (rc *ResponseCache) record(resource string, hit bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	resourceStats := rc.stats.Resources[resource]
+	if hit {
+		rc.stats.Hits++
+		resourceStats.Hits++
+	} else {
+		rc.stats.Misses++
+		resourceStats.Misses++
+	}
+	rc.stats.Resources[resource] = resourceStats
+}
+
+// cachedResponse is the response returned for a cache hit.
+func cachedResponse(call *requestCall) *http.Response {
+	header := http.Header{}
+	header.Set(CacheStatusHeader, "hit")
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+		Body:       http.NoBody,
+		Request:    &http.Request{Method: call.method},
+	}
+}
+
+// relatedCacheResources reports whether a write to one resource may change another. Resources are
+// compared without hyphens, and one containing the other as a prefix is treated as related, so a write to
+// "computers-inventory-detail" invalidates "computers-inventory" and the Classic API's "computers".
+func relatedCacheResources(written, cached string) bool {
+	a, b := strings.ReplaceAll(written, "-", ""), strings.ReplaceAll(cached, "-", "")
+	if a == "" || b == "" {
+		return a == b
+	}
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
+}
+
+// LRU backend
+
+// LRUCache is an in-memory CacheBackend which evicts the least recently used entry when full.
+type LRUCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewLRUCache creates an in-memory backend holding up to maxEntries entries.
+func NewLRUCache(maxEntries int) *LRUCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	return &LRUCache{maxEntries: maxEntries, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (l *LRUCache) Get(key string) (*CacheEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	return element.Value.(*lruItem).entry, true
+}
+
+func (l *LRUCache) Set(key string, entry *CacheEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		element.Value.(*lruItem).entry = entry
+		l.order.MoveToFront(element)
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(&lruItem{key: key, entry: entry})
+	for l.order.Len() > l.maxEntries {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruItem).key)
+	}
+}
+
+func (l *LRUCache) Delete(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		l.order.Remove(element)
+		delete(l.entries, key)
+	}
+}
+
+func (l *LRUCache) DeleteResources(match func(resource string) bool) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	deleted := 0
+	for key, element := range l.entries {
+		if match(element.Value.(*lruItem).entry.Resource) {
+			l.order.Remove(element)
+			delete(l.entries, key)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// Len returns the number of entries held.
+func (l *LRUCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+// Disk backend
+
+// DiskCache is a CacheBackend storing each entry as a JSON file under Dir, grouped by resource, so a
+// cache can be shared by successive runs. Entries may hold sensitive data and are written readable
+// only by the current user.
+type DiskCache struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+// NewDiskCache creates a disk backend rooted at dir, creating it if needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("could not create cache directory: %w", err)
+	}
+	return &DiskCache{Dir: dir}, nil
+}
+
+func (d *DiskCache) Get(key string) (*CacheEntry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	path, ok := d.find(key)
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		os.Remove(path)
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (d *DiskCache) Set(key string, entry *CacheEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if path, ok := d.find(key); ok {
+		os.Remove(path)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	dir := filepath.Join(d.Dir, diskCacheDirName(entry.Resource))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+
+	temp, err := os.CreateTemp(dir, ".entry-*")
+	if err != nil {
+		return
+	}
+	_, writeErr := temp.Write(data)
+	closeErr := temp.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(temp.Name())
+		return
+	}
+	if err := os.Rename(temp.Name(), filepath.Join(dir, diskCacheFileName(key))); err != nil {
+		os.Remove(temp.Name())
+	}
+}
+
+func (d *DiskCache) Delete(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if path, ok := d.find(key); ok {
+		os.Remove(path)
+	}
+}
+
+func (d *DiskCache) DeleteResources(match func(resource string) bool) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	resourceDirs, err := os.ReadDir(d.Dir)
+	if err != nil {
+		return 0
+	}
+
+	deleted := 0
+	for _, resourceDir := range resourceDirs {
+		if !resourceDir.IsDir() {
+			continue
+		}
+		dir := filepath.Join(d.Dir, resourceDir.Name())
+		files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil || len(files) == 0 {
+			continue
+		}
+
+		// The directory name may be sanitised, so match on the resource recorded in an entry
+		var entry CacheEntry
+		data, err := os.ReadFile(files[0])
+		if err != nil || json.Unmarshal(data, &entry) != nil || !match(entry.Resource) {
+			continue
+		}
+		if err := os.RemoveAll(dir); err == nil {
+			deleted += len(files)
+		}
+	}
+	return deleted
+}
+
+// find returns the path of a key's entry file.
+func (d *DiskCache) find(key string) (string, bool) {
+	matches, err := filepath.Glob(filepath.Join(d.Dir, "*", diskCacheFileName(key)))
+	if err != nil || len(matches) == 0 {
+		return "", false
+	}
+	return matches[0], true
+}
+
+func diskCacheFileName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:]) + ".json"
+}
+
+// diskCacheDirName makes a resource name safe to use as a directory name.
+func diskCacheDirName(resource string) string {
+	if resource == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, resource)
+}
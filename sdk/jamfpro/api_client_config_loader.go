@@ -0,0 +1,475 @@
+// api_client_config_loader.go
+// ConfigLoader builds a ConfigContainer from layers, each overriding the last: defaults, a named profile
+// from a shared profiles file, a JSON, YAML or TOML configuration file, environment variables and
+// explicit overrides such as command line flags. The loaded configuration records which layer set each
+// value.
+//
+// The profiles file, ~/.jamfpro/config by default, holds one section per profile using the standard
+// configuration keys, in the manner of AWS CLI profiles:
+//
+//	[prod]
+//	instance_domain = https://acme.jamfcloud.com
+//	auth_method = oauth2
+//	client_id = ...
+//
+//	[dev]
+//	instance_domain = https://acme-dev.jamfcloud.com
+package jamfpro
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Configuration layers, from lowest to highest precedence.
+const (
+	ConfigLayerDefault  = "default"
+	ConfigLayerProfile  = "profile"
+	ConfigLayerFile     = "file"
+	ConfigLayerEnv      = "env"
+	ConfigLayerOverride = "override"
+)
+
+// ProfileEnvVar selects the profile loaded by a ConfigLoader which does not name one.
+const ProfileEnvVar = "JAMFPRO_PROFILE"
+
+const defaultProfileName = "default"
+
+// ConfigLoader loads a configuration from layered sources.
+type ConfigLoader struct {
+	// Defaults is the lowest layer. Defaults to DefaultConfig().
+	Defaults *ConfigContainer
+
+	// Profile names the section of ProfilesFile to load. Defaults to $JAMFPRO_PROFILE, then to the
+	// "default" section if the file has one.
+	Profile string
+
+	// ProfilesFile defaults to ~/.jamfpro/config.
+	ProfilesFile string
+
+	// File is a JSON, YAML or TOML configuration file, chosen by its extension.
+	File string
+
+	// EnvPrefix is prepended to the environment variable names used by BuildClientWithEnv, e.g. with
+	// "JAMFPRO_" the instance domain is read from JAMFPRO_INSTANCE_DOMAIN.
+	EnvPrefix string
+
+	// DisableEnv skips the environment layer.
+	DisableEnv bool
+
+	// Overrides is the highest layer, keyed by configuration key, e.g. "instance_domain".
+	Overrides map[string]string
+
+	flagNames map[string]string
+}
+
+// ConfigValueSource describes where a configuration value came from.
+type ConfigValueSource struct {
+	Layer    string
+	Location string // file path and section, environment variable or flag; empty for defaults
+}
+
+func (s ConfigValueSource) String() string {
+	if s.Location == "" {
+		return s.Layer
+	}
+	return s.Layer + " " + s.Location
+}
+
+// LoadedConfig is a configuration along with the source of each of its values.
+type LoadedConfig struct {
+	Config *ConfigContainer
+
+	// Sources is keyed by ConfigContainer field name, e.g. "InstanceDomain".
+	Sources map[string]ConfigValueSource
+
+	// Profile is the profile loaded, if any.
+	Profile string
+}
+
+// SortedSources returns the sources as "Field: source" lines, sorted by field name.
+func (c *LoadedConfig) SortedSources() []string {
+	lines := make([]string, 0, len(c.Sources))
+	for field, source := range c.Sources {
+		lines = append(lines, fmt.Sprintf("%s: %s", field, source))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// DefaultConfig returns the defaults used by BuildClientWithEnv.
+func DefaultConfig() *ConfigContainer {
+	return &ConfigContainer{
+		LogLevel:                    "warn",
+		HideSensitiveData:           true,
+		MaxRetryAttempts:            3,
+		MaxConcurrentRequests:       1,
+		TokenRefreshBufferPeriod:    300,
+		TotalRetryDuration:          60,
+		CustomTimeout:               60,
+		FollowRedirects:             true,
+		MaxRedirects:                5,
+		EnableConcurrencyManagement: true,
+		RetryEligiableRequests:      true,
+	}
+}
+
+// WithConfigLoader sets the configuration the client is built from to the one loaded by loader.
+func WithConfigLoader(loader *ConfigLoader) Option {
+	return func(o *clientOptions) error {
+		loaded, err := loader.Load()
+		if err != nil {
+			return err
+		}
+		o.config = loaded.Config
+		return nil
+	}
+}
+
+// BindFlags registers a flag for each configuration key on fs, named after the key with hyphens and
+// the given prefix, e.g. "-jamf-instance-domain" for prefix "jamf-". Flags set on the command line
+// become overrides.
+func (l *ConfigLoader) BindFlags(fs *flag.FlagSet, prefix string) {
+	if l.flagNames == nil {
+		l.flagNames = map[string]string{}
+	}
+	for _, field := range configFields() {
+		key, name := field.key, prefix+strings.ReplaceAll(field.key, "_", "-")
+		l.flagNames[key] = "-" + name
+		fs.Func(name, fmt.Sprintf("Jamf Pro %s, overriding other configuration", key), func(value string) error {
+			if l.Overrides == nil {
+				l.Overrides = map[string]string{}
+			}
+			l.Overrides[key] = value
+			return nil
+		})
+	}
+}
+
+// Load merges the layers into a configuration. Values which cannot be parsed and unknown keys are
+// reported by Validate; unreadable files and missing profiles are returned as errors.
+func (l *ConfigLoader) Load() (*LoadedConfig, error) {
+	defaults := l.Defaults
+	if defaults == nil {
+		defaults = DefaultConfig()
+	}
+
+	config := *defaults
+	config.loadProblems = append([]ConfigProblem{}, defaults.loadProblems...)
+	loaded := &LoadedConfig{Config: &config, Sources: map[string]ConfigValueSource{}}
+	for _, field := range configFields() {
+		loaded.Sources[field.name] = ConfigValueSource{Layer: ConfigLayerDefault}
+	}
+
+	// Profile
+	profile, section, err := l.loadProfile()
+	if err != nil {
+		return nil, err
+	}
+	if section != nil {
+		loaded.Profile = profile
+		location := fmt.Sprintf("%s [%s]", l.profilesFile(), profile)
+		for _, key := range sortedConfigKeys(section) {
+			loaded.set(key, section[key], ConfigValueSource{Layer: ConfigLayerProfile, Location: location})
+		}
+	}
+
+	// File
+	if l.File != "" {
+		values, err := readConfigFile(l.File)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range sortedConfigKeys(values) {
+			loaded.set(key, values[key], ConfigValueSource{Layer: ConfigLayerFile, Location: l.File})
+		}
+	}
+
+	// Environment
+	if !l.DisableEnv {
+		for _, field := range configFields() {
+			envVar := configSources[field.name].EnvVar
+			if envVar == "" {
+				continue
+			}
+			source := ConfigValueSource{Layer: ConfigLayerEnv, Location: "$" + l.EnvPrefix + envVar}
+
+			if field.name == "CredentialProviderConfig" {
+				if providerConfig := credentialProviderConfigFromEnv(l.EnvPrefix); providerConfig != nil {
+					config.CredentialProviderConfig = providerConfig
+					loaded.Sources[field.name] = source
+				}
+				continue
+			}
+			if value, ok := os.LookupEnv(l.EnvPrefix + envVar); ok && value != "" {
+				loaded.set(field.key, value, source)
+			}
+		}
+	}
+
+	// Overrides
+	for _, key := range sortedConfigKeys(l.Overrides) {
+		value, location := l.Overrides[key], l.flagNames[key]
+		if location == "" {
+			location = key
+		}
+		loaded.set(key, value, ConfigValueSource{Layer: ConfigLayerOverride, Location: location})
+	}
+
+	return loaded, nil
+}
+
+// set applies a value to the configuration, recording a problem if it cannot be applied.
+func (c *LoadedConfig) set(key string, value interface{}, source ConfigValueSource) {
+	field, ok := configFieldsByKey()[key]
+	if !ok {
+		c.Config.loadProblems = append(c.Config.loadProblems, ConfigProblem{
+			Field:   key,
+			Message: fmt.Sprintf("in %s is not a configuration setting", source),
+		})
+		return
+	}
+
+	if err := setConfigValue(c.Config, field, value); err != nil {
+		problem := newConfigProblem(field.name, "in %s %v", source, err)
+		if source.Layer == ConfigLayerEnv {
+			problem.EnvVar = strings.TrimPrefix(source.Location, "$")
+		}
+		c.Config.loadProblems = append(c.Config.loadProblems, problem)
+		return
+	}
+	c.Sources[field.name] = source
+}
+
+// Profiles
+
+func (l *ConfigLoader) profilesFile() string {
+	if l.ProfilesFile != "" {
+		return l.ProfilesFile
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".jamfpro", "config")
+}
+
+// loadProfile returns the selected profile's section, or nil when no profile is selected and the
+// profiles file has no default section.
+func (l *ConfigLoader) loadProfile() (string, map[string]string, error) {
+	profile := l.Profile
+	if profile == "" {
+		profile = os.Getenv(ProfileEnvVar)
+	}
+	required := profile != ""
+	if profile == "" {
+		profile = defaultProfileName
+	}
+
+	path := l.profilesFile()
+	if path == "" {
+		if required {
+			return "", nil, fmt.Errorf("could not locate profiles file for profile %q", profile)
+		}
+		return "", nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !required {
+			return "", nil, nil
+		}
+		return "", nil, fmt.Errorf("could not read profiles file: %w", err)
+	}
+
+	profiles, err := parseProfiles(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not parse profiles file %s: %w", path, err)
+	}
+
+	section, ok := profiles[profile]
+	if !ok {
+		if required {
+			return "", nil, fmt.Errorf("profile %q not found in %s", profile, path)
+		}
+		return "", nil, nil
+	}
+	return profile, section, nil
+}
+
+// parseProfiles parses an INI style profiles file. Section headers may be written "[name]" or
+// "[profile name]"; lines starting with # or ; are comments.
+func parseProfiles(data []byte) (map[string]map[string]string, error) {
+	profiles := map[string]map[string]string{}
+	var section map[string]string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header", lineNumber)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			name = strings.TrimSpace(strings.TrimPrefix(name, "profile "))
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty section name", lineNumber)
+			}
+			if profiles[name] == nil {
+				profiles[name] = map[string]string{}
+			}
+			section = profiles[name]
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNumber)
+		}
+		if section == nil {
+			return nil, fmt.Errorf("line %d: setting outside a profile section", lineNumber)
+		}
+		section[strings.TrimSpace(key)] = unquoteProfileValue(strings.TrimSpace(value))
+	}
+
+	return profiles, scanner.Err()
+}
+
+// unquoteProfileValue strips matching single or double quotes from a value.
+func unquoteProfileValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// Files
+
+// readConfigFile decodes a JSON, YAML or TOML configuration file into its top level keys.
+func readConfigFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read configuration file: %w", err)
+	}
+
+	var values map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &values)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("unsupported configuration file type %q, use .json, .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse configuration file %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+// Fields
+
+// configField is a ConfigContainer field which can be set by key.
+type configField struct {
+	name  string
+	key   string
+	index []int
+}
+
+// configFields returns the ConfigContainer fields with a JSON key, in declaration order.
+func configFields() []configField {
+	var fields []configField
+	configType := reflect.TypeOf(ConfigContainer{})
+	for i := 0; i < configType.NumField(); i++ {
+		structField := configType.Field(i)
+		key, _, _ := strings.Cut(structField.Tag.Get("json"), ",")
+		if key == "" || key == "-" || !structField.IsExported() {
+			continue
+		}
+		fields = append(fields, configField{name: structField.Name, key: key, index: structField.Index})
+	}
+	return fields
+}
+
+func configFieldsByKey() map[string]configField {
+	fields := map[string]configField{}
+	for _, field := range configFields() {
+		fields[field.key] = field
+	}
+	return fields
+}
+
+// setConfigValue sets a field from a decoded file value or, for strings from profiles, the environment
+// and overrides, a string representation. Cookies and credential providers are given as JSON strings.
+func setConfigValue(config *ConfigContainer, field configField, value interface{}) error {
+	target := reflect.ValueOf(config).Elem().FieldByIndex(field.index)
+
+	if text, ok := value.(string); ok {
+		switch target.Kind() {
+		case reflect.String:
+			target.SetString(text)
+			return nil
+		case reflect.Bool:
+			parsed, err := strconv.ParseBool(strings.TrimSpace(text))
+			if err != nil {
+				return fmt.Errorf("%q is not a boolean, use true or false", text)
+			}
+			target.SetBool(parsed)
+			return nil
+		case reflect.Int:
+			parsed, err := strconv.Atoi(strings.TrimSpace(text))
+			if err != nil {
+				return fmt.Errorf("%q is not a whole number", text)
+			}
+			target.SetInt(int64(parsed))
+			return nil
+		default:
+			decoded := reflect.New(target.Type())
+			if err := json.Unmarshal([]byte(text), decoded.Interface()); err != nil {
+				return fmt.Errorf("is not valid JSON: %v", err)
+			}
+			target.Set(decoded.Elem())
+			return nil
+		}
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoded := reflect.New(target.Type())
+	if err := json.Unmarshal(data, decoded.Interface()); err != nil {
+		return fmt.Errorf("has the wrong type: %v", err)
+	}
+	target.Set(decoded.Elem())
+	return nil
+}
+
+// sortedConfigKeys returns the keys of a layer's values in sorted order.
+func sortedConfigKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
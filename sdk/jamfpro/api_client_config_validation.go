@@ -0,0 +1,312 @@
+// api_client_config_validation.go
+// Validate checks a ConfigContainer before a client is built and reports every problem at once. Each
+// problem names the configuration field along with the JSON key and environment variable which set it,
+// so it can be fixed wherever the configuration came from.
+package jamfpro
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deploymenttheory/go-api-http-client/httpclient"
+)
+
+// configSource names where a ConfigContainer field is set in a configuration file and the environment.
+type configSource struct {
+	JSONKey string
+	EnvVar  string
+}
+
+// configSources maps ConfigContainer fields to their JSON keys and environment variables.
+var configSources = map[string]configSource{
+	"LogLevel":                    {"log_level", "LOG_LEVEL"},
+	"LogExportPath":               {"log_export_path", ""},
+	"HideSensitiveData":           {"hide_sensitive_data", "HIDE_SENSITIVE_DATA"},
+	"InstanceDomain":              {"instance_domain", "INSTANCE_DOMAIN"},
+	"AuthMethod":                  {"auth_method", "AUTH_METHOD"},
+	"ClientID":                    {"client_id", "CLIENT_ID"},
+	"ClientSecret":                {"client_secret", "CLIENT_SECRET"},
+	"Username":                    {"basic_auth_username", "BASIC_AUTH_USERNAME"},
+	"Password":                    {"basic_auth_password", "BASIC_AUTH_PASSWORD"},
+	"JamfLoadBalancerLock":        {"jamf_load_balancer_lock", "JAMF_LOAD_BALANCER_LOCK"},
+	"CredentialProviderConfig":    {"credential_provider", "CREDENTIAL_PROVIDER"},
+	"CustomCookies":               {"custom_cookies", "CUSTOM_COOKIES"},
+	"MaxRetryAttempts":            {"max_retry_attempts", "MAX_RETRY_ATTEMPTS"},
+	"MaxConcurrentRequests":       {"max_concurrent_requests", "MAX_CONCURRENT_REQUESTS"},
+	"EnableDynamicRateLimiting":   {"enable_dynamic_rate_limiting", "ENABLE_DYNAMIC_RATE_LIMITING"},
+	"CustomTimeout":               {"custom_timeout_seconds", "CUSTOM_TIMEOUT_SECONDS"},
+	"TokenRefreshBufferPeriod":    {"token_refresh_buffer_period_seconds", "TOKEN_REFRESH_BUFFER_PERIOD_SECONDS"},
+	"TotalRetryDuration":          {"total_retry_duration_seconds", "TOTAL_RETRY_DURATION_SECONDS"},
+	"FollowRedirects":             {"follow_redirects", "FOLLOW_REDIRECTS"},
+	"MaxRedirects":                {"max_redirects", "MAX_REDIRECTS"},
+	"EnableConcurrencyManagement": {"enable_concurrency_management", "ENABLE_CONCURRENCY_MANAGEMENT"},
+	"MandatoryRequestDelay":       {"mandatory_request_delay_milliseconds", "MANDATORY_REQUEST_DELAY_MILLISECONDS"},
+	"RetryEligiableRequests":      {"retry_eligiable_requests", "RETRY_ELIGIABLE_REQUESTS"},
+}
+
+// ConfigProblem is a single invalid configuration setting.
+type ConfigProblem struct {
+	Field   string // ConfigContainer field, e.g. "InstanceDomain"
+	JSONKey string
+	EnvVar  string
+	Message string
+}
+
+func newConfigProblem(field, format string, args ...interface{}) ConfigProblem {
+	source := configSources[field]
+	return ConfigProblem{
+		Field:   field,
+		JSONKey: source.JSONKey,
+		EnvVar:  source.EnvVar,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (p ConfigProblem) String() string {
+	var sources []string
+	if p.JSONKey != "" {
+		sources = append(sources, p.JSONKey)
+	}
+	if p.EnvVar != "" {
+		sources = append(sources, "$"+p.EnvVar)
+	}
+	if len(sources) == 0 {
+		return fmt.Sprintf("%s %s", p.Field, p.Message)
+	}
+	return fmt.Sprintf("%s (%s) %s", p.Field, strings.Join(sources, ", "), p.Message)
+}
+
+// ConfigError lists every problem found in a configuration.
+type ConfigError struct {
+	Problems []ConfigProblem
+}
+
+func (e *ConfigError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		problems[i] = problem.String()
+	}
+	return fmt.Sprintf("invalid configuration: %s", strings.Join(problems, "; "))
+}
+
+// Validate checks the configuration, returning a *ConfigError listing every problem found, including
+// settings which have no effect. New only logs the latter as warnings. Credentials left empty are accepted
+// when a credential provider is configured to supply them. LogLevel may be left empty for clients given a
+// logger by New.
+func (config *ConfigContainer) Validate() error {
+	problems, warnings := config.validate(false)
+	if problems = append(problems, warnings...); len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// validate checks the configuration, requiring LogLevel when the client builds its own logger. It returns
+// the settings which prevent a client being built and, separately, those which have no effect.
+func (config *ConfigContainer) validate(requireLogLevel bool) (problems, warnings []ConfigProblem) {
+	problems = append(problems, config.loadProblems...)
+	add := func(field, format string, args ...interface{}) {
+		problems = append(problems, newConfigProblem(field, format, args...))
+	}
+	warn := func(field, format string, args ...interface{}) {
+		warnings = append(warnings, newConfigProblem(field, format, args...))
+	}
+
+	// Logging
+	if config.LogLevel == "" {
+		if requireLogLevel {
+			add("LogLevel", "is required, one of %s", strings.Join(logLevelNames(), ", "))
+		}
+	} else if _, err := LogLevelStringtoZap(config.LogLevel); err != nil {
+		add("LogLevel", "%q is not a log level, use one of %s", config.LogLevel, strings.Join(logLevelNames(), ", "))
+	}
+
+	// Instance
+	if config.InstanceDomain == "" {
+		add("InstanceDomain", "is required, e.g. https://yourinstance.jamfcloud.com")
+	} else if problem := instanceDomainProblem(config.InstanceDomain); problem != "" {
+		add("InstanceDomain", "%s", problem)
+	}
+
+	// Authentication
+	hasProvider := config.CredentialProvider != nil || config.CredentialProviderConfig != nil
+	switch config.AuthMethod {
+	case "oauth2":
+		if !hasProvider {
+			if config.ClientID == "" {
+				add("ClientID", "is required for oauth2 authentication")
+			}
+			if config.ClientSecret == "" {
+				add("ClientSecret", "is required for oauth2 authentication, or configure a credential provider")
+			}
+		}
+	case "basic":
+		if !hasProvider {
+			if config.Username == "" {
+				add("Username", "is required for basic authentication")
+			}
+			if config.Password == "" {
+				add("Password", "is required for basic authentication, or configure a credential provider")
+			}
+		}
+	case "":
+		add("AuthMethod", `is required, use "oauth2" or "basic"`)
+	default:
+		add("AuthMethod", `%q is not an auth method, use "oauth2" or "basic"`, config.AuthMethod)
+	}
+
+	if providerConfig := config.CredentialProviderConfig; providerConfig != nil && config.CredentialProvider == nil {
+		if _, err := providerConfig.Build(); err != nil {
+			add("CredentialProviderConfig", "%v", err)
+		}
+	}
+
+	// Cookies
+	for i, cookie := range config.CustomCookies {
+		if cookie.Name == "" {
+			add("CustomCookies", "cookie %d has no name", i+1)
+		}
+	}
+
+	// Durations and limits
+	for _, setting := range []struct {
+		field string
+		value int
+	}{
+		{"MaxRetryAttempts", config.MaxRetryAttempts},
+		{"MaxConcurrentRequests", config.MaxConcurrentRequests},
+		{"CustomTimeout", config.CustomTimeout},
+		{"TokenRefreshBufferPeriod", config.TokenRefreshBufferPeriod},
+		{"TotalRetryDuration", config.TotalRetryDuration},
+		{"MaxRedirects", config.MaxRedirects},
+		{"MandatoryRequestDelay", config.MandatoryRequestDelay},
+	} {
+		if setting.value < 0 {
+			add(setting.field, "cannot be negative, got %d", setting.value)
+		}
+	}
+
+	if config.RetryEligiableRequests && config.TotalRetryDuration == 0 {
+		warn("TotalRetryDuration", "is 0 while RetryEligiableRequests is true, which would stop GET, PUT and DELETE requests being sent; New uses %s instead", httpclient.DefaultTotalRetryDuration)
+	}
+
+	// Concurrency
+	if config.EnableConcurrencyManagement {
+		if config.MaxConcurrentRequests == 0 {
+			add("MaxConcurrentRequests", "must be at least 1 when EnableConcurrencyManagement is true")
+		}
+	} else {
+		if config.MaxConcurrentRequests > 1 {
+			warn("MaxConcurrentRequests", "is %d but has no effect unless EnableConcurrencyManagement is true", config.MaxConcurrentRequests)
+		}
+		if config.EnableDynamicRateLimiting {
+			warn("EnableDynamicRateLimiting", "has no effect unless EnableConcurrencyManagement is true")
+		}
+	}
+
+	return problems, warnings
+}
+
+// instanceDomainProblem describes what is wrong with an instance domain, or returns "". The domain is
+// used as a prefix for every endpoint, so it needs a scheme and no path.
+func instanceDomainProblem(domain string) string {
+	if !strings.Contains(domain, "://") {
+		return fmt.Sprintf("%q has no scheme, use https://%s", domain, strings.TrimSuffix(domain, "/"))
+	}
+
+	parsed, err := url.Parse(domain)
+	if err != nil {
+		return fmt.Sprintf("%q is not a valid URL: %v", domain, err)
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return fmt.Sprintf("%q has unsupported scheme %q, use https", domain, parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return fmt.Sprintf("%q has no host", domain)
+	}
+	if parsed.Path != "" || parsed.RawQuery != "" || parsed.Fragment != "" {
+		return fmt.Sprintf("%q must not include a path, use %s://%s", domain, parsed.Scheme, parsed.Host)
+	}
+	return ""
+}
+
+// logLevelNames returns the log levels accepted by LogLevelStringtoZap.
+func logLevelNames() []string {
+	return []string{"debug", "info", "warn", "error", "dpanic", "fatal"}
+}
+
+// Environment
+
+// envConfigReader reads configuration values from the environment, recording values which cannot be
+// parsed instead of silently using the default.
+type envConfigReader struct {
+	problems []ConfigProblem
+}
+
+// configFieldForEnv returns the ConfigContainer field set by an environment variable.
+func configFieldForEnv(key string) string {
+	fields := make([]string, 0, len(configSources))
+	for field := range configSources {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		if configSources[field].EnvVar == key {
+			return field
+		}
+	}
+	return key
+}
+
+func (r *envConfigReader) invalid(key, format string, args ...interface{}) {
+	problem := newConfigProblem(configFieldForEnv(key), format, args...)
+	problem.EnvVar = key
+	r.problems = append(r.problems, problem)
+}
+
+// bool gets the environment variable as a boolean or returns a default value
+func (r *envConfigReader) bool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		r.invalid(key, "%q is not a boolean, use true or false", valueStr)
+		return defaultValue
+	}
+	return value
+}
+
+// int gets the environment variable as an integer or returns a default value
+func (r *envConfigReader) int(key string, defaultValue int) int {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		r.invalid(key, "%q is not a whole number", valueStr)
+		return defaultValue
+	}
+	return value
+}
+
+// cookies gets the environment variable as a JSON array of custom cookies
+func (r *envConfigReader) cookies(key string) []CustomCookie {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return nil
+	}
+	var customCookies []CustomCookie
+	if err := json.Unmarshal([]byte(valueStr), &customCookies); err != nil {
+		r.invalid(key, `is not a JSON array of cookies such as [{"name": "jpro-ingress", "value": "..."}]: %v`, err)
+		return nil
+	}
+	return customCookies
+}
@@ -0,0 +1,469 @@
+// api_client_credential_providers.go
+// Credential providers allow BuildClient to fetch client secrets and passwords on demand rather than
+// reading them from plaintext configuration files or fixed environment variables. The provider is asked
+// again before every token request, so a secret rotated in the provider is picked up at the next token
+// refresh without rebuilding the client.
+package jamfpro
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Credential keys requested from a CredentialProvider. They match the ConfigContainer JSON keys.
+const (
+	CredentialKeyClientID     = "client_id"
+	CredentialKeyClientSecret = "client_secret"
+	CredentialKeyUsername     = "basic_auth_username"
+	CredentialKeyPassword     = "basic_auth_password"
+)
+
+const defaultKeyringService = "go-api-sdk-jamfpro"
+
+// execCredentialCacheTTL is how long a credential helper's answer is reused, long enough for the client ID
+// and secret of one token request to come from a single run.
+const execCredentialCacheTTL = 10 * time.Second
+
+// CredentialRequest describes a single credential needed by BuildClient.
+type CredentialRequest struct {
+	Key            string // one of the CredentialKey constants
+	InstanceDomain string
+	AuthMethod     string
+	Identity       string // client ID or username already known for this request, if any
+}
+
+// CredentialProvider supplies credentials which are missing from a ConfigContainer.
+type CredentialProvider interface {
+	GetCredential(request CredentialRequest) (string, error)
+}
+
+// CredentialProviderConfig declares a credential provider from a configuration file or environment variables.
+type CredentialProviderConfig struct {
+	Type         string   `json:"type"` // "file", "exec" or "keyring"
+	Path         string   `json:"path,omitempty"`
+	Command      []string `json:"command,omitempty"`
+	Service      string   `json:"service,omitempty"`
+	FallbackPath string   `json:"fallback_path,omitempty"`
+}
+
+// Build returns the CredentialProvider described by the configuration.
+func (p *CredentialProviderConfig) Build() (CredentialProvider, error) {
+	switch p.Type {
+	case "file":
+		return &FileCredentialProvider{Path: p.Path}, nil
+	case "exec":
+		if len(p.Command) == 0 {
+			return nil, fmt.Errorf("exec credential provider requires a command")
+		}
+		return &ExecCredentialProvider{Command: p.Command[0], Args: p.Command[1:]}, nil
+	case "keyring":
+		return &KeyringCredentialProvider{Service: p.Service, FallbackPath: p.FallbackPath}, nil
+	default:
+		return nil, fmt.Errorf("invalid credential provider type supplied: %s", p.Type)
+	}
+}
+
+// providedCredentialField links a credential key to the ConfigContainer field it populates and,
+// for secrets, the field holding the identity the secret belongs to.
+type providedCredentialField struct {
+	key      string
+	value    *string
+	identity *string
+}
+
+// credentialProvider returns the configured credential provider, or nil when there is none.
+func (config *ConfigContainer) credentialProvider() (CredentialProvider, error) {
+	if config.CredentialProvider != nil {
+		return config.CredentialProvider, nil
+	}
+	if config.CredentialProviderConfig != nil {
+		return config.CredentialProviderConfig.Build()
+	}
+	return nil, nil
+}
+
+// withProvidedCredentials returns a copy of the configuration with any credentials required by the
+// auth method, but absent from the configuration, fetched from the provider.
+func (config *ConfigContainer) withProvidedCredentials(provider CredentialProvider) (*ConfigContainer, error) {
+	resolved := *config
+	if provider == nil {
+		return &resolved, nil
+	}
+
+	var fields []providedCredentialField
+	switch config.AuthMethod {
+	case "oauth2":
+		fields = []providedCredentialField{
+			{key: CredentialKeyClientID, value: &resolved.ClientID},
+			{key: CredentialKeyClientSecret, value: &resolved.ClientSecret, identity: &resolved.ClientID},
+		}
+	case "basic":
+		fields = []providedCredentialField{
+			{key: CredentialKeyUsername, value: &resolved.Username},
+			{key: CredentialKeyPassword, value: &resolved.Password, identity: &resolved.Username},
+		}
+	}
+
+	for _, field := range fields {
+		if *field.value != "" {
+			continue
+		}
+
+		request := CredentialRequest{
+			Key:            field.key,
+			InstanceDomain: config.InstanceDomain,
+			AuthMethod:     config.AuthMethod,
+		}
+		if field.identity != nil {
+			request.Identity = *field.identity
+		}
+
+		value, err := provider.GetCredential(request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s from credential provider: %w", field.key, err)
+		}
+		*field.value = value
+	}
+
+	return &resolved, nil
+}
+
+// refreshProvidedCredentials returns an attempt interceptor which fetches the credentials again before
+// every token request and sends the current ones, so a rotated secret is used without rebuilding the
+// client. config is the configuration before its credentials were resolved. When the provider fails, the
+// token request is sent with the credentials it already carries.
+func refreshProvidedCredentials(config *ConfigContainer, provider CredentialProvider, logger *zap.SugaredLogger) attemptInterceptor {
+	return func(attempt *requestAttempt, next attemptHandler) (*http.Response, error) {
+		if !attempt.tokenRequest {
+			return next(attempt.req)
+		}
+
+		resolved, err := config.withProvidedCredentials(provider)
+		if err != nil {
+			logger.Warn("Failed to refresh credentials from credential provider, using the previous credentials", zap.Error(err))
+			return next(attempt.req)
+		}
+
+		switch config.AuthMethod {
+		case "oauth2":
+			if err := setTokenRequestForm(attempt.req, resolved.ClientID, resolved.ClientSecret); err != nil {
+				return nil, err
+			}
+		case "basic":
+			attempt.req.SetBasicAuth(resolved.Username, resolved.Password)
+		}
+		return next(attempt.req)
+	}
+}
+
+// setTokenRequestForm replaces the client ID and secret in an OAuth token request's form body.
+func setTokenRequestForm(req *http.Request, clientID, clientSecret string) error {
+	if req.Body == nil {
+		return nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read token request: %w", err)
+	}
+	form, err := url.ParseQuery(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse token request: %w", err)
+	}
+	form.Set("client_id", clientID)
+	form.Set("client_secret", clientSecret)
+
+	encoded := form.Encode()
+	req.Body = io.NopCloser(strings.NewReader(encoded))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(encoded)), nil
+	}
+	req.ContentLength = int64(len(encoded))
+	return nil
+}
+
+// File
+
+// FileCredentialProvider reads credentials from a JSON object keyed by the CredentialKey constants,
+// e.g. {"client_id": "...", "client_secret": "..."}. The file must not be accessible by group or other users.
+type FileCredentialProvider struct {
+	Path string
+}
+
+// GetCredential returns the requested key from the credentials file.
+func (p *FileCredentialProvider) GetCredential(request CredentialRequest) (string, error) {
+	values, err := readRestrictedCredentialFile(p.Path)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := values[request.Key]
+	if !ok || value == "" {
+		return "", fmt.Errorf("credential %s not found in %s", request.Key, p.Path)
+	}
+
+	return value, nil
+}
+
+// readRestrictedCredentialFile reads a JSON credential file after checking its permissions.
+func readRestrictedCredentialFile(path string) (map[string]string, error) {
+	resolvedPath, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve credential file: %w", err)
+	}
+
+	info, err := os.Stat(resolvedPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not stat credential file: %w", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("credential file %s has permissions %v, must not be accessible by group or others (e.g. chmod 600)", path, info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(resolvedPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read credential file: %w", err)
+	}
+
+	values := map[string]string{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("could not unmarshal credential file: %w", err)
+	}
+
+	return values, nil
+}
+
+// Exec
+
+// ExecCredentialProvider runs a helper command using the git-credential protocol. The command receives
+// protocol, host and (when known) username attributes on stdin followed by a blank line, and must print
+// key=value lines. The "username" attribute answers client ID and username requests, "password" answers
+// client secret and password requests. The action "get" is appended to Args.
+type ExecCredentialProvider struct {
+	Command string
+	Args    []string
+	Env     []string
+
+	mu     sync.Mutex
+	cached map[string]execCredentialResult
+}
+
+// execCredentialResult is a credential helper's answer and when it was given.
+type execCredentialResult struct {
+	attributes map[string]string
+	at         time.Time
+}
+
+// GetCredential runs the helper and returns the requested attribute. An answer is reused for requests
+// for the same instance and identity made within a few seconds of it.
+func (p *ExecCredentialProvider) GetCredential(request CredentialRequest) (string, error) {
+	attributes, err := p.run(request)
+	if err != nil {
+		return "", err
+	}
+
+	attribute := "password"
+	if request.Key == CredentialKeyClientID || request.Key == CredentialKeyUsername {
+		attribute = "username"
+	}
+
+	value, ok := attributes[attribute]
+	if !ok || value == "" {
+		return "", fmt.Errorf("credential helper %s returned no %s", p.Command, attribute)
+	}
+
+	return value, nil
+}
+
+// run executes the helper and parses its output, caching the result briefly for the same instance and
+// identity.
+func (p *ExecCredentialProvider) run(request CredentialRequest) (map[string]string, error) {
+	protocol, host := "https", request.InstanceDomain
+	if parsed, err := url.Parse(request.InstanceDomain); err == nil && parsed.Host != "" {
+		protocol, host = parsed.Scheme, parsed.Host
+	}
+
+	cacheKey := host + "\x00" + request.Identity
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if result, ok := p.cached[cacheKey]; ok && time.Since(result.at) < execCredentialCacheTTL {
+		return result.attributes, nil
+	}
+
+	var stdin bytes.Buffer
+	fmt.Fprintf(&stdin, "protocol=%s\nhost=%s\n", protocol, host)
+	if request.Identity != "" {
+		fmt.Fprintf(&stdin, "username=%s\n", request.Identity)
+	}
+	stdin.WriteString("\n")
+
+	cmd := exec.Command(p.Command, append(append([]string{}, p.Args...), "get")...)
+	cmd.Stdin = &stdin
+	cmd.Env = append(os.Environ(), p.Env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credential helper %s failed: %v: %s", p.Command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	attributes := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if found {
+			attributes[key] = value
+		}
+	}
+
+	if p.cached == nil {
+		p.cached = map[string]execCredentialResult{}
+	}
+	p.cached[cacheKey] = execCredentialResult{attributes: attributes, at: time.Now()}
+
+	return attributes, nil
+}
+
+// Keyring
+
+// KeyringCredentialProvider reads credentials from the OS keyring: the login keychain via `security` on macOS
+// and the Secret Service via `secret-tool` on Linux. Where no keyring is available, or the item is not found,
+// it falls back to a restricted JSON file at FallbackPath. Items are stored under Service with an account of
+// "<instance host>/<credential key>".
+type KeyringCredentialProvider struct {
+	Service      string
+	FallbackPath string
+}
+
+// GetCredential returns the requested credential from the keyring or the fallback file.
+func (p *KeyringCredentialProvider) GetCredential(request CredentialRequest) (string, error) {
+	account := keyringAccount(request.InstanceDomain, request.Key)
+
+	if value, err := p.lookupOSKeyring(account); err == nil && value != "" {
+		return value, nil
+	}
+
+	if p.FallbackPath == "" {
+		return "", fmt.Errorf("credential %s not found in keyring service %s", account, p.service())
+	}
+
+	values, err := readRestrictedCredentialFile(p.FallbackPath)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := values[account]
+	if !ok || value == "" {
+		return "", fmt.Errorf("credential %s not found in keyring or %s", account, p.FallbackPath)
+	}
+
+	return value, nil
+}
+
+// SetCredential stores a credential in the OS keyring, or in the fallback file when no keyring is available.
+func (p *KeyringCredentialProvider) SetCredential(instanceDomain, key, value string) error {
+	account := keyringAccount(instanceDomain, key)
+
+	if err := p.storeOSKeyring(account, value); err == nil {
+		return nil
+	} else if p.FallbackPath == "" {
+		return err
+	}
+
+	values := map[string]string{}
+	if _, err := os.Stat(p.FallbackPath); err == nil {
+		values, err = readRestrictedCredentialFile(p.FallbackPath)
+		if err != nil {
+			return err
+		}
+	}
+	values[account] = value
+
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(p.FallbackPath, data, 0600)
+}
+
+// service returns the keyring service name.
+func (p *KeyringCredentialProvider) service() string {
+	if p.Service == "" {
+		return defaultKeyringService
+	}
+	return p.Service
+}
+
+// lookupOSKeyring reads an item from the platform keyring.
+func (p *KeyringCredentialProvider) lookupOSKeyring(account string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", p.service(), "-a", account, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", p.service(), "account", account)
+	default:
+		return "", fmt.Errorf("no keyring support on %s", runtime.GOOS)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// storeOSKeyring writes an item to the platform keyring. The value is passed on stdin, never as an
+// argument, so it cannot be read from the process list.
+func (p *KeyringCredentialProvider) storeOSKeyring(account, value string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// security's interactive mode reads the command, and so the password, from stdin.
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+			securityQuote(p.service()), securityQuote(account), securityQuote(value)))
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label", p.service()+" "+account, "service", p.service(), "account", account)
+		cmd.Stdin = strings.NewReader(value)
+	default:
+		return fmt.Errorf("no keyring support on %s", runtime.GOOS)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to store credential in keyring: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// securityQuote quotes an argument for a command read by security's interactive mode.
+func securityQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// keyringAccount builds the keyring account name for an instance and credential key.
+func keyringAccount(instanceDomain, key string) string {
+	host := instanceDomain
+	if parsed, err := url.Parse(instanceDomain); err == nil && parsed.Host != "" {
+		host = parsed.Host
+	}
+	return host + "/" + key
+}
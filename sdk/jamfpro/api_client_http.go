@@ -0,0 +1,451 @@
+// api_client_http.go
+// HTTPClient wraps the go-api-http-client client so every SDK request passes through a chain of request
+// interceptors, and every HTTP attempt the client makes, including retries and token refreshes, passes
+// through a chain of attempt interceptors.
+//
+// The underlying client takes neither a context nor any per-request state, so each request is tagged with
+// a URL fragment naming its call. Fragments are never sent to the server; the executor strips the tag,
+// attaches the call's context to the attempt and hands both to the attempt interceptors.
+//
+// Tagging depends on go-api-http-client keeping the endpoint's fragment when it builds the request URL,
+// which it does as of v0.2. Should a later version drop it, attempts reach the attempt interceptors without
+// their call: requests still succeed, but lose their context, middleware headers, session affinity and
+// per-call telemetry. The client logs a warning the first time this happens.
+package jamfpro
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/deploymenttheory/go-api-http-client/httpclient"
+)
+
+const requestTagPrefix = "jamfpro-call-"
+
+// HTTPClient sends the SDK's requests. It embeds the underlying client, so its configuration and
+// integration remain accessible.
+type HTTPClient struct {
+	*httpclient.Client
+
+	ctx          context.Context
+	interceptors []requestInterceptor
+	tracker      *requestTracker
+	telemetry    *clientTelemetry
+	cache        *ResponseCache
+	names        *NameIndex
+	limiter      *AdaptiveLimiter
+	affinity     *SessionAffinity
+}
+
+// requestCall describes a single SDK request as it passes through the interceptors.
+type requestCall struct {
+	ctx        context.Context
+	method     string
+	endpoint   string
+	body       interface{}
+	out        interface{}
+	operation  string // SDK method making the request, e.g. GetPolicyByID
+	resource   string
+	resourceID string
+	attempts   int
+	statusCode int         // status of the last response received, even when the call failed
+	header     http.Header // headers added by middleware
+	formFields map[string]string
+	files      map[string][]string // multipart file paths by form field
+
+	send func(endpoint string) (*http.Response, error)
+}
+
+// requestAttempt is a single HTTP round trip made on behalf of a call. Call is nil for requests made
+// outside an SDK call, such as the initial token request.
+type requestAttempt struct {
+	call         *requestCall
+	req          *http.Request
+	number       int
+	tokenRequest bool
+	integration  bool // sent by the integration's executor
+}
+
+type requestHandler func(call *requestCall) (*http.Response, error)
+
+type requestInterceptor func(call *requestCall, next requestHandler) (*http.Response, error)
+
+type attemptHandler func(req *http.Request) (*http.Response, error)
+
+type attemptInterceptor func(attempt *requestAttempt, next attemptHandler) (*http.Response, error)
+
+// newHTTPClient wraps a built client. The tracker must be the one given to the client's executor and
+// integration.
+func newHTTPClient(client *httpclient.Client, tracker *requestTracker) *HTTPClient {
+	return &HTTPClient{Client: client, ctx: context.Background(), tracker: tracker, names: newNameIndex(defaultNameIndexTTL)}
+}
+
+// NewHTTPClient builds an HTTPClient from a go-api-http-client configuration, for callers which assemble
+// the client themselves rather than through New. The configuration's integration and executor are wrapped
+// so that requests are tracked per call, and the client looks up names and honours bulk dry runs as a
+// client from New does. Middleware, caching, telemetry, rate limiting and session affinity are only
+// available through New. The configuration is copied, not modified.
+func NewHTTPClient(config *httpclient.ClientConfig) (*HTTPClient, error) {
+	if config == nil || config.Integration == nil || config.HTTPExecutor == nil {
+		return nil, fmt.Errorf("an HTTP client configuration with an integration and an executor is required")
+	}
+
+	tracker := newRequestTracker()
+	tracker.use(recordBulkResponses)
+
+	tracked := *config
+	tracked.Integration = &trackedIntegration{APIIntegration: config.Integration, tracker: tracker}
+	tracked.HTTPExecutor = &trackedExecutor{HTTPExecutor: config.HTTPExecutor, tracker: tracker}
+
+	client, err := tracked.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build HTTP client: %w", err)
+	}
+
+	httpClient := newHTTPClient(client, tracker)
+	httpClient.use(bulkDryRun, httpClient.names.intercept)
+	return httpClient, nil
+}
+
+// WithContext returns a copy of the client whose requests use ctx, for cancellation and as the parent of
+// any trace spans.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	httpClient := *c.HTTP
+	httpClient.ctx = ctx
+
+	clone := *c
+	clone.HTTP = &httpClient
+	return &clone
+}
+
+// DoRequest sends a request through the interceptors and the underlying client.
+func (h *HTTPClient) DoRequest(method, endpoint string, body, out interface{}) (*http.Response, error) {
+	call := h.newCall(method, endpoint, body, out)
+	call.send = func(endpoint string) (*http.Response, error) {
+		return h.Client.DoRequest(call.method, endpoint, call.body, call.out)
+	}
+	return h.do(call)
+}
+
+// DoMultiPartRequest sends a multipart request through the interceptors and the underlying client.
+func (h *HTTPClient) DoMultiPartRequest(method, endpoint string, files map[string][]string, formDataFields map[string]string, fileContentTypes map[string]string, formDataPartHeaders map[string]http.Header, out interface{}) (*http.Response, error) {
+	call := h.newCall(method, endpoint, nil, out)
+	call.formFields, call.files = formDataFields, files
+	call.send = func(endpoint string) (*http.Response, error) {
+		return h.Client.DoMultiPartRequest(call.method, endpoint, call.files, call.formFields, fileContentTypes, formDataPartHeaders, call.out)
+	}
+	return h.do(call)
+}
+
+// context returns the context of the client's requests, which is unset on a client built by hand.
+func (h *HTTPClient) context() context.Context {
+	if h.ctx == nil {
+		return context.Background()
+	}
+	return h.ctx
+}
+
+func (h *HTTPClient) newCall(method, endpoint string, body, out interface{}) *requestCall {
+	ctx := h.context()
+	resource, resourceID := describeEndpoint(endpoint)
+	return &requestCall{
+		ctx:        ctx,
+		method:     method,
+		endpoint:   endpoint,
+		body:       body,
+		out:        out,
+		operation:  callerOperation(),
+		resource:   resource,
+		resourceID: resourceID,
+	}
+}
+
+// do runs the call through the interceptors, tagging it for the executor last.
+func (h *HTTPClient) do(call *requestCall) (*http.Response, error) {
+	handler := func(call *requestCall) (*http.Response, error) {
+		if err := call.ctx.Err(); err != nil {
+			return nil, err
+		}
+		if h.tracker == nil {
+			resp, err := call.send(call.endpoint)
+			if resp != nil {
+				call.statusCode = resp.StatusCode
+			}
+			return resp, err
+		}
+		call.statusCode = 0
+		tag := h.tracker.register(call)
+		defer h.tracker.release(tag)
+		resp, err := call.send(call.endpoint + "#" + tag)
+		if resp != nil && call.attempts == 0 {
+			h.tracker.untagged.Do(func() {
+				h.Sugar.Warnw("Request reached the executor without its call tag; per-call features are disabled", "endpoint", call.endpoint)
+			})
+		}
+		return resp, err
+	}
+
+	for i := len(h.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := h.interceptors[i], handler
+		handler = func(call *requestCall) (*http.Response, error) {
+			return interceptor(call, next)
+		}
+	}
+
+	return handler(call)
+}
+
+// use appends request interceptors. The first interceptor added is the outermost.
+func (h *HTTPClient) use(interceptors ...requestInterceptor) {
+	h.interceptors = append(h.interceptors, interceptors...)
+}
+
+// recordPages reports the number of pages a paginated listing fetched.
+func (h *HTTPClient) recordPages(endpoint string, pages int) {
+	if h.telemetry != nil {
+		h.telemetry.recordPages(h.ctx, endpoint, pages)
+	}
+}
+
+// Request tracking
+
+// requestTracker maps tagged requests back to their calls and runs the attempt interceptors. It is shared
+// by the executors of the client and the integration.
+type requestTracker struct {
+	next         atomic.Uint64
+	calls        sync.Map
+	authCall     atomic.Pointer[requestCall]
+	authMu       sync.RWMutex
+	untagged     sync.Once
+	interceptors []attemptInterceptor
+}
+
+func newRequestTracker() *requestTracker {
+	return &requestTracker{}
+}
+
+func (t *requestTracker) register(call *requestCall) string {
+	tag := fmt.Sprintf("%s%d", requestTagPrefix, t.next.Add(1))
+	t.calls.Store(tag, call)
+	return tag
+}
+
+func (t *requestTracker) release(tag string) {
+	t.calls.Delete(tag)
+}
+
+// lookup returns the call a request was tagged with, or nil.
+func (t *requestTracker) lookup(req *http.Request) *requestCall {
+	if req == nil || req.URL == nil || !strings.HasPrefix(req.URL.Fragment, requestTagPrefix) {
+		return nil
+	}
+	if call, ok := t.calls.Load(req.URL.Fragment); ok {
+		return call.(*requestCall)
+	}
+	return nil
+}
+
+// use appends attempt interceptors. The first interceptor added is the outermost.
+func (t *requestTracker) use(interceptors ...attemptInterceptor) {
+	t.interceptors = append(t.interceptors, interceptors...)
+}
+
+// roundTrip runs an attempt through the attempt interceptors and the executor.
+func (t *requestTracker) roundTrip(attempt *requestAttempt, send attemptHandler) (*http.Response, error) {
+	handler := send
+	for i := len(t.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := t.interceptors[i], handler
+		handler = func(req *http.Request) (*http.Response, error) {
+			attempt.req = req
+			return interceptor(attempt, next)
+		}
+	}
+	return handler(attempt.req)
+}
+
+// trackedExecutor resolves each request's call before sending it. The integration's executor, which
+// makes token requests, attributes them to the call whose request is being authorised.
+type trackedExecutor struct {
+	httpclient.HTTPExecutor
+	tracker     *requestTracker
+	integration bool
+}
+
+func (e *trackedExecutor) Do(req *http.Request) (*http.Response, error) {
+	attempt := &requestAttempt{req: req, integration: e.integration}
+
+	if call := e.tracker.lookup(req); call != nil {
+		call.attempts++
+		attempt.call = call
+		attempt.number = call.attempts
+
+		url := *req.URL
+		url.Fragment = ""
+		attempt.req = req.WithContext(call.ctx)
+		attempt.req.URL = &url
+	} else if e.integration {
+		attempt.call = e.tracker.authCall.Load()
+		attempt.tokenRequest = isTokenRequest(req)
+		if attempt.call != nil {
+			attempt.req = req.WithContext(attempt.call.ctx)
+		}
+	}
+
+	resp, err := e.tracker.roundTrip(attempt, e.HTTPExecutor.Do)
+	if !e.integration && attempt.call != nil && resp != nil {
+		attempt.call.statusCode = resp.StatusCode
+	}
+	return resp, err
+}
+
+// trackedIntegration refreshes the token before preparing each request, one refresh at a time, and
+// records which call it is refreshing for so the token request is attributed to it. Requests are then
+// prepared concurrently, reading the refreshed token.
+type trackedIntegration struct {
+	httpclient.APIIntegration
+	tracker *requestTracker
+}
+
+func (i *trackedIntegration) PrepRequestParamsAndAuth(req *http.Request) error {
+	if err := i.refreshToken(req); err != nil {
+		return err
+	}
+
+	i.tracker.authMu.RLock()
+	defer i.tracker.authMu.RUnlock()
+	return i.APIIntegration.PrepRequestParamsAndAuth(req)
+}
+
+// refreshToken refreshes the token when it is missing, expired or within its buffer period.
+func (i *trackedIntegration) refreshToken(req *http.Request) error {
+	i.tracker.authMu.Lock()
+	defer i.tracker.authMu.Unlock()
+
+	i.tracker.authCall.Store(i.tracker.lookup(req))
+	defer i.tracker.authCall.Store(nil)
+
+	return i.APIIntegration.CheckRefreshToken()
+}
+
+// isTokenRequest reports whether a request fetches an OAuth or bearer token.
+func isTokenRequest(req *http.Request) bool {
+	return req != nil && req.URL != nil &&
+		(strings.HasSuffix(req.URL.Path, "/api/oauth/token") || strings.HasSuffix(req.URL.Path, "/api/v1/auth/token"))
+}
+
+// Call description
+
+// clientMethodPrefix is the qualified name prefix of Client methods, e.g.
+// "github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro.(*Client).".
+var clientMethodPrefix = func() string {
+	name := runtime.FuncForPC(reflect.ValueOf((*Client).WithContext).Pointer()).Name()
+	return strings.TrimSuffix(name, "WithContext")
+}()
+
+// callerOperationSkip lists exported helpers which are not operations in their own right.
+var callerOperationSkip = map[string]bool{
+	"DoPaginatedGet":      true,
+	"DoPaginatedGetPages": true,
+	"WithContext":         true,
+}
+
+// callerOperations caches, per program counter, the operation its frames belong to, or "" for none.
+var callerOperations sync.Map
+
+// callerOperation returns the innermost exported Client method on the call stack. Frames are resolved
+// once per program counter, so only the first request from each call site pays for symbolisation.
+func callerOperation() string {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+
+	for _, pc := range pcs[:n] {
+		operation, ok := callerOperations.Load(pc)
+		if !ok {
+			operation, _ = callerOperations.LoadOrStore(pc, frameOperation(pc))
+		}
+		if operation != "" {
+			return operation.(string)
+		}
+	}
+	return ""
+}
+
+// frameOperation returns the innermost operation among the frames at pc, including inlined frames.
+func frameOperation(pc uintptr) string {
+	frames := runtime.CallersFrames([]uintptr{pc})
+	for {
+		frame, more := frames.Next()
+		if name, ok := strings.CutPrefix(frame.Function, clientMethodPrefix); ok {
+			name, _, _ = strings.Cut(name, ".")
+			if name != "" && unicode.IsUpper(rune(name[0])) && !callerOperationSkip[name] {
+				return name
+			}
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// describeEndpoint returns the resource and, when present, the resource ID or name an endpoint refers to.
+// "/JSSResource/policies/id/5" is ("policies", "5") and "/api/v1/computers-inventory-detail/12" is
+// ("computers-inventory-detail", "12").
+func describeEndpoint(endpoint string) (resource, id string) {
+	path, _, _ := strings.Cut(endpoint, "?")
+	path, _, _ = strings.Cut(path, "#")
+	segments := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+
+	if len(segments) >= 2 && segments[0] == "JSSResource" {
+		resource = segments[1]
+		for i := 2; i+1 < len(segments); i += 2 {
+			switch segments[i] {
+			case "id", "name", "udid", "serialnumber", "macaddress", "username", "email", "ip":
+				return resource, segments[i+1]
+			}
+		}
+		return resource, ""
+	}
+
+	if len(segments) >= 3 && segments[0] == "api" && isAPIVersion(segments[1]) {
+		resource = segments[2]
+		if len(segments) >= 4 && isResourceID(segments[3]) {
+			id = segments[3]
+		}
+		return resource, id
+	}
+
+	if len(segments) > 0 {
+		resource = segments[len(segments)-1]
+	}
+	return resource, ""
+}
+
+// isAPIVersion reports whether a path segment is a Jamf Pro API version, e.g. "v1".
+func isAPIVersion(segment string) bool {
+	if len(segment) < 2 || segment[0] != 'v' {
+		return false
+	}
+	for _, r := range segment[1:] {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isResourceID reports whether a path segment looks like a numeric ID or UUID.
+func isResourceID(segment string) bool {
+	for _, r := range segment {
+		if !unicode.IsDigit(r) && !unicode.Is(unicode.ASCII_Hex_Digit, r) && r != '-' {
+			return false
+		}
+	}
+	return strings.ContainsAny(segment, "0123456789")
+}
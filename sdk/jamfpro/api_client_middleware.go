@@ -0,0 +1,160 @@
+// api_client_middleware.go
+// Middleware wraps every request the SDK sends, whichever method sends it. A middleware sees the request's
+// method, endpoint, resource and body before it is sent and the decoded response after, and may add
+// headers, replace the body, or refuse the request by returning an error without calling next.
+//
+//	audit := jamfpro.AfterResponse(func(req *jamfpro.Request, resp *jamfpro.Response, err error) {
+//		log.Printf("%s %s %s: %d in %s", req.Operation, req.Method, req.Endpoint, resp.StatusCode, resp.Duration)
+//	})
+//	changeWindow := jamfpro.BeforeRequest(func(req *jamfpro.Request) error {
+//		if req.Method == http.MethodDelete && !inChangeWindow(time.Now()) {
+//			return fmt.Errorf("%s blocked outside the change window", req.Operation)
+//		}
+//		return nil
+//	})
+//	client, err := jamfpro.New(jamfpro.WithConfig(config), jamfpro.WithMiddleware(changeWindow, audit))
+package jamfpro
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+)
+
+// Request is an SDK request as seen by middleware. Changes to Context, Body, FormFields, Files and Header
+// are used when the request is sent; the other fields describe the request and are read only.
+type Request struct {
+	Context    context.Context
+	Method     string
+	Endpoint   string      // endpoint relative to the instance, e.g. /api/v1/categories/5
+	Operation  string      // SDK method making the request, e.g. GetCategoryByID
+	Resource   string      // e.g. categories
+	ResourceID string      // ID in the endpoint, if any
+	Body       interface{} // nil for multipart requests, which use FormFields and Files
+
+	// FormFields and Files are the form values and the paths of the files, by form field, sent by
+	// multipart requests such as package uploads. They are nil for other requests.
+	FormFields map[string]string
+	Files      map[string][]string
+
+	// Header is added to the request's headers, replacing any set by the SDK, including Authorization.
+	Header http.Header
+}
+
+// Response is the outcome of an SDK request as seen by middleware.
+type Response struct {
+	StatusCode int // status of the last response received, or 0 when none was received
+	Header     http.Header
+	Body       interface{} // response decoded into the SDK method's result type
+	Attempts   int         // HTTP attempts made, retries included
+	Duration   time.Duration
+}
+
+// Handler sends a request through the rest of the middleware and the client.
+type Handler func(req *Request) (*Response, error)
+
+// Middleware wraps SDK requests. It calls next to send the request, and must return an error when it
+// does not.
+type Middleware func(req *Request, next Handler) (*Response, error)
+
+// WithMiddleware adds middleware around every SDK request. The first middleware given is the outermost.
+// Middleware runs outside the response cache, so it also sees requests answered from the cache.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(o *clientOptions) error {
+		for _, m := range middleware {
+			if m == nil {
+				return fmt.Errorf("middleware cannot be nil")
+			}
+		}
+		o.middleware = append(o.middleware, middleware...)
+		return nil
+	}
+}
+
+// BeforeRequest returns middleware which runs hook before each request is sent. A request is not sent
+// when hook returns an error, which the SDK method returns.
+func BeforeRequest(hook func(req *Request) error) Middleware {
+	return func(req *Request, next Handler) (*Response, error) {
+		if err := hook(req); err != nil {
+			return nil, err
+		}
+		return next(req)
+	}
+}
+
+// AfterResponse returns middleware which runs hook after each request, whether or not it succeeded. The
+// response is never nil, but is empty when the request was refused by middleware inside this one.
+func AfterResponse(hook func(req *Request, resp *Response, err error)) Middleware {
+	return func(req *Request, next Handler) (*Response, error) {
+		resp, err := next(req)
+		if resp == nil {
+			resp = &Response{}
+		}
+		hook(req, resp, err)
+		return resp, err
+	}
+}
+
+// middlewareInterceptor adapts middleware to a request interceptor.
+func middlewareInterceptor(middleware Middleware) requestInterceptor {
+	return func(call *requestCall, next requestHandler) (*http.Response, error) {
+		req := &Request{
+			Context:    call.ctx,
+			Method:     call.method,
+			Endpoint:   call.endpoint,
+			Operation:  call.operation,
+			Resource:   call.resource,
+			ResourceID: call.resourceID,
+			Body:       call.body,
+			FormFields: maps.Clone(call.formFields),
+			Files:      maps.Clone(call.files),
+			Header:     call.header.Clone(),
+		}
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+
+		var httpResp *http.Response
+		sent := false
+		handler := func(req *Request) (*Response, error) {
+			if req.Context != nil {
+				call.ctx = req.Context
+			}
+			call.body = req.Body
+			call.formFields, call.files = req.FormFields, req.Files
+			call.header = req.Header
+
+			started := time.Now()
+			resp, err := next(call)
+			httpResp, sent = resp, true
+
+			response := &Response{StatusCode: call.statusCode, Body: call.out, Attempts: call.attempts, Duration: time.Since(started)}
+			if resp != nil {
+				response.StatusCode = resp.StatusCode
+				response.Header = resp.Header
+			}
+			return response, err
+		}
+
+		if _, err := middleware(req, handler); err != nil {
+			return httpResp, err
+		}
+		if !sent {
+			return nil, fmt.Errorf("middleware returned no response for %s %s", call.method, call.endpoint)
+		}
+		return httpResp, nil
+	}
+}
+
+// applyCallHeaders is an attempt interceptor which adds the headers set by middleware to each attempt of
+// their call. Token requests are left alone.
+func applyCallHeaders(attempt *requestAttempt, next attemptHandler) (*http.Response, error) {
+	if attempt.call != nil && !attempt.integration {
+		for name, values := range attempt.call.header {
+			attempt.req.Header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	return next(attempt.req)
+}
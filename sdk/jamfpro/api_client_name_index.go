@@ -0,0 +1,373 @@
+// api_client_name_index.go
+// NameIndex resolves resource names to IDs for the ByName methods of resources without a name endpoint,
+// such as most Jamf Pro API resources; Classic API resources with a /name/ endpoint use it directly. Each
+// resource's index is built from its list endpoint on first use and rebuilt once it expires, when a write
+// through the client touches the resource, or when a name is not found in an index more than 10 seconds
+// old. Updates and deletes by name always rebuild the index first, so they never act on a stale ID. Names
+// are matched without regard to case, as Jamf Pro does, and a name shared by more than one resource is
+// reported as a DuplicateNameError listing every ID rather than resolving to whichever is listed first.
+//
+// Each build fetches the resource's whole list, so the first lookup of a resource, a miss, and every
+// update or delete by name cost a list request in addition to the ByID request.
+package jamfpro
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultNameIndexTTL = 5 * time.Minute
+
+// nameIndexMissRebuildAge is how old an index must be before a name missing from it rebuilds it, so
+// repeated lookups of absent names do not list the resource each time.
+const nameIndexMissRebuildAge = 10 * time.Second
+
+// ErrNameNotFound is returned when no resource has the name looked up.
+var ErrNameNotFound = errors.New(errMsgNoName)
+
+// DuplicateNameError is returned when more than one resource of a type has the name looked up. Use the
+// ByID methods with one of the IDs instead.
+type DuplicateNameError struct {
+	Resource string
+	Name     string
+	IDs      []string
+}
+
+func (e *DuplicateNameError) Error() string {
+	return fmt.Sprintf("%d %s are named %q, IDs: %s", len(e.IDs), e.Resource, e.Name, strings.Join(e.IDs, ", "))
+}
+
+// NamedResource is a resource's ID and name as returned by its list endpoint.
+type NamedResource struct {
+	ID   string
+	Name string
+}
+
+// nameIndexSource describes how to list the names and IDs of a resource.
+type nameIndexSource struct {
+	list      func(c *Client) (interface{}, error)
+	field     string // list field holding the resources, when the response has more than one
+	nameField string // resource field holding the name, defaults to Name
+}
+
+// nameIndexSources maps the resources the index covers to their list methods. Keys are named after the
+// endpoint resource, so writes to it can be matched with relatedCacheResources.
+var nameIndexSources = map[string]nameIndexSource{
+	// Classic API
+	"accounts-users":                    {list: func(c *Client) (interface{}, error) { return c.GetAccounts() }, field: "Users"},
+	"accounts-groups":                   {list: func(c *Client) (interface{}, error) { return c.GetAccounts() }, field: "Groups"},
+	"advancedcomputersearches":          {list: func(c *Client) (interface{}, error) { return c.GetAdvancedComputerSearches() }},
+	"advancedmobiledevicesearches":      {list: func(c *Client) (interface{}, error) { return c.GetAdvancedMobileDeviceSearches() }},
+	"advancedusersearches":              {list: func(c *Client) (interface{}, error) { return c.GetAdvancedUserSearches() }},
+	"allowedfileextensions":             {list: func(c *Client) (interface{}, error) { return c.GetAllowedFileExtensions() }, nameField: "Extension"},
+	"byoprofiles":                       {list: func(c *Client) (interface{}, error) { return c.GetBYOProfiles() }},
+	"classes":                           {list: func(c *Client) (interface{}, error) { return c.GetClasses() }},
+	"computerextensionattributes":       {list: func(c *Client) (interface{}, error) { return c.GetComputerExtensionAttributes() }},
+	"computergroups":                    {list: func(c *Client) (interface{}, error) { return c.GetComputerGroups() }},
+	"computers":                         {list: func(c *Client) (interface{}, error) { return c.GetComputers() }},
+	"directorybindings":                 {list: func(c *Client) (interface{}, error) { return c.GetDirectoryBindings() }},
+	"diskencryptionconfigurations":      {list: func(c *Client) (interface{}, error) { return c.GetDiskEncryptionConfigurations() }},
+	"distributionpoints":                {list: func(c *Client) (interface{}, error) { return c.GetDistributionPoints() }},
+	"dockitems":                         {list: func(c *Client) (interface{}, error) { return c.GetDockItems() }},
+	"ebooks":                            {list: func(c *Client) (interface{}, error) { return c.GetEbooks() }},
+	"ibeacons":                          {list: func(c *Client) (interface{}, error) { return c.GetIBeacons() }},
+	"ldapservers":                       {list: func(c *Client) (interface{}, error) { return c.GetLDAPServers() }},
+	"licensedsoftware":                  {list: func(c *Client) (interface{}, error) { return c.GetLicensedSoftware() }},
+	"macapplications":                   {list: func(c *Client) (interface{}, error) { return c.GetMacApplications() }},
+	"mobiledeviceapplications":          {list: func(c *Client) (interface{}, error) { return c.GetMobileDeviceApplications() }},
+	"mobiledeviceconfigurationprofiles": {list: func(c *Client) (interface{}, error) { return c.GetMobileDeviceConfigurationProfiles() }},
+	"mobiledeviceenrollmentprofiles":    {list: func(c *Client) (interface{}, error) { return c.GetMobileDeviceEnrollmentProfiles() }},
+	"mobiledeviceextensionattributes":   {list: func(c *Client) (interface{}, error) { return c.GetMobileExtensionAttributes() }},
+	"mobiledevicegroups":                {list: func(c *Client) (interface{}, error) { return c.GetMobileDeviceGroups() }},
+	"mobiledeviceprovisioningprofiles":  {list: func(c *Client) (interface{}, error) { return c.GetMobileDeviceProvisioningProfiles() }},
+	"mobiledevices":                     {list: func(c *Client) (interface{}, error) { return c.GetMobileDevices() }},
+	"networksegments":                   {list: func(c *Client) (interface{}, error) { return c.GetNetworkSegments() }},
+	"osxconfigurationprofiles":          {list: func(c *Client) (interface{}, error) { return c.GetMacOSConfigurationProfiles() }},
+	"patchexternalsources":              {list: func(c *Client) (interface{}, error) { return c.GetPatchExternalSources() }},
+	"policies":                          {list: func(c *Client) (interface{}, error) { return c.GetPolicies() }},
+	"printers":                          {list: func(c *Client) (interface{}, error) { return c.GetPrinters() }},
+	"removablemacaddresses":             {list: func(c *Client) (interface{}, error) { return c.GetRemovableMACAddresses() }},
+	"restrictedsoftware":                {list: func(c *Client) (interface{}, error) { return c.GetRestrictedSoftwares() }},
+	"sites":                             {list: func(c *Client) (interface{}, error) { return c.GetSites() }},
+	"softwareupdateservers":             {list: func(c *Client) (interface{}, error) { return c.GetSoftwareUpdateServers() }},
+	"userextensionattributes":           {list: func(c *Client) (interface{}, error) { return c.GetUserExtensionAttributes() }},
+	"usergroups":                        {list: func(c *Client) (interface{}, error) { return c.GetUserGroups() }},
+	"users":                             {list: func(c *Client) (interface{}, error) { return c.GetUsers() }},
+	"webhooks":                          {list: func(c *Client) (interface{}, error) { return c.GetWebhooks() }},
+
+	// Jamf Pro API
+	"api-integrations":                    {list: func(c *Client) (interface{}, error) { return c.GetApiIntegrations("") }, nameField: "DisplayName"},
+	"api-roles":                           {list: func(c *Client) (interface{}, error) { return c.GetJamfAPIRoles("") }, nameField: "DisplayName"},
+	"buildings":                           {list: func(c *Client) (interface{}, error) { return c.GetBuildings("") }},
+	"categories":                          {list: func(c *Client) (interface{}, error) { return c.GetCategories("") }},
+	"computer-prestages":                  {list: func(c *Client) (interface{}, error) { return c.GetComputerPrestages("") }, nameField: "DisplayName"},
+	"departments":                         {list: func(c *Client) (interface{}, error) { return c.GetDepartments("") }},
+	"enrollment-access-groups":            {list: func(c *Client) (interface{}, error) { return c.GetAccountDrivenUserEnrollmentAccessGroups("") }},
+	"patch-software-title-configurations": {list: func(c *Client) (interface{}, error) { return c.GetPatchSoftwareTitleConfigurations() }, nameField: "DisplayName"},
+	"scripts":                             {list: func(c *Client) (interface{}, error) { return c.GetScripts("") }},
+	"self-service-branding-macos":         {list: func(c *Client) (interface{}, error) { return c.GetSelfServiceBrandingMacOS("") }, nameField: "BrandingName"},
+	"volume-purchasing-subscriptions":     {list: func(c *Client) (interface{}, error) { return c.GetVolumePurchasingSubscriptions("") }},
+}
+
+// NameIndexResources returns the resources the name index covers, for use with LookupIDByName.
+func NameIndexResources() []string {
+	return sortedConfigKeys(nameIndexSources)
+}
+
+// NameIndex holds name-to-ID maps for the resources in nameIndexSources. It is shared by a client and
+// the copies returned by WithContext. A nil NameIndex caches nothing, so every lookup lists the resource.
+type NameIndex struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*nameIndexEntry
+}
+
+// nameIndexEntry is the index of a single resource.
+type nameIndexEntry struct {
+	mu      sync.Mutex          // held while the entry is built
+	ids     map[string][]string // keyed by lower-case name
+	builtAt time.Time
+	stale   atomic.Bool
+}
+
+// WithNameIndexTTL sets how long a resource's name index is used before it is rebuilt from the list
+// endpoint, 5 minutes by default. A TTL of zero or less rebuilds it for every lookup.
+func WithNameIndexTTL(ttl time.Duration) Option {
+	return func(o *clientOptions) error {
+		o.nameIndexTTL = &ttl
+		return nil
+	}
+}
+
+// NameIndex returns the client's name index.
+func (c *Client) NameIndex() *NameIndex {
+	return c.HTTP.names
+}
+
+func newNameIndex(ttl time.Duration) *NameIndex {
+	return &NameIndex{ttl: ttl, entries: make(map[string]*nameIndexEntry)}
+}
+
+// LookupIDByName returns the ID of the resource with the given name, e.g. LookupIDByName("policies",
+// "Install Firefox"). Names are matched without regard to case. It returns ErrNameNotFound when no
+// resource has the name and a *DuplicateNameError when more than one does.
+func (c *Client) LookupIDByName(resource, name string) (string, error) {
+	return c.lookupIDByName(resource, name, false)
+}
+
+// lookupIDByNameForWrite resolves a name for an update or delete. The index is rebuilt first, so a
+// resource renamed, deleted or recreated since the last build is not written to by its old ID.
+func (c *Client) lookupIDByNameForWrite(resource, name string) (string, error) {
+	return c.lookupIDByName(resource, name, true)
+}
+
+func (c *Client) lookupIDByName(resource, name string, rebuild bool) (string, error) {
+	source, ok := nameIndexSources[resource]
+	if !ok {
+		return "", fmt.Errorf("name index does not cover %q, use one of %s", resource, strings.Join(NameIndexResources(), ", "))
+	}
+
+	names := c.HTTP.names
+	entry := names.entry(resource)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	built := false
+	if rebuild || entry.ids == nil || entry.stale.Load() || time.Since(entry.builtAt) >= names.expiry() {
+		if err := c.buildNameIndexEntry(entry, source); err != nil {
+			return "", err
+		}
+		built = true
+	}
+
+	key := strings.ToLower(name)
+	ids := entry.ids[key]
+	if len(ids) == 0 && !built && time.Since(entry.builtAt) >= nameIndexMissRebuildAge {
+		// The resource may have been created since the index was built, e.g. in the Jamf Pro UI.
+		if err := c.buildNameIndexEntry(entry, source); err != nil {
+			return "", err
+		}
+		ids = entry.ids[key]
+	}
+
+	switch len(ids) {
+	case 0:
+		return "", ErrNameNotFound
+	case 1:
+		return ids[0], nil
+	default:
+		return "", &DuplicateNameError{Resource: resource, Name: name, IDs: append([]string(nil), ids...)}
+	}
+}
+
+// buildNameIndexEntry lists a resource and rebuilds its index. The list skips the response cache, as the
+// index keeps its own expiry.
+func (c *Client) buildNameIndexEntry(entry *nameIndexEntry, source nameIndexSource) error {
+	entry.stale.Store(false)
+
+	list, err := source.list(c.WithContext(BypassCache(c.HTTP.context())))
+	if err != nil {
+		entry.ids = nil
+		return err
+	}
+
+	resources, err := namedResources(list, source.field, source.nameField)
+	if err != nil {
+		entry.ids = nil
+		return err
+	}
+
+	ids := make(map[string][]string, len(resources))
+	for _, resource := range resources {
+		key := strings.ToLower(resource.Name)
+		ids[key] = append(ids[key], resource.ID)
+	}
+	for _, matches := range ids {
+		sortIDs(matches)
+	}
+
+	entry.ids = ids
+	entry.builtAt = time.Now()
+	return nil
+}
+
+// Invalidate marks the index of a resource, and of resources related to it, to be rebuilt on next use.
+func (ix *NameIndex) Invalidate(resource string) {
+	if ix == nil {
+		return
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for indexed, entry := range ix.entries {
+		if relatedCacheResources(resource, indexed) {
+			entry.stale.Store(true)
+		}
+	}
+}
+
+// Clear marks every resource's index to be rebuilt on next use.
+func (ix *NameIndex) Clear() {
+	if ix == nil {
+		return
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	for _, entry := range ix.entries {
+		entry.stale.Store(true)
+	}
+}
+
+// entry returns the index of a resource. A nil index returns a new entry each time.
+func (ix *NameIndex) entry(resource string) *nameIndexEntry {
+	if ix == nil {
+		return &nameIndexEntry{}
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	entry, ok := ix.entries[resource]
+	if !ok {
+		entry = &nameIndexEntry{}
+		ix.entries[resource] = entry
+	}
+	return entry
+}
+
+// expiry returns how long an index is used before it is rebuilt.
+func (ix *NameIndex) expiry() time.Duration {
+	if ix == nil {
+		return 0
+	}
+	return ix.ttl
+}
+
+// intercept invalidates the index of resources written through the client.
+func (ix *NameIndex) intercept(call *requestCall, next requestHandler) (*http.Response, error) {
+	resp, err := next(call)
+	if call.method != http.MethodGet {
+		ix.Invalidate(call.resource)
+	}
+	return resp, err
+}
+
+// namedResources extracts the ID and name of each resource in a list response. The resources are the
+// elements of the response's slice field whose struct has an ID or Id field and the name field.
+func namedResources(list interface{}, field, nameField string) ([]NamedResource, error) {
+	if nameField == "" {
+		nameField = "Name"
+	}
+
+	v := reflect.ValueOf(list)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, fmt.Errorf("empty list response %T", list)
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unexpected list response %T", list)
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		if field != "" && v.Type().Field(i).Name != field {
+			continue
+		}
+		items := v.Field(i)
+		if items.Kind() != reflect.Slice || items.Type().Elem().Kind() != reflect.Struct {
+			continue
+		}
+		idField, ok := namedResourceIDField(items.Type().Elem(), nameField)
+		if !ok {
+			continue
+		}
+
+		resources := make([]NamedResource, 0, items.Len())
+		for j := 0; j < items.Len(); j++ {
+			item := items.Index(j)
+			resources = append(resources, NamedResource{
+				ID:   fmt.Sprint(item.FieldByName(idField).Interface()),
+				Name: item.FieldByName(nameField).String(),
+			})
+		}
+		return resources, nil
+	}
+
+	return nil, fmt.Errorf("no list of resources with an ID and %s found in %T", nameField, list)
+}
+
+// namedResourceIDField returns the ID field of a listed resource which has a string name field.
+func namedResourceIDField(item reflect.Type, nameField string) (string, bool) {
+	name, ok := item.FieldByName(nameField)
+	if !ok || name.Type.Kind() != reflect.String {
+		return "", false
+	}
+	for _, idField := range []string{"ID", "Id"} {
+		if id, ok := item.FieldByName(idField); ok {
+			switch id.Type.Kind() {
+			case reflect.String, reflect.Int, reflect.Int64:
+				return idField, true
+			}
+		}
+	}
+	return "", false
+}
+
+// sortIDs orders IDs numerically where possible.
+func sortIDs(ids []string) {
+	sort.SliceStable(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return ids[i] < ids[j]
+	})
+}
@@ -0,0 +1,323 @@
+// api_client_options.go
+// New builds a client from functional options. Options cover what a ConfigContainer cannot express:
+// an existing zap logger or slog handler, the HTTP client, transport, TLS and proxy settings used to
+// reach Jamf Pro, a User-Agent, a base URL override and a custom executor. BuildClient is New with
+// WithConfig.
+package jamfpro
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/deploymenttheory/go-api-http-client/httpclient"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Option configures a client built by New.
+type Option func(*clientOptions) error
+
+// clientOptions collects the settings applied by options.
+type clientOptions struct {
+	config *ConfigContainer
+
+	logger *zap.Logger
+
+	executor   httpclient.HTTPExecutor
+	httpClient *http.Client
+	transport  http.RoundTripper
+	tlsConfig  *tls.Config
+	proxy      func(*http.Request) (*url.URL, error)
+
+	userAgent string
+	baseURL   string
+
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+
+	cache         *CacheOptions
+	nameIndexTTL  *time.Duration
+	adaptiveLimit *AdaptiveLimitOptions
+	affinity      *SessionAffinityOptions
+	middleware    []Middleware
+}
+
+// WithConfig sets the configuration the client is built from. Other options take precedence over the
+// equivalent configuration fields.
+func WithConfig(config *ConfigContainer) Option {
+	return func(o *clientOptions) error {
+		if config == nil {
+			return fmt.Errorf("config cannot be nil")
+		}
+		o.config = config
+		return nil
+	}
+}
+
+// WithLogger sets the logger used by the client in place of one built from LogLevel and LogExportPath.
+func WithLogger(logger *zap.Logger) Option {
+	return func(o *clientOptions) error {
+		if logger == nil {
+			return fmt.Errorf("logger cannot be nil")
+		}
+		o.logger = logger
+		return nil
+	}
+}
+
+// WithSlogHandler sends the client's logs to a log/slog handler in place of a logger built from
+// LogLevel and LogExportPath. The handler decides which levels are logged.
+func WithSlogHandler(handler slog.Handler) Option {
+	return func(o *clientOptions) error {
+		if handler == nil {
+			return fmt.Errorf("slog handler cannot be nil")
+		}
+		o.logger = zap.New(newSlogCore(handler), zap.AddCaller())
+		return nil
+	}
+}
+
+// WithHTTPClient sets the http.Client used to reach Jamf Pro. The client is copied, and its cookie jar,
+// timeout and redirect policy are reapplied after go-api-http-client has set its own. A jar given here is
+// shared by API and token requests; without one, API requests use a new jar and token requests none.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *clientOptions) error {
+		if client == nil {
+			return fmt.Errorf("http client cannot be nil")
+		}
+		o.httpClient = client
+		return nil
+	}
+}
+
+// WithTransport sets the http.RoundTripper used to reach Jamf Pro.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *clientOptions) error {
+		if transport == nil {
+			return fmt.Errorf("transport cannot be nil")
+		}
+		o.transport = transport
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used to reach Jamf Pro, e.g. to trust a private CA. The
+// transport must be an *http.Transport.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *clientOptions) error {
+		if config == nil {
+			return fmt.Errorf("TLS config cannot be nil")
+		}
+		o.tlsConfig = config
+		return nil
+	}
+}
+
+// WithProxy sends requests through the proxy at proxyURL, e.g. "http://proxy.example.com:3128". The
+// transport must be an *http.Transport.
+func WithProxy(proxyURL string) Option {
+	return func(o *clientOptions) error {
+		parsed, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL %q: %w", proxyURL, err)
+		}
+		if parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q: scheme and host are required", proxyURL)
+		}
+		o.proxy = http.ProxyURL(parsed)
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request, including token requests.
+func WithUserAgent(userAgent string) Option {
+	return func(o *clientOptions) error {
+		if userAgent == "" {
+			return fmt.Errorf("user agent cannot be empty")
+		}
+		o.userAgent = userAgent
+		return nil
+	}
+}
+
+// WithBaseURL overrides the configured InstanceDomain, e.g. "https://yourinstance.jamfcloud.com".
+func WithBaseURL(baseURL string) Option {
+	return func(o *clientOptions) error {
+		parsed, err := url.Parse(baseURL)
+		if err != nil {
+			return fmt.Errorf("invalid base URL %q: %w", baseURL, err)
+		}
+		if parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("invalid base URL %q: scheme and host are required", baseURL)
+		}
+		o.baseURL = strings.TrimSuffix(baseURL, "/")
+		return nil
+	}
+}
+
+// WithExecutor sets the executor which sends requests, replacing the default executor wrapping an
+// http.Client. The same executor sends API and token requests, so they share the cookie jar which
+// go-api-http-client gives it, including any load balancer cookie. It cannot be combined with the HTTP
+// client, transport, TLS or proxy options.
+func WithExecutor(executor httpclient.HTTPExecutor) Option {
+	return func(o *clientOptions) error {
+		if executor == nil {
+			return fmt.Errorf("executor cannot be nil")
+		}
+		o.executor = executor
+		return nil
+	}
+}
+
+// WithTracerProvider enables OpenTelemetry spans for SDK calls, overriding ConfigContainer.TracerProvider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *clientOptions) error {
+		o.tracerProvider = provider
+		return nil
+	}
+}
+
+// WithMeterProvider enables OpenTelemetry metrics for SDK calls, overriding ConfigContainer.MeterProvider.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(o *clientOptions) error {
+		o.meterProvider = provider
+		return nil
+	}
+}
+
+// newClientOptions applies options over an empty configuration.
+func newClientOptions(opts []Option) (*clientOptions, error) {
+	o := &clientOptions{config: &ConfigContainer{}}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.executor != nil && (o.httpClient != nil || o.transport != nil || o.tlsConfig != nil || o.proxy != nil) {
+		return nil, fmt.Errorf("a custom executor cannot be combined with HTTP client, transport, TLS or proxy options")
+	}
+
+	return o, nil
+}
+
+// resolvedConfig returns a copy of the configuration with the options which override it applied.
+func (o *clientOptions) resolvedConfig() *ConfigContainer {
+	config := *o.config
+	if o.baseURL != "" {
+		config.InstanceDomain = o.baseURL
+	}
+	if o.tracerProvider != nil {
+		config.TracerProvider = o.tracerProvider
+	}
+	if o.meterProvider != nil {
+		config.MeterProvider = o.meterProvider
+	}
+	return &config
+}
+
+// buildLogger returns the configured logger, or builds one from LogLevel and LogExportPath.
+func (o *clientOptions) buildLogger(config *ConfigContainer) (*zap.Logger, error) {
+	if o.logger != nil {
+		return o.logger, nil
+	}
+
+	loggerConfig := zap.NewProductionConfig()
+	level, err := LogLevelStringtoZap(config.LogLevel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set log level: %v", err)
+	}
+	loggerConfig.Level = level
+
+	if config.LogExportPath != "" {
+		loggerConfig.OutputPaths = append(loggerConfig.OutputPaths, config.LogExportPath)
+	}
+
+	logger, err := loggerConfig.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build logger: %v", err)
+	}
+	return logger, nil
+}
+
+// newExecutor returns the executor for the client or its integration. Each gets its own copy of the
+// http.Client, as the client sets a cookie jar, timeout and redirect policy on its executor.
+func (o *clientOptions) newExecutor() (httpclient.HTTPExecutor, error) {
+	if o.executor != nil {
+		return o.executor, nil
+	}
+
+	client := &http.Client{}
+	if o.httpClient != nil {
+		copied := *o.httpClient
+		client = &copied
+	}
+
+	transport, err := o.buildTransport(client.Transport)
+	if err != nil {
+		return nil, err
+	}
+	client.Transport = transport
+
+	return &httpclient.ProdExecutor{Client: client}, nil
+}
+
+// reapplyHTTPClient restores the cookie jar, timeout and redirect policy of the client given to
+// WithHTTPClient on an executor built from it, as building the underlying client replaces them.
+func (o *clientOptions) reapplyHTTPClient(executor httpclient.HTTPExecutor) {
+	if o.executor != nil || o.httpClient == nil {
+		return
+	}
+	if o.httpClient.Jar != nil {
+		executor.SetCookieJar(o.httpClient.Jar)
+	}
+	if o.httpClient.Timeout > 0 {
+		executor.SetCustomTimeout(o.httpClient.Timeout)
+	}
+	if o.httpClient.CheckRedirect != nil {
+		checkRedirect := o.httpClient.CheckRedirect
+		executor.SetRedirectPolicy(&checkRedirect)
+	}
+}
+
+// buildTransport applies the transport, TLS and proxy options over the http.Client's transport.
+func (o *clientOptions) buildTransport(clientTransport http.RoundTripper) (http.RoundTripper, error) {
+	transport := clientTransport
+	if o.transport != nil {
+		transport = o.transport
+	}
+	if o.tlsConfig == nil && o.proxy == nil {
+		return transport, nil
+	}
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	base, ok := transport.(*http.Transport)
+	if !ok {
+		return nil, fmt.Errorf("TLS and proxy options require an *http.Transport, got %T", transport)
+	}
+
+	cloned := base.Clone()
+	if o.tlsConfig != nil {
+		cloned.TLSClientConfig = o.tlsConfig.Clone()
+	}
+	if o.proxy != nil {
+		cloned.Proxy = o.proxy
+	}
+	return cloned, nil
+}
+
+// setUserAgent replaces the User-Agent header of every attempt.
+func setUserAgent(userAgent string) attemptInterceptor {
+	return func(attempt *requestAttempt, next attemptHandler) (*http.Response, error) {
+		attempt.req.Header.Set("User-Agent", userAgent)
+		return next(attempt.req)
+	}
+}
@@ -0,0 +1,344 @@
+// api_client_pool.go
+// ClientPool manages clients for many Jamf Pro instances (tenants). Tenant configurations are loaded
+// from a directory or a single YAML file, clients are built lazily on first use and functions can be
+// run concurrently across all tenants or a tag-selected subset.
+package jamfpro
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultClientPoolConcurrency = 8
+
+// TenantConfig describes a single Jamf Pro instance managed by a ClientPool.
+type TenantConfig struct {
+	Name   string
+	Tags   []string
+	Config ConfigContainer
+}
+
+// TenantSelector selects tenants by name and/or tag. An empty selector matches every tenant.
+// A tenant matches when its name is listed in Names (if any) and it carries every tag in Tags.
+type TenantSelector struct {
+	Names []string
+	Tags  []string
+}
+
+// TenantResult holds the outcome of running a function against a single tenant.
+type TenantResult[T any] struct {
+	Tenant   string
+	Value    T
+	Err      error
+	Duration time.Duration
+}
+
+// ClientPool builds and caches a Client per tenant.
+type ClientPool struct {
+	// MaxConcurrency limits how many tenants RunAcrossTenants processes at once. Defaults to 8.
+	MaxConcurrency int
+
+	// BuildFunc builds a client from a tenant configuration. Defaults to BuildClient.
+	BuildFunc func(config *ConfigContainer) (*Client, error)
+
+	mu      sync.Mutex
+	names   []string
+	tenants map[string]*poolTenant
+}
+
+// poolTenant holds a tenant configuration and its lazily built client.
+type poolTenant struct {
+	config TenantConfig
+	mu     sync.Mutex
+	client *Client
+}
+
+// NewClientPool creates a pool from tenant configurations. Tenant names must be unique.
+func NewClientPool(tenants []TenantConfig) (*ClientPool, error) {
+	pool := &ClientPool{tenants: map[string]*poolTenant{}}
+	for _, tenant := range tenants {
+		if err := pool.AddTenant(tenant); err != nil {
+			return nil, err
+		}
+	}
+	return pool, nil
+}
+
+// LoadClientPoolFromDir creates a pool from every .json, .yaml and .yml file in dir. Each file holds one
+// ConfigContainer using the standard keys, plus optional "name" and "tags" keys. The name defaults to
+// the file name without its extension.
+func LoadClientPoolFromDir(dir string) (*ClientPool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read tenant directory: %w", err)
+	}
+
+	var tenants []TenantConfig
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read tenant file %s: %w", path, err)
+		}
+
+		var raw map[string]interface{}
+		if ext == ".json" {
+			err = json.Unmarshal(data, &raw)
+		} else {
+			err = yaml.Unmarshal(data, &raw)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not parse tenant file %s: %w", path, err)
+		}
+
+		tenant, err := tenantConfigFromMap(raw, strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+		if err != nil {
+			return nil, fmt.Errorf("invalid tenant file %s: %w", path, err)
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	return NewClientPool(tenants)
+}
+
+// LoadClientPoolFromYAML creates a pool from a single YAML file containing a "tenants" list. Each entry
+// holds the standard ConfigContainer keys plus a required "name" and optional "tags", e.g.
+//
+//	tenants:
+//	  - name: acme
+//	    tags: [prod, eu]
+//	    instance_domain: https://acme.jamfcloud.com
+//	    auth_method: oauth2
+//	    client_id: ...
+func LoadClientPoolFromYAML(path string) (*ClientPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read tenant file: %w", err)
+	}
+
+	var document struct {
+		Tenants []map[string]interface{} `yaml:"tenants"`
+	}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("could not parse tenant file: %w", err)
+	}
+
+	var tenants []TenantConfig
+	for i, raw := range document.Tenants {
+		tenant, err := tenantConfigFromMap(raw, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid tenant at index %d: %w", i, err)
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	return NewClientPool(tenants)
+}
+
+// tenantConfigFromMap converts a decoded tenant document into a TenantConfig, reusing the ConfigContainer JSON keys.
+func tenantConfigFromMap(raw map[string]interface{}, defaultName string) (TenantConfig, error) {
+	tenant := TenantConfig{Name: defaultName}
+
+	if name, ok := raw["name"].(string); ok && name != "" {
+		tenant.Name = name
+	}
+	if tenant.Name == "" {
+		return tenant, fmt.Errorf("tenant has no name")
+	}
+
+	if tags, ok := raw["tags"].([]interface{}); ok {
+		for _, tag := range tags {
+			tenant.Tags = append(tenant.Tags, fmt.Sprint(tag))
+		}
+	}
+
+	delete(raw, "name")
+	delete(raw, "tags")
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return tenant, err
+	}
+	if err := json.Unmarshal(data, &tenant.Config); err != nil {
+		return tenant, fmt.Errorf("could not unmarshal configuration: %w", err)
+	}
+
+	return tenant, nil
+}
+
+// AddTenant registers a tenant with the pool.
+func (p *ClientPool) AddTenant(tenant TenantConfig) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if tenant.Name == "" {
+		return fmt.Errorf("tenant has no name")
+	}
+	if p.tenants == nil {
+		p.tenants = map[string]*poolTenant{}
+	}
+	if _, exists := p.tenants[tenant.Name]; exists {
+		return fmt.Errorf("duplicate tenant name: %s", tenant.Name)
+	}
+
+	p.tenants[tenant.Name] = &poolTenant{config: tenant}
+	p.names = append(p.names, tenant.Name)
+	sort.Strings(p.names)
+
+	return nil
+}
+
+// Tenants returns the configurations of the tenants matching the selector, ordered by name.
+func (p *ClientPool) Tenants(selector TenantSelector) []TenantConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var out []TenantConfig
+	for _, name := range p.names {
+		tenant := p.tenants[name].config
+		if selector.matches(tenant) {
+			out = append(out, tenant)
+		}
+	}
+	return out
+}
+
+// Client returns the client for a tenant, building it on first use. Build failures are not cached.
+func (p *ClientPool) Client(name string) (*Client, error) {
+	p.mu.Lock()
+	tenant, ok := p.tenants[name]
+	build := p.BuildFunc
+	p.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown tenant: %s", name)
+	}
+	if build == nil {
+		build = BuildClient
+	}
+
+	tenant.mu.Lock()
+	defer tenant.mu.Unlock()
+
+	if tenant.client != nil {
+		return tenant.client, nil
+	}
+
+	config := tenant.config.Config
+	client, err := build(&config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build client for tenant %s: %w", name, err)
+	}
+	tenant.client = client
+
+	return client, nil
+}
+
+// matches reports whether a tenant satisfies the selector.
+func (s TenantSelector) matches(tenant TenantConfig) bool {
+	if len(s.Names) > 0 {
+		found := false
+		for _, name := range s.Names {
+			if name == tenant.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, want := range s.Tags {
+		found := false
+		for _, tag := range tenant.Tags {
+			if tag == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RunAcrossTenants runs fn concurrently for every tenant matching the selector and returns one result per
+// tenant, ordered by tenant name. Client build failures are reported as that tenant's error. Tenants which
+// have not started when ctx is cancelled are reported with the context error, and a panic in fn is reported
+// as that tenant's error.
+func RunAcrossTenants[T any](ctx context.Context, pool *ClientPool, selector TenantSelector, fn func(ctx context.Context, tenant TenantConfig, client *Client) (T, error)) []TenantResult[T] {
+	tenants := pool.Tenants(selector)
+	results := make([]TenantResult[T], len(tenants))
+
+	concurrency := pool.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultClientPoolConcurrency
+	}
+	semaphore := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, tenant := range tenants {
+		wg.Add(1)
+		go func(i int, tenant TenantConfig) {
+			defer wg.Done()
+			results[i].Tenant = tenant.Name
+
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				results[i].Err = ctx.Err()
+				return
+			}
+
+			if err := ctx.Err(); err != nil {
+				results[i].Err = err
+				return
+			}
+
+			start := time.Now()
+			defer func() {
+				results[i].Duration = time.Since(start)
+			}()
+
+			client, err := pool.Client(tenant.Name)
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+
+			results[i].Value, results[i].Err = runTenant(ctx, tenant, client, fn)
+		}(i, tenant)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// runTenant calls fn for a tenant, reporting a panic as the tenant's error so other tenants are unaffected.
+func runTenant[T any](ctx context.Context, tenant TenantConfig, client *Client, fn func(ctx context.Context, tenant TenantConfig, client *Client) (T, error)) (value T, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("tenant %s panicked: %v\n%s", tenant.Name, recovered, debug.Stack())
+		}
+	}()
+	return fn(ctx, tenant, client)
+}
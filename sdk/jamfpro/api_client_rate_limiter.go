@@ -0,0 +1,308 @@
+// api_client_rate_limiter.go
+// AdaptiveLimiter adjusts how many requests a client has in flight from the responses Jamf Pro sends
+// back. The limit grows by one request per round trip while responses arrive promptly, and is cut by a
+// factor when the instance answers 429 Too Many Requests or 503 Service Unavailable, or responds slower
+// than the latency threshold (additive increase, multiplicative decrease). A rejection also pauses every
+// request for the Retry-After period, or an exponential backoff when none is sent.
+//
+// The limiter sits in front of every HTTP attempt, including retries made by the underlying client, and
+// is shared by all methods and goroutines using the same client. Token requests are not limited.
+package jamfpro
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAdaptiveInitialLimit     = 4
+	defaultAdaptiveMinLimit         = 1
+	defaultAdaptiveMaxLimit         = 32
+	defaultAdaptiveLatencyThreshold = 5 * time.Second
+	defaultAdaptiveDecreaseFactor   = 0.5
+	defaultAdaptiveBaseBackoff      = time.Second
+	defaultAdaptiveMaxBackoff       = time.Minute
+
+	// latencySmoothing weights each response's latency in the moving average.
+	latencySmoothing = 0.2
+)
+
+// AdaptiveLimitOptions configures the adaptive limiter enabled by WithAdaptiveRateLimit.
+type AdaptiveLimitOptions struct {
+	// InitialLimit is the number of requests allowed in flight at first, 4 by default.
+	InitialLimit int
+
+	// MinLimit and MaxLimit bound the limit, 1 and 32 by default.
+	MinLimit int
+	MaxLimit int
+
+	// LatencyThreshold is the response time above which the instance is treated as overloaded and the
+	// limit is cut, 5 seconds by default.
+	LatencyThreshold time.Duration
+
+	// DecreaseFactor multiplies the limit on a rejection or slow response, 0.5 by default.
+	DecreaseFactor float64
+
+	// BaseBackoff is the pause after a rejection sent without Retry-After, doubled for each rejection in a
+	// row up to MaxBackoff. 1 second and 1 minute by default.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// AdaptiveLimitState is a snapshot of an adaptive limiter.
+type AdaptiveLimitState struct {
+	Limit    float64 // requests allowed in flight; the whole part is used
+	MinLimit int
+	MaxLimit int
+	InFlight int
+	Waiting  int
+
+	Latency      time.Duration // moving average of response times
+	BackoffUntil time.Time     // requests wait until then; zero when not backing off
+	RetryAfter   time.Duration // last Retry-After received
+
+	Requests  int64
+	Throttled int64 // 429 and 503 responses
+	Slow      int64 // responses slower than the latency threshold
+	Decreases int64
+}
+
+// BackingOff reports whether requests are paused after a rejection.
+func (s AdaptiveLimitState) BackingOff() bool {
+	return time.Now().Before(s.BackoffUntil)
+}
+
+func (s AdaptiveLimitState) String() string {
+	state := fmt.Sprintf("limit %.2f (%d-%d), %d in flight, %d waiting, latency %s",
+		s.Limit, s.MinLimit, s.MaxLimit, s.InFlight, s.Waiting, s.Latency.Round(time.Millisecond))
+	if s.BackingOff() {
+		state += fmt.Sprintf(", backing off for %s", time.Until(s.BackoffUntil).Round(time.Millisecond))
+	}
+	return state
+}
+
+// AdaptiveLimiter limits the requests a client has in flight, adapting the limit to the instance's
+// responses.
+type AdaptiveLimiter struct {
+	options AdaptiveLimitOptions
+
+	mu           sync.Mutex
+	changed      chan struct{} // closed and replaced whenever a waiting request may proceed
+	limit        float64
+	inFlight     int
+	waiting      int
+	latency      time.Duration
+	backoffUntil time.Time
+	retryAfter   time.Duration
+	rejections   int // rejections in a row
+	lastDecrease time.Time
+
+	requests  int64
+	throttled int64
+	slow      int64
+	decreases int64
+}
+
+// WithAdaptiveRateLimit enables the adaptive limiter. It works alongside the static concurrency and
+// request delay settings, which still apply to the underlying client.
+func WithAdaptiveRateLimit(options AdaptiveLimitOptions) Option {
+	return func(o *clientOptions) error {
+		if options.DecreaseFactor < 0 || options.DecreaseFactor >= 1 {
+			return fmt.Errorf("adaptive rate limit decrease factor must be between 0 and 1, got %v", options.DecreaseFactor)
+		}
+		if options.MinLimit < 0 || options.MaxLimit < 0 || options.InitialLimit < 0 {
+			return fmt.Errorf("adaptive rate limits cannot be negative")
+		}
+		o.adaptiveLimit = &options
+		return nil
+	}
+}
+
+// RateLimiter returns the client's adaptive limiter, or nil when adaptive rate limiting is not enabled.
+func (c *Client) RateLimiter() *AdaptiveLimiter {
+	return c.HTTP.limiter
+}
+
+// NewAdaptiveLimiter creates a limiter, filling in options left unset.
+func NewAdaptiveLimiter(options AdaptiveLimitOptions) *AdaptiveLimiter {
+	if options.MinLimit == 0 {
+		options.MinLimit = defaultAdaptiveMinLimit
+	}
+	if options.MaxLimit == 0 {
+		options.MaxLimit = defaultAdaptiveMaxLimit
+	}
+	if options.MaxLimit < options.MinLimit {
+		options.MaxLimit = options.MinLimit
+	}
+	if options.InitialLimit == 0 {
+		options.InitialLimit = defaultAdaptiveInitialLimit
+	}
+	if options.LatencyThreshold == 0 {
+		options.LatencyThreshold = defaultAdaptiveLatencyThreshold
+	}
+	if options.DecreaseFactor == 0 {
+		options.DecreaseFactor = defaultAdaptiveDecreaseFactor
+	}
+	if options.BaseBackoff == 0 {
+		options.BaseBackoff = defaultAdaptiveBaseBackoff
+	}
+	if options.MaxBackoff == 0 {
+		options.MaxBackoff = defaultAdaptiveMaxBackoff
+	}
+
+	return &AdaptiveLimiter{
+		options: options,
+		changed: make(chan struct{}),
+		limit:   math.Min(math.Max(float64(options.InitialLimit), float64(options.MinLimit)), float64(options.MaxLimit)),
+	}
+}
+
+// State returns a snapshot of the limiter.
+func (l *AdaptiveLimiter) State() AdaptiveLimitState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := AdaptiveLimitState{
+		Limit:      l.limit,
+		MinLimit:   l.options.MinLimit,
+		MaxLimit:   l.options.MaxLimit,
+		InFlight:   l.inFlight,
+		Waiting:    l.waiting,
+		Latency:    l.latency,
+		RetryAfter: l.retryAfter,
+		Requests:   l.requests,
+		Throttled:  l.throttled,
+		Slow:       l.slow,
+		Decreases:  l.decreases,
+	}
+	if time.Now().Before(l.backoffUntil) {
+		state.BackoffUntil = l.backoffUntil
+	}
+	return state
+}
+
+// intercept is an attempt interceptor which holds each attempt until the limiter allows it, then adapts
+// the limit to the response.
+func (l *AdaptiveLimiter) intercept(attempt *requestAttempt, next attemptHandler) (*http.Response, error) {
+	if attempt.tokenRequest {
+		return next(attempt.req)
+	}
+
+	if err := l.acquire(attempt.req.Context()); err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	resp, err := next(attempt.req)
+	l.release(resp, time.Since(started))
+	return resp, err
+}
+
+// acquire waits until a request may be sent: the limiter is not backing off and fewer requests than the
+// limit are in flight.
+func (l *AdaptiveLimiter) acquire(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waiting++
+	defer func() { l.waiting-- }()
+
+	for {
+		wait := time.Until(l.backoffUntil)
+		if wait <= 0 && l.inFlight < int(l.limit) {
+			l.inFlight++
+			l.requests++
+			return nil
+		}
+
+		changed := l.changed
+		l.mu.Unlock()
+		err := waitForChange(ctx, changed, wait)
+		l.mu.Lock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// waitForChange blocks until changed is closed, wait has passed when positive, or ctx is done.
+func waitForChange(ctx context.Context, changed <-chan struct{}, wait time.Duration) error {
+	var expired <-chan time.Time
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case <-changed:
+		return nil
+	case <-expired:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees the request's place and adapts the limit to its response.
+func (l *AdaptiveLimiter) release(resp *http.Response, latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	defer l.broadcast()
+
+	l.inFlight--
+	if resp == nil {
+		return // transport errors say nothing about the instance's load
+	}
+
+	if l.latency == 0 {
+		l.latency = latency
+	} else {
+		l.latency = time.Duration(latencySmoothing*float64(latency) + (1-latencySmoothing)*float64(l.latency))
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		l.throttled++
+		l.rejections++
+		l.decrease()
+
+		backoff := cappedBackoff(l.options.BaseBackoff, l.options.MaxBackoff, l.rejections)
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			l.retryAfter = retryAfter
+			backoff = min(retryAfter, l.options.MaxBackoff)
+		}
+		if until := time.Now().Add(backoff); until.After(l.backoffUntil) {
+			l.backoffUntil = until
+		}
+
+	case latency > l.options.LatencyThreshold:
+		l.slow++
+		l.rejections = 0
+		l.decrease()
+
+	default:
+		l.rejections = 0
+		l.limit = math.Min(l.limit+1/l.limit, float64(l.options.MaxLimit))
+	}
+}
+
+// decrease cuts the limit, at most once per round trip so a burst of rejections to requests sent together
+// counts as a single signal.
+func (l *AdaptiveLimiter) decrease() {
+	if time.Since(l.lastDecrease) < l.latency {
+		return
+	}
+	l.lastDecrease = time.Now()
+	l.decreases++
+	l.limit = math.Max(l.limit*l.options.DecreaseFactor, float64(l.options.MinLimit))
+}
+
+// broadcast wakes waiting requests to check whether they may proceed.
+func (l *AdaptiveLimiter) broadcast() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
@@ -0,0 +1,456 @@
+// api_client_session_affinity.go
+// SessionAffinity keeps a client's requests on a single Jamf Cloud node. Jamf Cloud routes requests by
+// the jpro-ingress cookie, and a resource written on one node may not yet be readable on another. The
+// cookie is chosen when the client is built and chosen again when:
+//
+//   - the load balancer sets a different cookie, as it does when the pinned node leaves the pool;
+//   - a request to the pinned node fails to connect, or is answered 502 or 504;
+//   - the cookie expires or is cleared.
+//
+// With VerifyReadAfterWrite, a GET answered 404 shortly after a create or update of the same resource is
+// retried, so a Create followed by a Get does not fail while the write reaches the node serving the read.
+// Writes are matched to reads by resource type and ID; a create's ID is taken from its response.
+package jamfpro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/deploymenttheory/go-api-http-client-integrations/jamf/jamfprointegration"
+	"github.com/deploymenttheory/go-api-http-client/httpclient"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultAffinityConsistencyWindow = 30 * time.Second
+	defaultAffinityReadRetries       = 3
+	defaultAffinityReadRetryDelay    = time.Second
+
+	// affinityRepinInterval is the least time between attempts to pin after one fails.
+	affinityRepinInterval = 5 * time.Second
+)
+
+// SessionAffinityOptions configures the session affinity enabled by JamfLoadBalancerLock or
+// WithSessionAffinity.
+type SessionAffinityOptions struct {
+	// VerifyReadAfterWrite retries a GET answered 404 within ConsistencyWindow of a create or update of
+	// the same resource.
+	VerifyReadAfterWrite bool
+
+	// ConsistencyWindow is how long after a write reads of the resource are verified, 30 seconds by
+	// default.
+	ConsistencyWindow time.Duration
+
+	// ReadRetries is how many times such a GET is retried, 3 by default.
+	ReadRetries int
+
+	// ReadRetryDelay is the wait before the first retry, doubled for each retry after it, 1 second by
+	// default.
+	ReadRetryDelay time.Duration
+}
+
+// SessionAffinityState is a snapshot of a client's session affinity.
+type SessionAffinityState struct {
+	Node      string    // jpro-ingress cookie value of the pinned node; empty when not pinned
+	PinnedAt  time.Time // when the current node was pinned
+	ExpiresAt time.Time // when the cookie expires; zero for session cookies
+
+	Repins          int64  // nodes pinned after the first
+	Rotations       int64  // cookie changes made by the load balancer
+	NodeFailures    int64  // requests to the pinned node which failed to connect or were answered 502 or 504
+	LastRepinReason string // why the node was last changed
+	LastError       error  // last failure to pin a node
+
+	ReadAfterWriteRetries  int64 // GETs retried after a 404 following a write
+	ReadAfterWriteFailures int64 // GETs which were still 404 after every retry
+}
+
+// SessionAffinity pins a client to a Jamf Cloud node and re-pins it when the node is lost.
+type SessionAffinity struct {
+	options SessionAffinityOptions
+	logger  *zap.SugaredLogger
+
+	cookieURL *url.URL
+	jar       cookieSetter
+	discover  func() ([]*http.Cookie, error)
+
+	repinMu sync.Mutex // serialises pinning
+
+	mu           sync.Mutex
+	state        SessionAffinityState
+	pending      string // reason the node must be re-pinned before the next request
+	nextPinAfter time.Time
+	writes       map[string]affinityWrite // keyed by affinityWriteKey
+}
+
+// cookieSetter is implemented by executors which keep a cookie jar.
+type cookieSetter interface {
+	SetCookies(u *url.URL, cookies []*http.Cookie)
+}
+
+// affinityWrite records the node which served the last write to a resource.
+type affinityWrite struct {
+	node string
+	at   time.Time
+}
+
+// affinityWriteKey identifies a resource by its type and ID, or name for Classic API name endpoints.
+func affinityWriteKey(resource, id string) string {
+	return resource + "/" + id
+}
+
+// WithSessionAffinity pins the client to a single Jamf Cloud node, as JamfLoadBalancerLock does, with
+// the given options.
+func WithSessionAffinity(options SessionAffinityOptions) Option {
+	return func(o *clientOptions) error {
+		if options.ConsistencyWindow < 0 || options.ReadRetries < 0 || options.ReadRetryDelay < 0 {
+			return fmt.Errorf("session affinity options cannot be negative")
+		}
+		o.affinity = &options
+		return nil
+	}
+}
+
+// SessionAffinity returns the client's session affinity, or nil when the client is not pinned to a node.
+func (c *Client) SessionAffinity() *SessionAffinity {
+	return c.HTTP.affinity
+}
+
+// newSessionAffinity creates session affinity, filling in options left unset. It pins nothing until
+// attached to the client's integration.
+func newSessionAffinity(options SessionAffinityOptions, logger *zap.SugaredLogger) *SessionAffinity {
+	if options.ConsistencyWindow == 0 {
+		options.ConsistencyWindow = defaultAffinityConsistencyWindow
+	}
+	if options.ReadRetries == 0 {
+		options.ReadRetries = defaultAffinityReadRetries
+	}
+	if options.ReadRetryDelay == 0 {
+		options.ReadRetryDelay = defaultAffinityReadRetryDelay
+	}
+	return &SessionAffinity{options: options, logger: logger, writes: make(map[string]affinityWrite)}
+}
+
+// attach sets the integration used to discover nodes and the executor whose cookie jar carries the
+// cookie.
+func (a *SessionAffinity) attach(integration httpclient.APIIntegration, executor httpclient.HTTPExecutor) error {
+	jamfIntegration, ok := integration.(*jamfprointegration.Integration)
+	if !ok {
+		return fmt.Errorf("integration is not of type *jamfprointegration.Integration")
+	}
+	cookieURL, err := url.Parse(integration.GetFQDN())
+	if err != nil {
+		return fmt.Errorf("invalid instance domain for load balancer cookie: %w", err)
+	}
+
+	a.cookieURL = cookieURL
+	a.jar = executor
+	a.discover = jamfIntegration.GetSessionCookies
+	return nil
+}
+
+// pinCookies chooses the initial node and adds its cookie to the client's custom cookies, replacing any
+// configured jpro-ingress cookie. When no node can be chosen the cookies are returned unchanged and the
+// node is chosen before the first request.
+func (a *SessionAffinity) pinCookies(customCookies []*http.Cookie) []*http.Cookie {
+	cookie, err := a.discoverNode()
+	if err != nil {
+		a.logger.Error("Failed to get session cookies for load balancer lock, retrying before the first request", zap.Error(err))
+		a.mu.Lock()
+		a.pending = "initial pin failed"
+		a.state.LastError = err
+		a.mu.Unlock()
+		return customCookies
+	}
+
+	a.mu.Lock()
+	a.pin(cookie, "")
+	a.mu.Unlock()
+
+	pinned := make([]*http.Cookie, 0, len(customCookies)+1)
+	for _, customCookie := range customCookies {
+		if customCookie.Name != jamfLoadBalancerCookieName {
+			pinned = append(pinned, customCookie)
+		}
+	}
+	return append(pinned, cookie)
+}
+
+// State returns a snapshot of the session affinity.
+func (a *SessionAffinity) State() SessionAffinityState {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.state
+}
+
+// Repin chooses a node again, e.g. after maintenance on the pinned node.
+func (a *SessionAffinity) Repin() error {
+	a.mu.Lock()
+	a.pending = "requested"
+	a.nextPinAfter = time.Time{}
+	a.mu.Unlock()
+	return a.repin()
+}
+
+// interceptAttempt is an attempt interceptor which re-pins the client when needed before the attempt is
+// sent, and watches the response for a lost node.
+func (a *SessionAffinity) interceptAttempt(attempt *requestAttempt, next attemptHandler) (*http.Response, error) {
+	if attempt.integration {
+		return next(attempt.req)
+	}
+
+	if a.needsRepin() {
+		if err := a.repin(); err != nil {
+			a.logger.Warn("Failed to re-pin load balancer node, sending request unpinned", zap.Error(err))
+		}
+	}
+
+	a.mu.Lock()
+	node := a.state.Node
+	a.mu.Unlock()
+
+	resp, err := next(attempt.req)
+	a.observe(node, resp, err)
+	return resp, err
+}
+
+// interceptCall is a request interceptor which records writes and retries reads which miss them.
+func (a *SessionAffinity) interceptCall(call *requestCall, next requestHandler) (*http.Response, error) {
+	resp, err := next(call)
+
+	switch call.method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		if err == nil {
+			a.recordWrite(call.resource, call.resourceID, responseResourceID(call.out))
+		}
+		return resp, err
+
+	case http.MethodGet:
+		if err == nil || call.statusCode != http.StatusNotFound || call.resourceID == "" {
+			return resp, err
+		}
+	default:
+		return resp, err
+	}
+
+	write, ok := a.recentWrite(call.resource, call.resourceID)
+	if !ok {
+		return resp, err
+	}
+
+	delay := a.options.ReadRetryDelay
+	for i := 0; i < a.options.ReadRetries && call.statusCode == http.StatusNotFound; i++ {
+		a.mu.Lock()
+		a.state.ReadAfterWriteRetries++
+		a.mu.Unlock()
+
+		if waitErr := waitForChange(call.ctx, nil, delay); waitErr != nil {
+			return resp, err
+		}
+		delay *= 2
+		resp, err = next(call)
+	}
+
+	if err != nil && call.statusCode == http.StatusNotFound {
+		a.mu.Lock()
+		a.state.ReadAfterWriteFailures++
+		node := a.state.Node
+		a.mu.Unlock()
+		a.logger.Warn("Resource written recently is still not found",
+			zap.String("resource", call.resource),
+			zap.String("endpoint", call.endpoint),
+			zap.Bool("node_changed_since_write", write.node != node))
+	}
+	return resp, err
+}
+
+// recordWrite records a write to a resource under each of its IDs. The "0" of a Classic API create
+// endpoint is not the resource's ID and is skipped.
+func (a *SessionAffinity) recordWrite(resource string, ids ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	write := affinityWrite{node: a.state.Node, at: time.Now()}
+	for _, id := range ids {
+		if id != "" && id != "0" {
+			a.writes[affinityWriteKey(resource, id)] = write
+		}
+	}
+}
+
+// responseResourceID returns the ID in a write's decoded response: its ID or Id field, or that of its
+// General subset. It returns "" when the response has none.
+func responseResourceID(out interface{}) string {
+	v := reflect.ValueOf(out)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for _, name := range []string{"ID", "Id"} {
+		field := v.FieldByName(name)
+		switch field.Kind() {
+		case reflect.String:
+			return field.String()
+		case reflect.Int, reflect.Int64:
+			if field.Int() != 0 {
+				return fmt.Sprint(field.Int())
+			}
+		}
+	}
+	if general := v.FieldByName("General"); general.IsValid() {
+		return responseResourceID(general.Interface())
+	}
+	return ""
+}
+
+// recentWrite returns the last write to a resource within the consistency window.
+func (a *SessionAffinity) recentWrite(resource, id string) (affinityWrite, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for written, write := range a.writes {
+		if time.Since(write.at) > a.options.ConsistencyWindow {
+			delete(a.writes, written)
+		}
+	}
+	write, ok := a.writes[affinityWriteKey(resource, id)]
+	return write, ok
+}
+
+// needsRepin reports whether the node must be chosen again before the next request.
+func (a *SessionAffinity) needsRepin() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.discover == nil || time.Now().Before(a.nextPinAfter) {
+		return false
+	}
+	if a.pending == "" && !a.state.ExpiresAt.IsZero() && time.Now().After(a.state.ExpiresAt) {
+		a.pending = "cookie expired"
+	}
+	return a.pending != ""
+}
+
+// repin chooses a node and sets its cookie in the client's cookie jar. Concurrent callers wait for a
+// single pin.
+func (a *SessionAffinity) repin() error {
+	a.repinMu.Lock()
+	defer a.repinMu.Unlock()
+
+	a.mu.Lock()
+	reason := a.pending
+	a.mu.Unlock()
+	if reason == "" {
+		return nil // pinned by another request
+	}
+
+	cookie, err := a.discoverNode()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err != nil {
+		a.state.LastError = err
+		a.nextPinAfter = time.Now().Add(affinityRepinInterval)
+		return err
+	}
+
+	previous := a.state.Node
+	a.jar.SetCookies(a.cookieURL, []*http.Cookie{cookie})
+	a.pin(cookie, reason)
+	a.logger.Info("Re-pinned load balancer node", zap.String("reason", reason), zap.Bool("node_changed", previous != cookie.Value))
+	return nil
+}
+
+// discoverNode asks the integration for the cookie of a node.
+func (a *SessionAffinity) discoverNode() (*http.Cookie, error) {
+	if a.discover == nil {
+		return nil, errors.New("session affinity is not attached to an integration")
+	}
+	cookies, err := a.discover()
+	if err != nil {
+		return nil, err
+	}
+	for _, cookie := range cookies {
+		if cookie.Name == jamfLoadBalancerCookieName && cookie.Value != "" {
+			return cookie, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s cookie was returned", jamfLoadBalancerCookieName)
+}
+
+// pin records a cookie as the pinned node. The caller holds a.mu.
+func (a *SessionAffinity) pin(cookie *http.Cookie, reason string) {
+	if a.state.Node != "" {
+		a.state.Repins++
+	}
+	if reason != "" {
+		a.state.LastRepinReason = reason
+	}
+	a.state.Node = cookie.Value
+	a.state.PinnedAt = time.Now()
+	a.state.ExpiresAt = cookieExpiry(cookie)
+	a.state.LastError = nil
+	a.pending = ""
+	a.nextPinAfter = time.Time{}
+}
+
+// observe checks a response from the node for signs it has been lost.
+func (a *SessionAffinity) observe(node string, resp *http.Response, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if resp == nil {
+		if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			a.state.NodeFailures++
+			a.pending = "node unreachable"
+		}
+		return
+	}
+
+	if resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusGatewayTimeout {
+		a.state.NodeFailures++
+		a.pending = fmt.Sprintf("node answered %d", resp.StatusCode)
+		return
+	}
+
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name != jamfLoadBalancerCookieName {
+			continue
+		}
+		if cookie.MaxAge < 0 || cookie.Value == "" {
+			a.pending = "cookie cleared by load balancer"
+			return
+		}
+		if cookie.Value != a.state.Node && node == a.state.Node {
+			// The cookie jar has already taken the new cookie, so later requests follow it.
+			a.state.Rotations++
+			a.pin(cookie, "cookie rotated by load balancer")
+			a.logger.Warn("Load balancer moved the session to another node; reads may not see earlier writes")
+		} else if cookie.Value == a.state.Node {
+			a.state.ExpiresAt = cookieExpiry(cookie)
+		}
+	}
+}
+
+// cookieExpiry returns when a cookie expires, or zero for a session cookie.
+func cookieExpiry(cookie *http.Cookie) time.Time {
+	if cookie.MaxAge > 0 {
+		return time.Now().Add(time.Duration(cookie.MaxAge) * time.Second)
+	}
+	if !cookie.Expires.IsZero() {
+		return cookie.Expires
+	}
+	return time.Time{}
+}
@@ -0,0 +1,200 @@
+// api_client_telemetry.go
+// OpenTelemetry instrumentation for SDK calls. When a tracer or meter provider is configured, every SDK
+// call is recorded as a span named after the SDK method, with a child span for each HTTP attempt it makes,
+// including retries and any token refresh needed to authorise it. Metrics record call and attempt latency,
+// response status codes, retries, token refreshes and the number of pages fetched by paginated listings.
+//
+// Calls made through a client returned by Client.WithContext become children of the span in that context.
+package jamfpro
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+const telemetryInstrumentationName = "github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+
+// Attribute keys, following the OpenTelemetry HTTP semantic conventions where one exists.
+const (
+	attributeOperation      = attribute.Key("jamfpro.operation")
+	attributeResource       = attribute.Key("jamfpro.resource")
+	attributeResourceID     = attribute.Key("jamfpro.resource.id")
+	attributeRetryCount     = attribute.Key("jamfpro.retry_count")
+	attributeHTTPMethod     = attribute.Key("http.request.method")
+	attributeHTTPStatusCode = attribute.Key("http.response.status_code")
+	attributeHTTPResend     = attribute.Key("http.request.resend_count")
+	attributeServerAddress  = attribute.Key("server.address")
+	attributeURLPath        = attribute.Key("url.path")
+	attributeErrorType      = attribute.Key("error.type")
+)
+
+// clientTelemetry holds the tracer and instruments of a client.
+type clientTelemetry struct {
+	tracer trace.Tracer
+
+	callDuration    metric.Float64Histogram
+	attemptDuration metric.Float64Histogram
+	retries         metric.Int64Counter
+	tokenRefreshes  metric.Int64Counter
+	paginationPages metric.Int64Histogram
+}
+
+// newClientTelemetry creates the instrumentation, or returns nil when neither provider is set.
+func newClientTelemetry(tracerProvider trace.TracerProvider, meterProvider metric.MeterProvider) (*clientTelemetry, error) {
+	if tracerProvider == nil && meterProvider == nil {
+		return nil, nil
+	}
+	if tracerProvider == nil {
+		tracerProvider = tracenoop.NewTracerProvider()
+	}
+	if meterProvider == nil {
+		meterProvider = metricnoop.NewMeterProvider()
+	}
+
+	meter := meterProvider.Meter(telemetryInstrumentationName)
+	t := &clientTelemetry{tracer: tracerProvider.Tracer(telemetryInstrumentationName)}
+
+	var err error
+	if t.callDuration, err = meter.Float64Histogram("jamfpro.client.operation.duration",
+		metric.WithDescription("Duration of SDK calls, including retries."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if t.attemptDuration, err = meter.Float64Histogram("http.client.request.duration",
+		metric.WithDescription("Duration of individual HTTP requests to Jamf Pro."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if t.retries, err = meter.Int64Counter("jamfpro.client.retries",
+		metric.WithDescription("HTTP requests retried by SDK calls."), metric.WithUnit("{retry}")); err != nil {
+		return nil, err
+	}
+	if t.tokenRefreshes, err = meter.Int64Counter("jamfpro.client.token_refreshes",
+		metric.WithDescription("Access token requests."), metric.WithUnit("{request}")); err != nil {
+		return nil, err
+	}
+	if t.paginationPages, err = meter.Int64Histogram("jamfpro.client.pagination.pages",
+		metric.WithDescription("Pages fetched by paginated listings."), metric.WithUnit("{page}")); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// interceptCall records a span and metrics for an SDK call.
+func (t *clientTelemetry) interceptCall(call *requestCall, next requestHandler) (*http.Response, error) {
+	name := call.operation
+	if name == "" {
+		name = call.method + " " + call.resource
+	}
+
+	attributes := []attribute.KeyValue{
+		attributeOperation.String(call.operation),
+		attributeResource.String(call.resource),
+		attributeHTTPMethod.String(call.method),
+	}
+	spanAttributes := append([]attribute.KeyValue{}, attributes...)
+	if call.resourceID != "" {
+		spanAttributes = append(spanAttributes, attributeResourceID.String(call.resourceID))
+	}
+
+	ctx, span := t.tracer.Start(call.ctx, "jamfpro."+name,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(spanAttributes...))
+	defer span.End()
+	call.ctx = ctx
+
+	start := time.Now()
+	resp, err := next(call)
+	elapsed := time.Since(start).Seconds()
+
+	var outcome []attribute.KeyValue
+	if resp != nil {
+		outcome = append(outcome, attributeHTTPStatusCode.Int(resp.StatusCode))
+	}
+	if err != nil {
+		outcome = append(outcome, attributeErrorType.String(errorType(resp, err)))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if retries := call.attempts - 1; retries > 0 {
+		span.SetAttributes(attributeRetryCount.Int(retries))
+		t.retries.Add(ctx, int64(retries), metric.WithAttributes(attributeOperation.String(call.operation), attributeResource.String(call.resource)))
+	}
+	span.SetAttributes(outcome...)
+	t.callDuration.Record(ctx, elapsed, metric.WithAttributes(append(attributes, outcome...)...))
+
+	return resp, err
+}
+
+// interceptAttempt records a child span and metrics for an HTTP attempt.
+func (t *clientTelemetry) interceptAttempt(attempt *requestAttempt, next attemptHandler) (*http.Response, error) {
+	req := attempt.req
+
+	name := "HTTP " + req.Method
+	if attempt.tokenRequest {
+		name = "jamfpro.token_refresh"
+	}
+
+	attributes := []attribute.KeyValue{
+		attributeHTTPMethod.String(req.Method),
+		attributeServerAddress.String(req.URL.Hostname()),
+	}
+	spanAttributes := append([]attribute.KeyValue{attributeURLPath.String(req.URL.Path)}, attributes...)
+	if attempt.number > 1 {
+		spanAttributes = append(spanAttributes, attributeHTTPResend.Int(attempt.number-1))
+	}
+
+	ctx, span := t.tracer.Start(req.Context(), name,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(spanAttributes...))
+	defer span.End()
+
+	start := time.Now()
+	resp, err := next(req.WithContext(ctx))
+	elapsed := time.Since(start).Seconds()
+
+	if resp != nil {
+		attributes = append(attributes, attributeHTTPStatusCode.Int(resp.StatusCode))
+		span.SetAttributes(attributeHTTPStatusCode.Int(resp.StatusCode))
+		if resp.StatusCode >= http.StatusBadRequest {
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		}
+	}
+	if err != nil {
+		attributes = append(attributes, attributeErrorType.String(errorType(resp, err)))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	t.attemptDuration.Record(ctx, elapsed, metric.WithAttributes(attributes...))
+	if attempt.tokenRequest {
+		t.tokenRefreshes.Add(ctx, 1, metric.WithAttributes(attributes...))
+	}
+
+	return resp, err
+}
+
+// recordPages records the number of pages a paginated listing fetched.
+func (t *clientTelemetry) recordPages(ctx context.Context, endpoint string, pages int) {
+	resource, _ := describeEndpoint(endpoint)
+	t.paginationPages.Record(ctx, int64(pages), metric.WithAttributes(attributeResource.String(resource)))
+}
+
+// errorType describes a failure for the error.type attribute: the status code when there was an error
+// response, the context error on cancellation, otherwise "_OTHER".
+func errorType(resp *http.Response, err error) string {
+	if resp != nil && resp.StatusCode >= http.StatusBadRequest {
+		return strconv.Itoa(resp.StatusCode)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err.Error()
+	}
+	return "_OTHER"
+}
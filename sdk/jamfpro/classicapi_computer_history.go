@@ -14,7 +14,7 @@ type ResourceComputerHistory struct {
 	General           ComputerHistorySubsetGeneralInfo     `json:"general" xml:"general"`
 	ComputerUsageLogs []ComputerHistorySubsetUsageLog      `json:"computer_usage_logs,omitempty" xml:"computer_usage_logs,omitempty"`
 	Audits            []ComputerHistorySubsetAudit         `json:"audits,omitempty" xml:"audits,omitempty"`
-	PolicyLogs        []ComputerHistorySubsetPolicyLog     `json:"policy_logs,omitempty" xml:"policy_logs,omitempty"`
+	PolicyLogs        []ComputerHistorySubsetPolicyDetails `json:"policy_logs,omitempty" xml:"policy_logs>policy_log,omitempty"`
 	CasperRemoteLogs  []ComputerHistorySubsetCasperRemote  `json:"casper_remote_logs,omitempty" xml:"casper_remote_logs,omitempty"`
 	ScreenSharingLogs []ComputerHistorySubsetScreenSharing `json:"screen_sharing_logs,omitempty" xml:"screen_sharing_logs,omitempty"`
 	CasperImagingLogs []ComputerHistorySubsetCasperImaging `json:"casper_imaging_logs,omitempty" xml:"casper_imaging_logs,omitempty"`
@@ -42,11 +42,6 @@ type ComputerHistorySubsetAudit struct {
 	Audit ComputerHistorySubsetEventDetails `json:"audit,omitempty" xml:"audit,omitempty"`
 }
 
-// ComputerHistorySubsetPolicyLog stores logs related to policies.
-type ComputerHistorySubsetPolicyLog struct {
-	PolicyLog ComputerHistorySubsetPolicyDetails `json:"policy_log,omitempty" xml:"policy_log,omitempty"`
-}
-
 // ComputerHistorySubsetCasperRemote stores logs for Casper remote actions.
 type ComputerHistorySubsetCasperRemote struct {
 	CasperRemoteLog ComputerHistorySubsetEventStatus `json:"casper_remote_log" xml:"casper_remote_log"`
@@ -90,15 +85,19 @@ type ComputerHistorySubsetEventDetails struct {
 	DateTimeUTC   string `json:"date_time_utc,omitempty" xml:"date_time_utc,omitempty"`
 }
 
-// ComputerHistorySubsetPolicyDetails defines the details for policy logs.
+// ComputerHistorySubsetPolicyDetails defines the details for policy logs. Jamf Pro reports when a run
+// completed in the date_completed fields; the date_time fields are kept for older responses.
 type ComputerHistorySubsetPolicyDetails struct {
-	PolicyID      int    `json:"policy_id,omitempty" xml:"policy_id,omitempty"`
-	PolicyName    string `json:"policy_name,omitempty" xml:"policy_name,omitempty"`
-	Username      string `json:"username,omitempty" xml:"username,omitempty"`
-	DateTime      string `json:"date_time,omitempty" xml:"date_time,omitempty"`
-	DateTimeEpoch int64  `json:"date_time_epoch,omitempty" xml:"date_time_epoch,omitempty"`
-	DateTimeUTC   string `json:"date_time_utc,omitempty" xml:"date_time_utc,omitempty"`
-	Status        string `json:"status,omitempty" xml:"status,omitempty"`
+	PolicyID           int    `json:"policy_id,omitempty" xml:"policy_id,omitempty"`
+	PolicyName         string `json:"policy_name,omitempty" xml:"policy_name,omitempty"`
+	Username           string `json:"username,omitempty" xml:"username,omitempty"`
+	DateCompleted      string `json:"date_completed,omitempty" xml:"date_completed,omitempty"`
+	DateCompletedEpoch int64  `json:"date_completed_epoch,omitempty" xml:"date_completed_epoch,omitempty"`
+	DateCompletedUTC   string `json:"date_completed_utc,omitempty" xml:"date_completed_utc,omitempty"`
+	DateTime           string `json:"date_time,omitempty" xml:"date_time,omitempty"`
+	DateTimeEpoch      int64  `json:"date_time_epoch,omitempty" xml:"date_time_epoch,omitempty"`
+	DateTimeUTC        string `json:"date_time_utc,omitempty" xml:"date_time_utc,omitempty"`
+	Status             string `json:"status,omitempty" xml:"status,omitempty"`
 }
 
 // ComputerHistorySubsetEventStatus defines a simple structure for logs with status and timestamps.
@@ -24,8 +24,8 @@ const uriEbooks = "/JSSResource/ebooks"
 
 // Struct to capture the XML response for ebooks list
 type ResponseEbooksList struct {
-	Size   int           `xml:"size"`
-	Ebooks EBookListItem `xml:"ebook"`
+	Size   int             `xml:"size"`
+	Ebooks []EBookListItem `xml:"ebook"`
 }
 
 type EBookListItem struct {
@@ -17,8 +17,8 @@ const uriDistributionPoints = "/JSSResource/distributionpoints"
 
 // Struct to capture the XML response for distribution points list
 type ResponseDistributionPointsList struct {
-	Size              int                       `xml:"size"`
-	DistributionPoint DistributionPointListItem `xml:"distribution_point"`
+	Size              int                         `xml:"size"`
+	DistributionPoint []DistributionPointListItem `xml:"distribution_point"`
 }
 
 type DistributionPointListItem struct {
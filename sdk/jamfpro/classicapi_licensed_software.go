@@ -25,6 +25,8 @@ type ResponseLicensedSoftwareList struct {
 }
 
 type LicensedSoftwareListItem struct {
+	ID   int    `xml:"id"`
+	Name string `xml:"name"`
 }
 
 // Resource
@@ -16,7 +16,6 @@ package jamfpro
 import (
 	"encoding/xml"
 	"fmt"
-	"strconv"
 )
 
 const uriMacOSConfigurationProfiles = "/JSSResource/osxconfigurationprofiles"
@@ -197,30 +196,12 @@ func (c *Client) GetMacOSConfigurationProfileByName(name string) (*ResourceMacOS
 
 // GetMacOSConfigurationProfileByNameByID retrieves the details of a macOS Configuration Profile by its name.
 func (c *Client) GetMacOSConfigurationProfileByNameByID(name string) (*ResourceMacOSConfigurationProfile, error) {
-
-	profilesList, err := c.GetMacOSConfigurationProfiles()
-	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedGet, "macOS configuration profiles", err)
-	}
-
-	var profileID string
-	for _, profile := range profilesList.Results {
-		if profile.Name == name {
-			profileID = strconv.Itoa(profile.ID)
-			break
-		}
-	}
-
-	if profileID == "0" {
-		return nil, fmt.Errorf(errMsgFailedGetByName, "macOS configuration profile", name, err)
-	}
-
-	detailedProfile, err := c.GetMacOSConfigurationProfileByID(profileID)
+	id, err := c.LookupIDByName("osxconfigurationprofiles", name)
 	if err != nil {
 		return nil, fmt.Errorf(errMsgFailedGetByName, "macOS configuration profile", name, err)
 	}
 
-	return detailedProfile, nil
+	return c.GetMacOSConfigurationProfileByID(id)
 }
 
 // CreateMacOSConfigurationProfile creates a new macOS Configuration Profile on the Jamf Pro server and returns the profile with its ID updated.
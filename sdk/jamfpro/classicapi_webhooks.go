@@ -177,6 +177,30 @@ func (c *Client) UpdateWebhookByName(name string, webhook *ResourceWebhook) (*Re
 	return &response, nil
 }
 
+// SetWebhookEnabledByID enables or disables a specific webhook by its ID, leaving its other settings,
+// including any password, unchanged.
+func (c *Client) SetWebhookEnabledByID(id string, enabled bool) error {
+	endpoint := fmt.Sprintf("%s/id/%s", uriWebhooks, id)
+
+	requestBody := struct {
+		XMLName xml.Name `xml:"webhook"`
+		Enabled bool     `xml:"enabled"`
+	}{
+		Enabled: enabled,
+	}
+
+	resp, err := c.HTTP.DoRequest("PUT", endpoint, &requestBody, nil)
+	if err != nil {
+		return fmt.Errorf(errMsgFailedUpdateByID, "webhook", id, err)
+	}
+
+	if resp != nil && resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	return nil
+}
+
 // DeleteWebhookByID deletes a specific webhook by its ID.
 func (c *Client) DeleteWebhookByID(id string) error {
 	endpoint := fmt.Sprintf("%s/id/%s", uriWebhooks, id)
@@ -85,18 +85,12 @@ func (c *Client) GetAccountDrivenUserEnrollmentAccessGroupByID(id string) (*Reso
 
 // GetAccountDrivenUserEnrollmentAccessGroupByName retrieves an Account Driven User Enrollment Access Group by its name
 func (c *Client) GetAccountDrivenUserEnrollmentAccessGroupByName(name string) (*ResourceAccountDrivenUserEnrollmentAccessGroup, error) {
-	accessGroupsList, err := c.GetAccountDrivenUserEnrollmentAccessGroups("")
+	id, err := c.LookupIDByName("enrollment-access-groups", name)
 	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "ADUE access group", err)
+		return nil, fmt.Errorf(errMsgFailedGetByName, "ADUE access group", name, err)
 	}
 
-	for _, group := range accessGroupsList.Results {
-		if group.Name == name {
-			return &group, nil
-		}
-	}
-
-	return nil, fmt.Errorf(errMsgFailedGetByName, "ADUE access group", name, errMsgNoName)
+	return c.GetAccountDrivenUserEnrollmentAccessGroupByID(id)
 }
 
 // Creates Account Driven User Enrollment Access Group from ResourceScript struct
@@ -135,18 +129,12 @@ func (c *Client) UpdateAccountDrivenUserEnrollmentAccessGroupByID(id string, gro
 
 // UpdateAccountDrivenUserEnrollmentAccessGroupByName updates an ADUE access group by resource name
 func (c *Client) UpdateAccountDrivenUserEnrollmentAccessGroupByName(targetName string, groupUpdate *ResourceAccountDrivenUserEnrollmentAccessGroup) (*ResourceAccountDrivenUserEnrollmentAccessGroup, error) {
-	target, err := c.GetAccountDrivenUserEnrollmentAccessGroupByName(targetName)
-
-	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedGetByName, "ADUE access group", targetName, err)
-	}
-
-	resp, err := c.UpdateAccountDrivenUserEnrollmentAccessGroupByID(target.ID, groupUpdate)
+	id, err := c.lookupIDByNameForWrite("enrollment-access-groups", targetName)
 	if err != nil {
 		return nil, fmt.Errorf(errMsgFailedUpdateByName, "ADUE access group", targetName, err)
 	}
 
-	return resp, nil
+	return c.UpdateAccountDrivenUserEnrollmentAccessGroupByID(id, groupUpdate)
 }
 
 // DeleteAccountDrivenUserEnrollmentAccessGroupByID deletes an ADUE access group with given id
@@ -167,16 +155,10 @@ func (c *Client) DeleteAccountDrivenUserEnrollmentAccessGroupByID(id string) err
 
 // DeleteAccountDrivenUserEnrollmentAccessGroupByName deletes an ADUE access group with given name, leverages GetAccountDrivenUserEnrollmentAccessGroupByName
 func (c *Client) DeleteAccountDrivenUserEnrollmentAccessGroupByName(targetName string) error {
-	target, err := c.GetAccountDrivenUserEnrollmentAccessGroupByName(targetName)
-	if err != nil {
-		return fmt.Errorf(errMsgFailedGetByName, "ADUE access group", targetName, err)
-	}
-
-	err = c.DeleteAccountDrivenUserEnrollmentAccessGroupByID(target.ID)
-
+	id, err := c.lookupIDByNameForWrite("enrollment-access-groups", targetName)
 	if err != nil {
 		return fmt.Errorf(errMsgFailedDeleteByName, "ADUE access group", targetName, err)
 	}
 
-	return nil
+	return c.DeleteAccountDrivenUserEnrollmentAccessGroupByID(id)
 }
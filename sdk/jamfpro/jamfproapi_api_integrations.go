@@ -7,7 +7,6 @@ package jamfpro
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/mitchellh/mapstructure"
 )
@@ -84,18 +83,12 @@ func (c *Client) GetApiIntegrationByID(id string) (*ResourceApiIntegration, erro
 
 // GetApiIntegrationNameByID fetches an API integration by its display name and then retrieves its details using its ID
 func (c *Client) GetApiIntegrationByName(name string) (*ResourceApiIntegration, error) {
-	integrations, err := c.GetApiIntegrations("")
+	id, err := c.LookupIDByName("api-integrations", name)
 	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "api integration", err)
-	}
-
-	for _, integration := range integrations.Results {
-		if integration.DisplayName == name {
-			return &integration, nil
-		}
+		return nil, fmt.Errorf(errMsgFailedGetByName, "api integration", name, err)
 	}
 
-	return nil, fmt.Errorf(errMsgFailedGetByName, "api integration", name, errMsgNoName)
+	return c.GetApiIntegrationByID(id)
 }
 
 // CreateApiIntegration creates a new API integration
@@ -134,18 +127,12 @@ func (c *Client) UpdateApiIntegrationByID(id string, integrationUpdate *Resource
 
 // UpdateApiIntegrationByName updates an API integration based on its display name
 func (c *Client) UpdateApiIntegrationByName(name string, integrationUpdate *ResourceApiIntegration) (*ResourceApiIntegration, error) {
-	target, err := c.GetApiIntegrationByName(name)
-	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedGetByName, "api integration", name, err)
-	}
-
-	target_id := strconv.Itoa(target.ID)
-	resp, err := c.UpdateApiIntegrationByID(target_id, integrationUpdate)
+	id, err := c.lookupIDByNameForWrite("api-integrations", name)
 	if err != nil {
 		return nil, fmt.Errorf(errMsgFailedUpdateByName, "api integration", name, err)
 	}
 
-	return resp, nil
+	return c.UpdateApiIntegrationByID(id, integrationUpdate)
 }
 
 // DeleteApiIntegrationByID deletes an API integration by its ID
@@ -166,19 +153,12 @@ func (c *Client) DeleteApiIntegrationByID(id string) error {
 
 // DeleteApiIntegrationByName deletes an API integration by its display name
 func (c *Client) DeleteApiIntegrationByName(name string) error {
-	target, err := c.GetApiIntegrationByName(name)
-	if err != nil {
-		return fmt.Errorf(errMsgFailedGetByName, "api integration", name, err)
-	}
-
-	target_id := strconv.Itoa(target.ID)
-
-	err = c.DeleteApiIntegrationByID(target_id)
+	id, err := c.lookupIDByNameForWrite("api-integrations", name)
 	if err != nil {
 		return fmt.Errorf(errMsgFailedDeleteByName, "api integration", name, err)
 	}
 
-	return nil
+	return c.DeleteApiIntegrationByID(id)
 }
 
 // Client Credentials
@@ -74,18 +74,12 @@ func (c *Client) GetJamfApiRoleByID(id string) (*ResourceAPIRole, error) {
 
 // GetJamfApiRolesNameById fetches a Jamf API role by its display name and then retrieves its details using its ID.
 func (c *Client) GetJamfApiRoleByName(name string) (*ResourceAPIRole, error) {
-	roles, err := c.GetJamfAPIRoles("")
+	id, err := c.LookupIDByName("api-roles", name)
 	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "api role", err)
-	}
-
-	for _, value := range roles.Results {
-		if value.DisplayName == name {
-			return &value, nil
-		}
+		return nil, fmt.Errorf(errMsgFailedGetByName, "api role", name, err)
 	}
 
-	return nil, fmt.Errorf(errMsgFailedGetByName, "api role", name, errMsgNoName)
+	return c.GetJamfApiRoleByID(id)
 }
 
 // CreateJamfApiRole creates a new Jamf API role
@@ -124,20 +118,12 @@ func (c *Client) UpdateJamfApiRoleByID(id string, roleUpdate *ResourceAPIRole) (
 
 // UpdateJamfApiRoleByName updates a Jamf API role based on its display name
 func (c *Client) UpdateJamfApiRoleByName(name string, roleUpdate *ResourceAPIRole) (*ResourceAPIRole, error) {
-	target, err := c.GetJamfApiRoleByName(name)
-
-	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedGetByName, "api role", name, err)
-	}
-
-	target_id := target.ID
-	resp, err := c.UpdateJamfApiRoleByID(target_id, roleUpdate)
-
+	id, err := c.lookupIDByNameForWrite("api-roles", name)
 	if err != nil {
 		return nil, fmt.Errorf(errMsgFailedUpdateByName, "api role", name, err)
 	}
 
-	return resp, nil
+	return c.UpdateJamfApiRoleByID(id, roleUpdate)
 }
 
 // DeleteJamfApiRoleByID deletes a Jamf API role by its ID
@@ -158,18 +144,10 @@ func (c *Client) DeleteJamfApiRoleByID(id string) error {
 
 // DeleteJamfApiRoleByName deletes a Jamf API role by its display name
 func (c *Client) DeleteJamfApiRoleByName(name string) error {
-	target, err := c.GetJamfApiRoleByName(name)
-	if err != nil {
-		return fmt.Errorf(errMsgFailedGetByName, "api role", name, err)
-	}
-
-	target_id := target.ID
-
-	err = c.DeleteJamfApiRoleByID(target_id)
-
+	id, err := c.lookupIDByNameForWrite("api-roles", name)
 	if err != nil {
 		return fmt.Errorf(errMsgFailedDeleteByName, "api role", name, err)
 	}
 
-	return nil
+	return c.DeleteJamfApiRoleByID(id)
 }
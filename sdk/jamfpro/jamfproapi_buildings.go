@@ -107,18 +107,12 @@ func (c *Client) GetBuildingByID(id string) (*ResourceBuilding, error) {
 
 // GetBuildingByNameByID retrieves a single building information by its name using GetBuildingByID.
 func (c *Client) GetBuildingByName(name string) (*ResourceBuilding, error) {
-	buildings, err := c.GetBuildings("")
+	id, err := c.LookupIDByName("buildings", name)
 	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "building", err)
-	}
-
-	for _, value := range buildings.Results {
-		if value.Name == name {
-			return &value, nil
-		}
+		return nil, fmt.Errorf(errMsgFailedGetByName, "building", name, err)
 	}
 
-	return nil, fmt.Errorf(errMsgFailedGetByName, "building", name, errMsgNoName)
+	return c.GetBuildingByID(id)
 }
 
 // CreateBuilding creates a new building in Jamf Pro
@@ -157,21 +151,12 @@ func (c *Client) UpdateBuildingByID(id string, buildingUpdate *ResourceBuilding)
 
 // UpdateBuildingByNameByID updates a building's information in Jamf Pro by its name.
 func (c *Client) UpdateBuildingByName(name string, buildingUpdate *ResourceBuilding) (*ResourceBuilding, error) {
-	target, err := c.GetBuildingByName(name)
-
-	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedGetByName, "building", name, err)
-	}
-
-	target_id := target.ID
-	resp, err := c.UpdateBuildingByID(target_id, buildingUpdate)
-
+	id, err := c.lookupIDByNameForWrite("buildings", name)
 	if err != nil {
 		return nil, fmt.Errorf(errMsgFailedUpdateByName, "building", name, err)
 	}
 
-	return resp, nil
-
+	return c.UpdateBuildingByID(id, buildingUpdate)
 }
 
 // DeleteBuildingByID deletes a building in Jamf Pro by its ID.
@@ -192,18 +177,12 @@ func (c *Client) DeleteBuildingByID(id string) error {
 
 // DeleteBuildingByNameByID deletes a building in Jamf Pro by its name.
 func (c *Client) DeleteBuildingByName(name string) error {
-	target, err := c.GetBuildingByName(name)
-	if err != nil {
-		return fmt.Errorf(errMsgFailedGetByName, "building", name, err)
-	}
-
-	target_id := target.ID
-	err = c.DeleteBuildingByID(target_id)
+	id, err := c.lookupIDByNameForWrite("buildings", name)
 	if err != nil {
 		return fmt.Errorf(errMsgFailedDeleteByName, "building", name, err)
 	}
 
-	return nil
+	return c.DeleteBuildingByID(id)
 }
 
 // DeleteMultipleBuildingsByID deletes multiple buildings in Jamf Pro by their IDs.
@@ -83,18 +83,12 @@ func (c *Client) GetCategoryByID(id string) (*ResourceCategory, error) {
 
 // GetCategoryNameByID retrieves a category by its name and then retrieves its details using its ID
 func (c *Client) GetCategoryByName(name string) (*ResourceCategory, error) {
-	categories, err := c.GetCategories("")
+	id, err := c.LookupIDByName("categories", name)
 	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "categories", err)
-	}
-
-	for _, value := range categories.Results {
-		if value.Name == name {
-			return &value, nil
-		}
+		return nil, fmt.Errorf(errMsgFailedGetByName, "category", name, err)
 	}
 
-	return nil, fmt.Errorf(errMsgFailedGetByName, "category", name, errMsgNoName)
+	return c.GetCategoryByID(id)
 }
 
 // CreateCategory creates a new category
@@ -133,19 +127,12 @@ func (c *Client) UpdateCategoryByID(id string, categoryUpdate *ResourceCategory)
 
 // UpdateCategoryByNameByID updates a category by its name and then updates its details using its ID.
 func (c *Client) UpdateCategoryByName(name string, categoryUpdate *ResourceCategory) (*ResponseCategoryCreateAndUpdate, error) {
-	category, err := c.GetCategoryByName(name)
-	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedGetByName, "category", name, err)
-	}
-
-	target_id := category.Id
-	resp, err := c.UpdateCategoryByID(target_id, categoryUpdate)
-
+	id, err := c.lookupIDByNameForWrite("categories", name)
 	if err != nil {
 		return nil, fmt.Errorf(errMsgFailedUpdateByName, "category", name, err)
 	}
 
-	return resp, nil
+	return c.UpdateCategoryByID(id, categoryUpdate)
 }
 
 // DeleteCategoryByID deletes a category by its ID
@@ -166,19 +153,12 @@ func (c *Client) DeleteCategoryByID(id string) error {
 
 // DeleteCategoryByNameByID deletes a category by its name after inferring its ID.
 func (c *Client) DeleteCategoryByName(name string) error {
-	category, err := c.GetCategoryByName(name)
-	if err != nil {
-		return fmt.Errorf(errMsgFailedGetByName, "category", name, err)
-	}
-
-	target_id := category.Id
-	err = c.DeleteCategoryByID(target_id)
-
+	id, err := c.lookupIDByNameForWrite("categories", name)
 	if err != nil {
 		return fmt.Errorf(errMsgFailedDeleteByName, "category", name, err)
 	}
 
-	return nil
+	return c.DeleteCategoryByID(id)
 }
 
 // DeleteMultipleCategoriesByID deletes multiple categories by their IDs
@@ -12,12 +12,129 @@ package jamfpro
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/mitchellh/mapstructure"
 )
 
 const uriComputersInventory = "/api/v1/computers-inventory"
 
+// Sections
+
+// ComputerInventorySection selects a sub-object of ResourceComputerInventory to be returned by the inventory
+// endpoints. When no section is requested, Jamf Pro returns only the GENERAL section.
+type ComputerInventorySection string
+
+const (
+	ComputerInventorySectionGeneral               ComputerInventorySection = "GENERAL"
+	ComputerInventorySectionDiskEncryption        ComputerInventorySection = "DISK_ENCRYPTION"
+	ComputerInventorySectionPurchasing            ComputerInventorySection = "PURCHASING"
+	ComputerInventorySectionApplications          ComputerInventorySection = "APPLICATIONS"
+	ComputerInventorySectionStorage               ComputerInventorySection = "STORAGE"
+	ComputerInventorySectionUserAndLocation       ComputerInventorySection = "USER_AND_LOCATION"
+	ComputerInventorySectionConfigurationProfiles ComputerInventorySection = "CONFIGURATION_PROFILES"
+	ComputerInventorySectionPrinters              ComputerInventorySection = "PRINTERS"
+	ComputerInventorySectionServices              ComputerInventorySection = "SERVICES"
+	ComputerInventorySectionHardware              ComputerInventorySection = "HARDWARE"
+	ComputerInventorySectionLocalUserAccounts     ComputerInventorySection = "LOCAL_USER_ACCOUNTS"
+	ComputerInventorySectionCertificates          ComputerInventorySection = "CERTIFICATES"
+	ComputerInventorySectionAttachments           ComputerInventorySection = "ATTACHMENTS"
+	ComputerInventorySectionPlugins               ComputerInventorySection = "PLUGINS"
+	ComputerInventorySectionPackageReceipts       ComputerInventorySection = "PACKAGE_RECEIPTS"
+	ComputerInventorySectionFonts                 ComputerInventorySection = "FONTS"
+	ComputerInventorySectionSecurity              ComputerInventorySection = "SECURITY"
+	ComputerInventorySectionOperatingSystem       ComputerInventorySection = "OPERATING_SYSTEM"
+	ComputerInventorySectionLicensedSoftware      ComputerInventorySection = "LICENSED_SOFTWARE"
+	ComputerInventorySectionIBeacons              ComputerInventorySection = "IBEACONS"
+	ComputerInventorySectionSoftwareUpdates       ComputerInventorySection = "SOFTWARE_UPDATES"
+	ComputerInventorySectionExtensionAttributes   ComputerInventorySection = "EXTENSION_ATTRIBUTES"
+	ComputerInventorySectionContentCaching        ComputerInventorySection = "CONTENT_CACHING"
+	ComputerInventorySectionGroupMemberships      ComputerInventorySection = "GROUP_MEMBERSHIPS"
+)
+
+// AllComputerInventorySections lists every section, for callers which need complete records.
+var AllComputerInventorySections = []ComputerInventorySection{
+	ComputerInventorySectionGeneral,
+	ComputerInventorySectionDiskEncryption,
+	ComputerInventorySectionPurchasing,
+	ComputerInventorySectionApplications,
+	ComputerInventorySectionStorage,
+	ComputerInventorySectionUserAndLocation,
+	ComputerInventorySectionConfigurationProfiles,
+	ComputerInventorySectionPrinters,
+	ComputerInventorySectionServices,
+	ComputerInventorySectionHardware,
+	ComputerInventorySectionLocalUserAccounts,
+	ComputerInventorySectionCertificates,
+	ComputerInventorySectionAttachments,
+	ComputerInventorySectionPlugins,
+	ComputerInventorySectionPackageReceipts,
+	ComputerInventorySectionFonts,
+	ComputerInventorySectionSecurity,
+	ComputerInventorySectionOperatingSystem,
+	ComputerInventorySectionLicensedSoftware,
+	ComputerInventorySectionIBeacons,
+	ComputerInventorySectionSoftwareUpdates,
+	ComputerInventorySectionExtensionAttributes,
+	ComputerInventorySectionContentCaching,
+	ComputerInventorySectionGroupMemberships,
+}
+
+// Query
+
+// ComputerInventoryQuery selects the sections, filter and sort order of a computer inventory request.
+type ComputerInventoryQuery struct {
+	// Sections to return. Jamf Pro returns only GENERAL when empty.
+	Sections []ComputerInventorySection
+
+	// Filter is an RSQL expression, e.g. `general.platform=="Mac" and operatingSystem.version=ge="14"`.
+	Filter string
+
+	// Sort lists sort fields with optional direction, e.g. "general.name:asc", "udid:desc".
+	Sort []string
+
+	// PageSize is the number of computers fetched per request, up to 2000. Defaults to 200.
+	PageSize int
+}
+
+// encode renders the query as URL parameters to append to a paginated request.
+func (q ComputerInventoryQuery) encode() string {
+	params := url.Values{}
+	for _, section := range q.Sections {
+		params.Add("section", string(section))
+	}
+	if len(q.Sort) > 0 {
+		params.Set("sort", strings.Join(q.Sort, ","))
+	}
+	if q.Filter != "" {
+		params.Set("filter", q.Filter)
+	}
+
+	if len(params) == 0 {
+		return ""
+	}
+	return "&" + params.Encode()
+}
+
+// pageSize returns the effective page size of the query.
+func (q ComputerInventoryQuery) pageSize() int {
+	switch {
+	case q.PageSize <= 0:
+		return standardPageSize
+	case q.PageSize > maxPageSize:
+		return maxPageSize
+	default:
+		return q.PageSize
+	}
+}
+
+// RSQLQuote quotes a value for use in an RSQL filter expression.
+func RSQLQuote(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+	return `"` + escaped + `"`
+}
+
 // List
 
 // ResponseComputerInventoryList represents the top-level JSON response structure.
@@ -533,12 +650,13 @@ type ResponseUploadAttachment struct {
 // CRUD
 
 // GetComputersInventory retrieves all computer inventory information with optional sorting and section filters.
+// sort_filter holds further query parameters, e.g. "section=HARDWARE&sort=general.name:asc".
 func (c *Client) GetComputersInventory(sort_filter string) (*ResponseComputerInventoryList, error) {
 	resp, err := c.DoPaginatedGet(
 		uriComputersInventory,
 		standardPageSize,
 		startingPageNumber,
-		"",
+		sort_filter,
 	)
 	if err != nil {
 		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "computers-inventories", err)
@@ -559,6 +677,54 @@ func (c *Client) GetComputersInventory(sort_filter string) (*ResponseComputerInv
 	return &out, nil
 }
 
+// GetComputersInventoryWithQuery retrieves computer inventory restricted to the sections, filter and sort order of the query.
+func (c *Client) GetComputersInventoryWithQuery(query ComputerInventoryQuery) (*ResponseComputerInventoryList, error) {
+	var out ResponseComputerInventoryList
+
+	totalCount, err := c.ForEachComputerInventory(query, func(inventory ResourceComputerInventory) error {
+		out.Results = append(out.Results, inventory)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out.TotalCount = totalCount
+	return &out, nil
+}
+
+// ForEachComputerInventory streams computer inventory matching the query to fn one page at a time, so only a
+// single page is held in memory. It returns the total number of matching computers reported by Jamf Pro.
+// Returning an error from fn stops the iteration and returns that error.
+func (c *Client) ForEachComputerInventory(query ComputerInventoryQuery, fn func(inventory ResourceComputerInventory) error) (int, error) {
+	var totalCount int
+
+	err := c.DoPaginatedGetPages(
+		uriComputersInventory,
+		query.pageSize(),
+		startingPageNumber,
+		query.encode(),
+		func(page *StandardPaginatedResponse) error {
+			totalCount = page.Size
+			for _, value := range page.Results {
+				var newObj ResourceComputerInventory
+				if err := mapstructure.Decode(value, &newObj); err != nil {
+					return fmt.Errorf(errMsgFailedMapstruct, "computer-inventory", err)
+				}
+				if err := fn(newObj); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return totalCount, fmt.Errorf(errMsgFailedPaginatedGet, "computers-inventories", err)
+	}
+
+	return totalCount, nil
+}
+
 // GetComputerInventoryByID retrieves a specific computer's inventory information by its ID.
 func (c *Client) GetComputerInventoryByID(id string) (*ResourceComputerInventory, error) {
 	endpoint := fmt.Sprintf("%s/%s", uriComputersInventory, id)
@@ -577,20 +743,56 @@ func (c *Client) GetComputerInventoryByID(id string) (*ResourceComputerInventory
 	return &responseInventory, nil
 }
 
+// GetComputerInventoryByIDWithSections retrieves the requested sections of a specific computer's inventory by its ID.
+func (c *Client) GetComputerInventoryByIDWithSections(id string, sections ...ComputerInventorySection) (*ResourceComputerInventory, error) {
+	endpoint := fmt.Sprintf("%s/%s", uriComputersInventory, id)
+	if query := (ComputerInventoryQuery{Sections: sections}).encode(); query != "" {
+		endpoint += "?" + strings.TrimPrefix(query, "&")
+	}
+
+	var responseInventory ResourceComputerInventory
+	resp, err := c.HTTP.DoRequest("GET", endpoint, nil, &responseInventory)
+	if err != nil {
+		return nil, fmt.Errorf(errMsgFailedGetByID, "computer inventory", id, err)
+	}
+
+	if resp != nil && resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	return &responseInventory, nil
+}
+
 // GetComputerInventoryByName retrieves a specific computer's inventory information by its name.
+// The name is matched server side with a filter rather than by listing the whole inventory.
 func (c *Client) GetComputerInventoryByName(name string) (*ResourceComputerInventory, error) {
-	inventories, err := c.GetComputersInventory("")
+	inventories, err := c.GetComputersInventoryWithQuery(ComputerInventoryQuery{
+		Filter: "general.name==" + RSQLQuote(name),
+	})
 	if err != nil {
 		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "computer inventory", err)
 	}
 
+	var matches []ResourceComputerInventory
 	for _, inventory := range inventories.Results {
 		if inventory.General.Name == name {
-			return &inventory, nil
+			matches = append(matches, inventory)
 		}
 	}
 
-	return nil, fmt.Errorf(errMsgFailedGetByName, "computer inventory", name, err)
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf(errMsgFailedGetByName, "computer inventory", name, ErrNameNotFound)
+	case 1:
+		return &matches[0], nil
+	}
+
+	ids := make([]string, len(matches))
+	for i, inventory := range matches {
+		ids[i] = inventory.ID
+	}
+	sortIDs(ids)
+	return nil, fmt.Errorf(errMsgFailedGetByName, "computer inventory", name, &DuplicateNameError{Resource: "computers-inventory", Name: name, IDs: ids})
 }
 
 // UpdateComputerInventoryByID updates a specific computer's inventory information by its ID.
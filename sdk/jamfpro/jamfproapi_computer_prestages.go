@@ -202,18 +202,12 @@ func (c *Client) GetComputerPrestageByID(id string) (*ResourceComputerPrestage,
 
 // GetComputerPrestageByName retrieves a specific computer prestage by its name.
 func (c *Client) GetComputerPrestageByName(name string) (*ResourceComputerPrestage, error) {
-	prestages, err := c.GetComputerPrestages("")
+	id, err := c.LookupIDByName("computer-prestages", name)
 	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "computer prestages", err)
-	}
-
-	for _, value := range prestages.Results {
-		if value.DisplayName == name {
-			return &value, nil
-		}
+		return nil, fmt.Errorf(errMsgFailedGetByName, "computer prestage", name, err)
 	}
 
-	return nil, fmt.Errorf(errMsgFailedGetByName, "computer prestage", name, errMsgNoName)
+	return c.GetComputerPrestageByID(id)
 }
 
 // CreateComputerPrestage creates a new computer prestage with the given details.
@@ -252,19 +246,12 @@ func (c *Client) UpdateComputerPrestageByID(id string, prestageUpdate *ResourceC
 
 // UpdateComputerPrestageByNameByID updates a computer prestage based on its display name.
 func (c *Client) UpdateComputerPrestageByName(name string, prestageUpdate *ResourceComputerPrestage) (*ResourceComputerPrestage, error) {
-	target, err := c.GetComputerPrestageByName(name)
-	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedGetByName, "computer prestage", name, err)
-	}
-
-	target_id := target.ID
-	resp, err := c.UpdateComputerPrestageByID(target_id, prestageUpdate)
-
+	id, err := c.lookupIDByNameForWrite("computer-prestages", name)
 	if err != nil {
 		return nil, fmt.Errorf(errMsgFailedUpdateByName, "computer prestage", name, err)
 	}
 
-	return resp, nil
+	return c.UpdateComputerPrestageByID(id, prestageUpdate)
 }
 
 // DeleteComputerPrestageByID deletes a computer prestage by its ID
@@ -285,19 +272,12 @@ func (c *Client) DeleteComputerPrestageByID(id string) error {
 
 // DeleteComputerPrestageByNameByID deletes a computer prestage by its name.
 func (c *Client) DeleteComputerPrestageByName(name string) error {
-	target, err := c.GetComputerPrestageByName(name)
-	if err != nil {
-		return fmt.Errorf(errMsgFailedPaginatedGet, "computer prestages", err)
-	}
-
-	target_id := target.ID
-
-	err = c.DeleteComputerPrestageByID(target_id)
+	id, err := c.lookupIDByNameForWrite("computer-prestages", name)
 	if err != nil {
 		return fmt.Errorf(errMsgFailedDeleteByName, "computer prestage", name, err)
 	}
 
-	return nil
+	return c.DeleteComputerPrestageByID(id)
 }
 
 // GetDeviceScopeForComputerPrestage retrieves the device scope for a specific computer prestage by its ID.
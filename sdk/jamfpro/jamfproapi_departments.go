@@ -79,18 +79,12 @@ func (c *Client) GetDepartmentByID(id string) (*ResourceDepartment, error) {
 
 // GetDepartmentByName retrieves a department by Name.
 func (c *Client) GetDepartmentByName(name string) (*ResourceDepartment, error) {
-	depts, err := c.GetDepartments("")
+	id, err := c.LookupIDByName("departments", name)
 	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "departments", err)
-	}
-
-	for _, value := range depts.Results {
-		if value.Name == name {
-			return &value, nil
-		}
+		return nil, fmt.Errorf(errMsgFailedGetByName, "department", name, err)
 	}
 
-	return nil, fmt.Errorf(errMsgFailedGetByName, "department", name, errMsgNoName)
+	return c.GetDepartmentByID(id)
 }
 
 // CreateDepartment creates a new department.
@@ -130,21 +124,12 @@ func (c *Client) UpdateDepartmentByID(id string, departmentUpdate *ResourceDepar
 
 // UpdateDepartmentByName Updates department by resource name
 func (c *Client) UpdateDepartmentByName(targetName string, departmentUpdate *ResourceDepartment) (*ResourceDepartment, error) {
-	target, err := c.GetDepartmentByName(targetName)
-
-	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedGetByName, "department", targetName, err)
-	}
-
-	target_id := target.ID
-
-	resp, err := c.UpdateDepartmentByID(target_id, departmentUpdate)
+	id, err := c.lookupIDByNameForWrite("departments", targetName)
 	if err != nil {
 		return nil, fmt.Errorf(errMsgFailedUpdateByName, "department", targetName, err)
 	}
 
-	return resp, nil
-
+	return c.UpdateDepartmentByID(id, departmentUpdate)
 }
 
 // DeleteDepartmentByID Deletes department with given id
@@ -165,17 +150,10 @@ func (c *Client) DeleteDepartmentByID(id string) error {
 
 // DeleteDepartmentByName deletes a department with given name, leverages GetDepartmentByName
 func (c *Client) DeleteDepartmentByName(targetName string) error {
-	target, err := c.GetDepartmentByName(targetName)
-	if err != nil {
-		return fmt.Errorf(errMsgFailedGetByName, "department", targetName, err)
-	}
-
-	target_id := target.ID
-	err = c.DeleteDepartmentByID(target_id)
-
+	id, err := c.lookupIDByNameForWrite("departments", targetName)
 	if err != nil {
 		return fmt.Errorf(errMsgFailedDeleteByName, "department", targetName, err)
 	}
 
-	return nil
+	return c.DeleteDepartmentByID(id)
 }
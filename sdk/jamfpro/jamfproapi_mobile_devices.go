@@ -0,0 +1,72 @@
+// jamfproapi_mobile_devices.go
+// Jamf Pro Api - Mobile Devices
+// api reference: https://developer.jamf.com/jamf-pro/reference/get_v2-mobile-devices-detail
+// Jamf Pro API requires the structs to support a JSON data structure.
+
+package jamfpro
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const uriMobileDevicesDetail = "/api/v2/mobile-devices/detail"
+
+// Structs
+
+// List
+
+type ResponseMobileDevicesDetailList struct {
+	TotalCount int                          `json:"totalCount"`
+	Results    []ResourceMobileDeviceDetail `json:"results"`
+}
+
+// Resource
+
+type ResourceMobileDeviceDetail struct {
+	MobileDeviceID string                          `json:"mobileDeviceId"`
+	DeviceType     string                          `json:"deviceType"`
+	General        MobileDeviceDetailSubsetGeneral `json:"general"`
+}
+
+// Subsets & Containers
+
+type MobileDeviceDetailSubsetGeneral struct {
+	UDID                    string `json:"udid"`
+	DisplayName             string `json:"displayName"`
+	AssetTag                string `json:"assetTag"`
+	SiteID                  string `json:"siteId"`
+	LastInventoryUpdateDate string `json:"lastInventoryUpdateDate"`
+	OsVersion               string `json:"osVersion"`
+	OsBuild                 string `json:"osBuild"`
+	SerialNumber            string `json:"serialNumber"`
+	Managed                 bool   `json:"managed"`
+	Supervised              bool   `json:"supervised"`
+	LastEnrolledDate        string `json:"lastEnrolledDate"`
+}
+
+// CRUD
+
+// GetMobileDevicesDetail retrieves mobile device details with optional sections, sorting and filtering,
+// e.g. `section=GENERAL&filter=general.lastInventoryUpdateDate=ge="2024-01-01T00:00:00Z"`.
+func (c *Client) GetMobileDevicesDetail(sort_filter string) (*ResponseMobileDevicesDetailList, error) {
+	resp, err := c.DoPaginatedGet(uriMobileDevicesDetail, standardPageSize, startingPageNumber, sort_filter)
+	if err != nil {
+		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "mobile devices detail", err)
+	}
+
+	var out ResponseMobileDevicesDetailList
+	out.TotalCount = resp.Size
+
+	for _, value := range resp.Results {
+		var newObj ResourceMobileDeviceDetail
+		err := mapstructure.Decode(value, &newObj)
+		if err != nil {
+			return nil, fmt.Errorf(errMsgFailedMapstruct, "mobile device detail", err)
+		}
+		out.Results = append(out.Results, newObj)
+	}
+
+	return &out, nil
+}
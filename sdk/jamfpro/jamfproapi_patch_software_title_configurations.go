@@ -89,18 +89,12 @@ func (c *Client) GetPatchSoftwareTitleConfigurationById(id string) (*ResourcePat
 
 // GetPatchSoftwareTitleConfigurationByName retrieves a department by Name.
 func (c *Client) GetPatchSoftwareTitleConfigurationByName(name string) (*ResourcePatchSoftwareTitleConfiguration, error) {
-	patchSoftwareTitle, err := c.GetPatchSoftwareTitleConfigurations()
+	id, err := c.LookupIDByName("patch-software-title-configurations", name)
 	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "patch software title configuration", err)
+		return nil, fmt.Errorf(errMsgFailedGetByName, "patch software title configuration", name, err)
 	}
 
-	for _, value := range patchSoftwareTitle.Results {
-		if value.DisplayName == name {
-			return &value, nil
-		}
-	}
-
-	return nil, fmt.Errorf(errMsgFailedGetByName, "patch software title configuration", name, errMsgNoName)
+	return c.GetPatchSoftwareTitleConfigurationById(id)
 }
 
 // CreatePatchSoftwareTitleConfiguration Creates a new PatchSoftwareTitleConfiguration
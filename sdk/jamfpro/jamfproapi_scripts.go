@@ -102,18 +102,12 @@ func (c *Client) GetScriptByID(id string) (*ResourceScript, error) {
 
 // Retrieves script by Name by leveraging GetScripts(), returns ResourceScript
 func (c *Client) GetScriptByName(name string) (*ResourceScript, error) {
-	scripts, err := c.GetScripts("")
+	id, err := c.LookupIDByName("scripts", name)
 	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "scripts", err)
-	}
-
-	for _, value := range scripts.Results {
-		if value.Name == name {
-			return &value, nil
-		}
+		return nil, fmt.Errorf(errMsgFailedGetByName, "script", name, err)
 	}
 
-	return nil, fmt.Errorf(errMsgFailedGetByName, "script", name, errMsgNoName)
+	return c.GetScriptByID(id)
 }
 
 // Creates script from ResourceScript struct
@@ -153,19 +147,12 @@ func (c *Client) UpdateScriptByID(id string, scriptUpdate *ResourceScript) (*Res
 
 // Leverages UpdateScriptByID and GetScripts to update script from provided ResourceScript
 func (c *Client) UpdateScriptByName(name string, scriptUpdate *ResourceScript) (*ResourceScript, error) {
-	target, err := c.GetScriptByName(name)
-	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedGetByName, "script", name, err)
-	}
-
-	target_id := target.ID
-	resp, err := c.UpdateScriptByID(target_id, scriptUpdate)
-
+	id, err := c.lookupIDByNameForWrite("scripts", name)
 	if err != nil {
 		return nil, fmt.Errorf(errMsgFailedUpdateByName, "script", name, err)
 	}
 
-	return resp, nil
+	return c.UpdateScriptByID(id, scriptUpdate)
 }
 
 // Deletes script with provided ID
@@ -186,17 +173,10 @@ func (c *Client) DeleteScriptByID(id string) error {
 
 // Leverages DeleteScriptByID and GetScripts to delete script by Name
 func (c *Client) DeleteScriptByName(name string) error {
-	target, err := c.GetScriptByName(name)
-	if err != nil {
-		return fmt.Errorf(errMsgFailedGetByName, "script", name, err)
-	}
-
-	target_id := target.ID
-
-	err = c.DeleteScriptByID(target_id)
+	id, err := c.lookupIDByNameForWrite("scripts", name)
 	if err != nil {
 		return fmt.Errorf(errMsgFailedDeleteByName, "script", name, err)
 	}
 
-	return nil
+	return c.DeleteScriptByID(id)
 }
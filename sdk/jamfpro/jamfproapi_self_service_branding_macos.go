@@ -83,18 +83,12 @@ func (c *Client) GetSelfServiceBrandingMacOSByID(id string) (*ResourceSelfServic
 
 // GetSelfServiceBrandingMacOSByNameByID retrieves a specific self-service branding configuration for macOS by its name.
 func (c *Client) GetSelfServiceBrandingMacOSByName(name string) (*ResourceSelfServiceBrandingDetail, error) {
-	all_ssbrandings, err := c.GetSelfServiceBrandingMacOS("")
+	id, err := c.LookupIDByName("self-service-branding-macos", name)
 	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "self service brandings", err)
-	}
-
-	for _, value := range all_ssbrandings.Results {
-		if value.BrandingName == name {
-			return &value, nil
-		}
+		return nil, fmt.Errorf(errMsgFailedGetByName, "self service branding", name, err)
 	}
 
-	return nil, fmt.Errorf(errMsgFailedGetByName, "self service branding", name, errMsgNoName)
+	return c.GetSelfServiceBrandingMacOSByID(id)
 }
 
 // CreateSelfServiceBrandingMacOS creates a new self-service branding configuration for macOS.
@@ -133,19 +127,12 @@ func (c *Client) UpdateSelfServiceBrandingMacOSByID(id string, brandingUpdate *R
 
 // UpdateSelfServiceBrandingMacOSByName updates a self-service branding configuration for macOS by name.
 func (c *Client) UpdateSelfServiceBrandingMacOSByName(name string, brandingUpdate *ResourceSelfServiceBrandingDetail) (*ResourceSelfServiceBrandingDetail, error) {
-	target, err := c.GetSelfServiceBrandingMacOSByName(name)
-	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedGetByName, "self service branding", name, err)
-	}
-
-	target_id := target.ID
-	resp, err := c.UpdateSelfServiceBrandingMacOSByID(target_id, brandingUpdate)
-
+	id, err := c.lookupIDByNameForWrite("self-service-branding-macos", name)
 	if err != nil {
 		return nil, fmt.Errorf(errMsgFailedUpdateByName, "self service branding", name, err)
 	}
 
-	return resp, nil
+	return c.UpdateSelfServiceBrandingMacOSByID(id, brandingUpdate)
 }
 
 // DeleteSelfServiceBrandingMacOSByID deletes a self-service branding configuration for macOS by ID.
@@ -166,16 +153,10 @@ func (c *Client) DeleteSelfServiceBrandingMacOSByID(id string) error {
 
 // DeleteSelfServiceBrandingMacOSByName deletes a self-service branding configuration for macOS by name.
 func (c *Client) DeleteSelfServiceBrandingMacOSByName(name string) error {
-	target, err := c.GetSelfServiceBrandingMacOSByName(name)
-	if err != nil {
-		return fmt.Errorf(errMsgFailedGetByName, "self service branding", name, err)
-	}
-
-	target_id := target.ID
-	err = c.DeleteSelfServiceBrandingMacOSByID(target_id)
+	id, err := c.lookupIDByNameForWrite("self-service-branding-macos", name)
 	if err != nil {
 		return fmt.Errorf(errMsgFailedDeleteByName, "self service branding", name, err)
 	}
 
-	return nil
+	return c.DeleteSelfServiceBrandingMacOSByID(id)
 }
@@ -93,19 +93,12 @@ func (c *Client) GetVolumePurchasingSubscriptionByID(id string) (*ResourceVolume
 
 // GetVolumePurchasingSubscriptionByNameByID fetches a volume purchasing subscription by its display name and retrieves its details using its ID.
 func (c *Client) GetVolumePurchasingSubscriptionByName(name string) (*ResourceVolumePurchasingSubscription, error) {
-	vpSubcriptions, err := c.GetVolumePurchasingSubscriptions("")
+	id, err := c.LookupIDByName("volume-purchasing-subscriptions", name)
 	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedPaginatedGet, "volume purchasing subscriptions", err)
-	}
-
-	for _, value := range vpSubcriptions.Results {
-		if value.Name == name {
-			return &value, nil
-		}
+		return nil, fmt.Errorf(errMsgFailedGetByName, "volume purchasing subscription", name, err)
 	}
 
-	return nil, fmt.Errorf(errMsgFailedGetByName, "volume purchasing subscription", name, errMsgNoName)
-
+	return c.GetVolumePurchasingSubscriptionByID(id)
 }
 
 // CreateVolumePurchasingSubscription creates a new volume purchasing subscription
@@ -148,17 +141,12 @@ func (c *Client) UpdateVolumePurchasingSubscriptionByID(id string, subscription
 
 // UpdateVolumePurchasingSubscriptionByNameByID updates a volume purchasing subscription by its display name
 func (c *Client) UpdateVolumePurchasingSubscriptionByName(name string, updateData *ResourceVolumePurchasingSubscription) (*ResourceVolumePurchasingSubscription, error) {
-	target, err := c.GetVolumePurchasingSubscriptionByName(name)
-	if err != nil {
-		return nil, fmt.Errorf(errMsgFailedGetByName, "volume purchasing subscription", name, err)
-	}
-
-	target_id := target.Id
-	resp, err := c.UpdateVolumePurchasingSubscriptionByID(target_id, updateData)
+	id, err := c.lookupIDByNameForWrite("volume-purchasing-subscriptions", name)
 	if err != nil {
 		return nil, fmt.Errorf(errMsgFailedUpdateByName, "volume purchasing subscription", name, err)
 	}
-	return resp, nil
+
+	return c.UpdateVolumePurchasingSubscriptionByID(id, updateData)
 }
 
 // DeleteVolumePurchasingSubscriptionByID deletes a volume purchasing subscription by its ID
@@ -179,17 +167,10 @@ func (c *Client) DeleteVolumePurchasingSubscriptionByID(id string) error {
 
 // DeleteVolumePurchasingSubscriptionByName finds a subscription by name and deletes it by its ID
 func (c *Client) DeleteVolumePurchasingSubscriptionByName(name string) error {
-	target, err := c.GetVolumePurchasingSubscriptionByName(name)
-	if err != nil {
-		return fmt.Errorf(errMsgFailedGetByName, "volume purchasing subscription", name, err)
-	}
-
-	target_id := target.Id
-	err = c.DeleteVolumePurchasingSubscriptionByID(target_id)
+	id, err := c.lookupIDByNameForWrite("volume-purchasing-subscriptions", name)
 	if err != nil {
 		return fmt.Errorf(errMsgFailedDeleteByName, "volume purchasing subscription", name, err)
 	}
 
-	return nil
-
+	return c.DeleteVolumePurchasingSubscriptionByID(id)
 }
@@ -12,7 +12,7 @@ const (
 	// Get
 	errMsgFailedGet           = "failed to get %s, error: %v"
 	errMsgFailedGetByID       = "failed to get %s by id: %v, error: %v"
-	errMsgFailedGetByName     = "failed to get %s by name: %s, error: %v"
+	errMsgFailedGetByName     = "failed to get %s by name: %s, error: %w"
 	errMsgFailedGetByCategory = "failed to get %s by category: %s, error: %v"
 	errMsgFailedGetByType     = "failed to get %s by type: %s, error: %v"
 	errMsgFailedGetByEmail    = "failed to get %s by Email: %s, error: %v"
@@ -25,14 +25,14 @@ const (
 	// Update
 	errMsgFailedUpdate         = "failed to update %s, error: %v"
 	errMsgFailedUpdateByID     = "failed to update %s by id: %v, error: %v"
-	errMsgFailedUpdateByName   = "failed to update %s by name: %s, error: %v"
+	errMsgFailedUpdateByName   = "failed to update %s by name: %s, error: %w"
 	errMsgFailedUpdateByEmail  = "failed to update %s by Email: %s, error: %v"
 	errMsgFailedUpdateByString = "failed to update %s by %s: %s, error: %v"
 
 	// Delete
 	errMsgFailedDelete         = "failed to delete %s, error %v"
 	errMsgFailedDeleteByID     = "failed to delete %s by id: %v, error: %v"
-	errMsgFailedDeleteByName   = "failed to delete %s by name: %s, error: %v"
+	errMsgFailedDeleteByName   = "failed to delete %s by name: %s, error: %w"
 	errMsgFailedDeleteByEmail  = "failed to delete %s by Email: %s, error: %v"
 	errMsgFailedDeleteMultiple = "failed to delete multiple %s, by ids: %v, error: %v"
 	errMsgFailedDeleteByString = "failed to delete %s by %s: %s, error: %v"
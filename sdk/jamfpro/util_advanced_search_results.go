@@ -0,0 +1,345 @@
+// util_advanced_search_results.go
+// This utility runs advanced computer, mobile device and user searches and returns their results as a table
+// with one column per display field. The Classic API reports display fields as elements named after the
+// field, e.g. <Operating_System_Version>, which the fixed result structs of the search resources discard.
+//
+// Searches may be existing searches, run by ID, or ad-hoc searches which are created, read and deleted.
+package jamfpro
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// AdvancedSearchColumnType is the type inferred for the values of a column.
+type AdvancedSearchColumnType string
+
+const (
+	AdvancedSearchColumnString  AdvancedSearchColumnType = "string"
+	AdvancedSearchColumnInteger AdvancedSearchColumnType = "integer"
+	AdvancedSearchColumnFloat   AdvancedSearchColumnType = "float"
+	AdvancedSearchColumnBoolean AdvancedSearchColumnType = "boolean"
+	AdvancedSearchColumnTime    AdvancedSearchColumnType = "time"
+)
+
+// advancedSearchTimeLayouts are the timestamp formats used in advanced search results.
+var advancedSearchTimeLayouts = []string{"2006-01-02 15:04:05", time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// AdvancedSearchColumn describes a column of an advanced search table.
+type AdvancedSearchColumn struct {
+	Name    string // display field name, or the element name for fields which are always returned
+	Element string // XML element name in the search results
+	Type    AdvancedSearchColumnType
+}
+
+// AdvancedSearchTable holds the results of an advanced search. Each row maps column names to values of the
+// column's type: string, int64, float64, bool or time.Time. Empty cells are nil.
+type AdvancedSearchTable struct {
+	SearchID   int
+	SearchName string
+	Columns    []AdvancedSearchColumn
+	Rows       []map[string]interface{}
+}
+
+// advancedSearchResults captures the generic result rows of any advanced search type.
+type advancedSearchResults struct {
+	ID            int                 `xml:"id"`
+	Name          string              `xml:"name"`
+	DisplayFields []DisplayField      `xml:"display_fields>display_field"`
+	Computers     []advancedSearchRow `xml:"computers>computer"`
+	MobileDevices []advancedSearchRow `xml:"mobile_devices>mobile_device"`
+	Users         []advancedSearchRow `xml:"users>user"`
+}
+
+// advancedSearchRow holds every child element of a result row.
+type advancedSearchRow struct {
+	Fields []struct {
+		XMLName xml.Name
+		Value   string `xml:",chardata"`
+	} `xml:",any"`
+}
+
+// Running
+
+// RunAdvancedComputerSearch runs an existing advanced computer search.
+func (c *Client) RunAdvancedComputerSearch(id string) (*AdvancedSearchTable, error) {
+	return c.runAdvancedSearch(uriAPIAdvancedComputerSearches, "advanced computer search", id)
+}
+
+// RunAdvancedMobileDeviceSearch runs an existing advanced mobile device search.
+func (c *Client) RunAdvancedMobileDeviceSearch(id string) (*AdvancedSearchTable, error) {
+	return c.runAdvancedSearch(uriAPIAdvancedMobileDeviceSearches, "advanced mobile device search", id)
+}
+
+// RunAdvancedUserSearch runs an existing advanced user search.
+func (c *Client) RunAdvancedUserSearch(id string) (*AdvancedSearchTable, error) {
+	return c.runAdvancedSearch(uriAPIAdvancedUserSearches, "advanced user search", id)
+}
+
+// RunAdHocAdvancedComputerSearch creates the search, runs it and deletes it. A name is generated when empty.
+func (c *Client) RunAdHocAdvancedComputerSearch(search *ResourceAdvancedComputerSearch) (*AdvancedSearchTable, error) {
+	adHoc := *search
+	adHoc.Name = adHocAdvancedSearchName(search.Name)
+
+	created, err := c.CreateAdvancedComputerSearch(&adHoc)
+	if err != nil {
+		return nil, err
+	}
+	id := strconv.Itoa(created.ID)
+
+	table, err := c.RunAdvancedComputerSearch(id)
+	return table, errors.Join(err, c.DeleteAdvancedComputerSearchByID(id))
+}
+
+// RunAdHocAdvancedMobileDeviceSearch creates the search, runs it and deletes it. A name is generated when empty.
+func (c *Client) RunAdHocAdvancedMobileDeviceSearch(search *ResourceAdvancedMobileDeviceSearch) (*AdvancedSearchTable, error) {
+	adHoc := *search
+	adHoc.Name = adHocAdvancedSearchName(search.Name)
+
+	created, err := c.CreateAdvancedMobileDeviceSearch(&adHoc)
+	if err != nil {
+		return nil, err
+	}
+	id := strconv.Itoa(created.ID)
+
+	table, err := c.RunAdvancedMobileDeviceSearch(id)
+	return table, errors.Join(err, c.DeleteAdvancedMobileDeviceSearchByID(id))
+}
+
+// RunAdHocAdvancedUserSearch creates the search, runs it and deletes it. A name is generated when empty.
+func (c *Client) RunAdHocAdvancedUserSearch(search *ResourceAdvancedUserSearch) (*AdvancedSearchTable, error) {
+	adHoc := *search
+	adHoc.Name = adHocAdvancedSearchName(search.Name)
+
+	created, err := c.CreateAdvancedUserSearch(&adHoc)
+	if err != nil {
+		return nil, err
+	}
+	id := strconv.Itoa(created.ID)
+
+	table, err := c.RunAdvancedUserSearch(id)
+	return table, errors.Join(err, c.DeleteAdvancedUserSearchByID(id))
+}
+
+// adHocAdvancedSearchName returns name, or a unique name when empty.
+func adHocAdvancedSearchName(name string) string {
+	if name != "" {
+		return name
+	}
+	return fmt.Sprintf("go-jamfpro ad-hoc search %d", time.Now().UnixNano())
+}
+
+// runAdvancedSearch reads a search by ID, which makes Jamf Pro evaluate it, and builds its table.
+func (c *Client) runAdvancedSearch(uri, resource, id string) (*AdvancedSearchTable, error) {
+	endpoint := fmt.Sprintf("%s/id/%s", uri, id)
+
+	var results advancedSearchResults
+	resp, err := c.HTTP.DoRequest("GET", endpoint, nil, &results)
+	if err != nil {
+		return nil, fmt.Errorf(errMsgFailedGetByID, resource, id, err)
+	}
+
+	if resp != nil && resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	rows := results.Computers
+	if len(results.MobileDevices) > 0 {
+		rows = results.MobileDevices
+	} else if len(results.Users) > 0 {
+		rows = results.Users
+	}
+
+	return newAdvancedSearchTable(results.ID, results.Name, results.DisplayFields, rows), nil
+}
+
+// newAdvancedSearchTable builds a typed table from result rows. Columns for the identifying fields returned
+// with every row come first, followed by the display fields in their configured order.
+func newAdvancedSearchTable(id int, name string, displayFields []DisplayField, rows []advancedSearchRow) *AdvancedSearchTable {
+	table := &AdvancedSearchTable{SearchID: id, SearchName: name}
+
+	displayNames := map[string]string{}
+	for _, field := range displayFields {
+		displayNames[advancedSearchElementKey(field.Name)] = field.Name
+	}
+
+	// Collect columns in the order elements first appear, placing display fields after the others.
+	var leading, display []AdvancedSearchColumn
+	seen := map[string]bool{}
+	for _, row := range rows {
+		for _, field := range row.Fields {
+			element := field.XMLName.Local
+			if seen[element] {
+				continue
+			}
+			seen[element] = true
+
+			if displayName, ok := displayNames[advancedSearchElementKey(element)]; ok {
+				display = append(display, AdvancedSearchColumn{Name: displayName, Element: element})
+			} else {
+				leading = append(leading, AdvancedSearchColumn{Name: element, Element: element})
+			}
+		}
+	}
+
+	sortedDisplay := make([]AdvancedSearchColumn, 0, len(display))
+	for _, field := range displayFields {
+		for _, column := range display {
+			if column.Name == field.Name {
+				sortedDisplay = append(sortedDisplay, column)
+			}
+		}
+	}
+	table.Columns = append(leading, sortedDisplay...)
+
+	// Infer each column's type from its non-empty values.
+	raw := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		raw[i] = map[string]string{}
+		for _, field := range row.Fields {
+			raw[i][field.XMLName.Local] = strings.TrimSpace(field.Value)
+		}
+	}
+	for i, column := range table.Columns {
+		var values []string
+		for _, row := range raw {
+			if value := row[column.Element]; value != "" {
+				values = append(values, value)
+			}
+		}
+		table.Columns[i].Type = inferAdvancedSearchColumnType(values)
+	}
+
+	for _, row := range raw {
+		typed := map[string]interface{}{}
+		for _, column := range table.Columns {
+			typed[column.Name] = parseAdvancedSearchValue(row[column.Element], column.Type)
+		}
+		table.Rows = append(table.Rows, typed)
+	}
+
+	return table
+}
+
+// advancedSearchElementKey normalises a display field or element name, as Jamf Pro replaces spaces and
+// punctuation in display field names with underscores.
+func advancedSearchElementKey(name string) string {
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, name))
+}
+
+// inferAdvancedSearchColumnType returns the narrowest type parsing every value.
+func inferAdvancedSearchColumnType(values []string) AdvancedSearchColumnType {
+	if len(values) == 0 {
+		return AdvancedSearchColumnString
+	}
+
+	for _, columnType := range []AdvancedSearchColumnType{
+		AdvancedSearchColumnInteger,
+		AdvancedSearchColumnFloat,
+		AdvancedSearchColumnBoolean,
+		AdvancedSearchColumnTime,
+	} {
+		parsesAll := true
+		for _, value := range values {
+			if _, ok := parseAdvancedSearchValue(value, columnType).(string); ok {
+				parsesAll = false
+				break
+			}
+		}
+		if parsesAll {
+			return columnType
+		}
+	}
+
+	return AdvancedSearchColumnString
+}
+
+// parseAdvancedSearchValue converts a value to the column type, returning the string itself when it does
+// not parse and nil when it is empty.
+func parseAdvancedSearchValue(value string, columnType AdvancedSearchColumnType) interface{} {
+	if value == "" {
+		return nil
+	}
+
+	// Values such as asset tags keep their leading zeros as strings.
+	numeric := !(len(value) > 1 && value[0] == '0' && value[1] != '.')
+
+	switch columnType {
+	case AdvancedSearchColumnInteger:
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil && numeric {
+			return parsed
+		}
+	case AdvancedSearchColumnFloat:
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && numeric {
+			return parsed
+		}
+	case AdvancedSearchColumnBoolean:
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	case AdvancedSearchColumnTime:
+		for _, layout := range advancedSearchTimeLayouts {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				return parsed
+			}
+		}
+	}
+
+	return value
+}
+
+// Table access
+
+// Column returns the values of a column, in row order.
+func (t *AdvancedSearchTable) Column(name string) []interface{} {
+	values := make([]interface{}, len(t.Rows))
+	for i, row := range t.Rows {
+		values[i] = row[name]
+	}
+	return values
+}
+
+// WriteCSV writes the table with a header row of column names. Times are written in RFC 3339 format.
+func (t *AdvancedSearchTable) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, len(t.Columns))
+	for i, column := range t.Columns {
+		header[i] = column.Name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(t.Columns))
+	for _, row := range t.Rows {
+		for i, column := range t.Columns {
+			switch value := row[column.Name].(type) {
+			case nil:
+				record[i] = ""
+			case time.Time:
+				record[i] = value.Format(time.RFC3339)
+			default:
+				record[i] = fmt.Sprint(value)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,447 @@
+// util_bulk_operations.go
+// RunBulk applies a create, update or delete operation to many resources. Items are processed by a pool
+// of workers at a limited rate, and operations rejected with 429 Too Many Requests or 503 Service
+// Unavailable are retried with exponential backoff, honouring any Retry-After header. Every item is
+// reported with its ID, status, error, attempts and duration.
+//
+// A dry run calls the operation with its writes replaced by reads. Each PUT, PATCH or DELETE is sent as
+// a GET to the same endpoint, so an item whose target does not exist fails, and creates are not sent.
+//
+//	items := make([]jamfpro.BulkItem[struct{}], len(policies.Policy))
+//	for i, policy := range policies.Policy {
+//		items[i] = jamfpro.BulkItem[struct{}]{Action: jamfpro.BulkDelete, ID: strconv.Itoa(policy.ID), Name: policy.Name}
+//	}
+//	report := jamfpro.RunBulk(ctx, client, items, jamfpro.BulkOptions{Workers: 8, RateLimit: 5, ContinueOnError: true},
+//		func(client *jamfpro.Client, item jamfpro.BulkItem[struct{}]) (string, error) {
+//			return item.ID, client.DeletePolicyByID(item.ID)
+//		})
+package jamfpro
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bulk actions set in BulkItem.Action
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// Bulk item statuses reported in BulkResult.Status
+const (
+	BulkSucceeded = "succeeded"
+	BulkFailed    = "failed"
+	BulkSkipped   = "skipped" // not started after an earlier failure or cancellation
+	BulkPlanned   = "planned" // dry run
+)
+
+const (
+	defaultBulkWorkers        = 4
+	defaultBulkMaxRetries     = 3
+	defaultBulkInitialBackoff = time.Second
+	defaultBulkMaxBackoff     = time.Minute
+)
+
+// BulkItem is a single change made by RunBulk.
+type BulkItem[T any] struct {
+	Action string // BulkCreate, BulkUpdate or BulkDelete
+	ID     string // ID of the resource to update or delete, empty for creates
+	Name   string // resource name, for reporting
+	Value  T      // resource to create or update
+}
+
+// BulkOperation makes the change for an item and returns the ID of the resource changed. It must use
+// the client it is given, which is scoped to the item so its responses can be checked for rate limiting.
+type BulkOperation[T any] func(client *Client, item BulkItem[T]) (string, error)
+
+// BulkOptions configures RunBulk.
+type BulkOptions struct {
+	// Workers is the number of items processed at once, 4 by default.
+	Workers int
+
+	// RateLimit caps the operations started per second, retries included. Zero leaves it unlimited.
+	RateLimit float64
+
+	// MaxRetries is how many times an item rejected with 429 or 503 is retried, 3 by default. A negative
+	// value disables retries.
+	MaxRetries int
+
+	// InitialBackoff is the wait before the first retry, doubled for each retry after it, 1 second by
+	// default. A Retry-After header sent with the rejection takes precedence.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between retries, 1 minute by default.
+	MaxBackoff time.Duration
+
+	// ContinueOnError processes every item whatever fails. Otherwise items not yet started when an item
+	// fails are skipped.
+	ContinueOnError bool
+
+	// DryRun runs the operation without changing anything: its PUT, PATCH and DELETE requests are sent as
+	// GETs to check their target exists, and its POST requests are not sent. Items whose checks pass are
+	// reported as planned.
+	DryRun bool
+}
+
+// BulkResult is the outcome of a single item.
+type BulkResult struct {
+	Index      int // position of the item in the items given to RunBulk
+	Action     string
+	ID         string // the item's ID, or the ID returned by the operation
+	Name       string
+	Status     string // BulkSucceeded, BulkFailed, BulkSkipped or BulkPlanned
+	StatusCode int    // HTTP status of the item's last response, when one was received
+	Attempts   int
+	Duration   time.Duration
+	Err        error
+}
+
+func (r BulkResult) String() string {
+	target := r.ID
+	if r.Name != "" {
+		target = strings.TrimSpace(fmt.Sprintf("%s %q", r.ID, r.Name))
+	}
+	if r.Err != nil {
+		return fmt.Sprintf("%s %s: %s: %v", r.Action, target, r.Status, r.Err)
+	}
+	return fmt.Sprintf("%s %s: %s", r.Action, target, r.Status)
+}
+
+// BulkReport lists the result of every item, in the order the items were given.
+type BulkReport struct {
+	Results   []BulkResult
+	Succeeded int
+	Failed    int
+	Skipped   int
+	Planned   int
+	Duration  time.Duration
+}
+
+// Err returns a *BulkError listing the failed items, or nil when none failed.
+func (r *BulkReport) Err() error {
+	var failed []BulkResult
+	for _, result := range r.Results {
+		if result.Status == BulkFailed {
+			failed = append(failed, result)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return &BulkError{Failed: failed, Total: len(r.Results)}
+}
+
+// BulkError lists the items which failed in a bulk run.
+type BulkError struct {
+	Failed []BulkResult
+	Total  int
+}
+
+func (e *BulkError) Error() string {
+	failures := make([]string, len(e.Failed))
+	for i, result := range e.Failed {
+		failures[i] = result.String()
+	}
+	return fmt.Sprintf("%d of %d bulk operations failed: %s", len(e.Failed), e.Total, strings.Join(failures, "; "))
+}
+
+// RunBulk runs op for each item and reports the outcome of every item. Cancelling ctx stops new items
+// and retries from starting; items not started are reported as skipped. A failure without
+// ContinueOnError only stops new items, leaving items already started to finish.
+func RunBulk[T any](ctx context.Context, client *Client, items []BulkItem[T], options BulkOptions, op BulkOperation[T]) *BulkReport {
+	options = options.withDefaults()
+	started := time.Now()
+
+	report := &BulkReport{Results: make([]BulkResult, len(items))}
+	for i, item := range items {
+		report.Results[i] = BulkResult{Index: i, Action: item.Action, ID: item.ID, Name: item.Name, Status: BulkSkipped}
+	}
+
+	// Stopping the feed leaves items already started running under ctx.
+	feed, stopFeed := context.WithCancel(ctx)
+	defer stopFeed()
+
+	limiter := newBulkRateLimiter(options.RateLimit)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < options.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if feed.Err() != nil {
+					continue // skipped
+				}
+				result := &report.Results[i]
+				runBulkItem(ctx, client, items[i], result, options, limiter, op)
+				if result.Status == BulkFailed && !options.ContinueOnError {
+					stopFeed()
+				}
+			}
+		}()
+	}
+
+feeding:
+	for i := range items {
+		select {
+		case indexes <- i:
+		case <-feed.Done():
+			break feeding
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	report.tally(started)
+	return report
+}
+
+// runBulkItem runs the operation for an item, retrying while it is rate limited.
+func runBulkItem[T any](ctx context.Context, client *Client, item BulkItem[T], result *BulkResult, options BulkOptions, limiter *bulkRateLimiter, op BulkOperation[T]) {
+	started := time.Now()
+	defer func() { result.Duration = time.Since(started) }()
+
+	for {
+		if err := limiter.wait(ctx); err != nil {
+			if result.Attempts == 0 {
+				return // skipped
+			}
+			result.Status, result.Err = BulkFailed, err
+			return
+		}
+
+		recorder := &bulkResponseRecorder{}
+		itemCtx := context.WithValue(ctx, bulkResponseRecorderKey{}, recorder)
+		if options.DryRun {
+			itemCtx = context.WithValue(itemCtx, bulkDryRunKey{}, true)
+		}
+		itemClient := client.WithContext(itemCtx)
+
+		result.Attempts++
+		id, err := op(itemClient, item)
+		result.StatusCode = recorder.statusCode
+		if id != "" {
+			result.ID = id
+		}
+		if err == nil {
+			result.Status, result.Err = BulkSucceeded, nil
+			if options.DryRun {
+				result.Status = BulkPlanned
+			}
+			return
+		}
+		result.Status, result.Err = BulkFailed, err
+
+		if !bulkRetryable(recorder.statusCode) || result.Attempts > options.MaxRetries {
+			return
+		}
+
+		wait := cappedBackoff(options.InitialBackoff, options.MaxBackoff, result.Attempts)
+		if recorder.hasRetryAfter {
+			wait = min(recorder.retryAfter, options.MaxBackoff)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// withDefaults fills in options left unset.
+func (o BulkOptions) withDefaults() BulkOptions {
+	if o.Workers <= 0 {
+		o.Workers = defaultBulkWorkers
+	}
+	if o.MaxRetries == 0 {
+		o.MaxRetries = defaultBulkMaxRetries
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = defaultBulkInitialBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = defaultBulkMaxBackoff
+	}
+	return o
+}
+
+func (r *BulkReport) tally(started time.Time) {
+	for _, result := range r.Results {
+		switch result.Status {
+		case BulkSucceeded:
+			r.Succeeded++
+		case BulkFailed:
+			r.Failed++
+		case BulkSkipped:
+			r.Skipped++
+		case BulkPlanned:
+			r.Planned++
+		}
+	}
+	r.Duration = time.Since(started)
+}
+
+// cappedBackoff returns the wait before retrying after the given attempt: initial doubled for each attempt
+// after the first, capped at limit. It stops doubling once the limit is reached, so it cannot overflow.
+func cappedBackoff(initial, limit time.Duration, attempt int) time.Duration {
+	wait := min(initial, limit)
+	for i := 1; i < attempt; i++ {
+		if wait > limit/2 {
+			return limit
+		}
+		wait *= 2
+	}
+	return wait
+}
+
+// bulkRetryable reports whether an operation rejected with the status may succeed if retried.
+func bulkRetryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// bulkRateLimiter spaces operations evenly to stay within a rate.
+type bulkRateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// newBulkRateLimiter returns a limiter for a rate per second, or nil when the rate is unlimited.
+func newBulkRateLimiter(perSecond float64) *bulkRateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &bulkRateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next operation may start.
+func (l *bulkRateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return ctx.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dry runs
+
+type bulkDryRunKey struct{}
+
+// bulkDryRun is a request interceptor which, for requests made for bulk items in a dry run, replaces
+// writes with reads. PUT, PATCH and DELETE requests are sent as GETs to the same endpoint, failing as the
+// write would when the target does not exist, and POST requests are not sent. Writes which pass are
+// answered with an empty success response, so the operation completes.
+func bulkDryRun(call *requestCall, next requestHandler) (*http.Response, error) {
+	if dryRun, _ := call.ctx.Value(bulkDryRunKey{}).(bool); !dryRun {
+		return next(call)
+	}
+
+	switch call.method {
+	case http.MethodPut, http.MethodPatch, http.MethodDelete:
+		ctx, method, body, out := call.ctx, call.method, call.body, call.out
+		call.ctx, call.method, call.body, call.out = BypassCache(ctx), http.MethodGet, nil, &struct{}{}
+		resp, err := next(call)
+		call.ctx, call.method, call.body, call.out = ctx, method, body, out
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+		// The target exists when the GET succeeded, even if its body did not decode.
+		if call.statusCode < 200 || call.statusCode > 299 {
+			if err == nil {
+				err = fmt.Errorf("dry run: GET %s returned status code %d", call.endpoint, call.statusCode)
+			}
+			return nil, fmt.Errorf("dry run: %s %s: %w", method, call.endpoint, err)
+		}
+		return dryRunResponse(method), nil
+	case http.MethodPost:
+		return dryRunResponse(call.method), nil
+	default:
+		return next(call)
+	}
+}
+
+// dryRunResponse is the empty response returned for a write skipped by a dry run.
+func dryRunResponse(method string) *http.Response {
+	status := http.StatusOK
+	switch method {
+	case http.MethodPost:
+		status = http.StatusCreated
+	case http.MethodDelete:
+		status = http.StatusNoContent
+	}
+	return &http.Response{StatusCode: status, Status: http.StatusText(status), Header: http.Header{}, Body: http.NoBody}
+}
+
+// Responses
+
+type bulkResponseRecorderKey struct{}
+
+// bulkResponseRecorder keeps the status and Retry-After of the last response received for a bulk item.
+// SDK methods do not return the status with their errors, so it is recorded as the responses arrive.
+type bulkResponseRecorder struct {
+	statusCode    int
+	retryAfter    time.Duration
+	hasRetryAfter bool
+}
+
+// recordBulkResponses is an attempt interceptor which records responses to requests made for bulk items.
+func recordBulkResponses(attempt *requestAttempt, next attemptHandler) (*http.Response, error) {
+	resp, err := next(attempt.req)
+	if attempt.call == nil || resp == nil {
+		return resp, err
+	}
+	if recorder, ok := attempt.call.ctx.Value(bulkResponseRecorderKey{}).(*bulkResponseRecorder); ok {
+		recorder.statusCode = resp.StatusCode
+		recorder.retryAfter, recorder.hasRetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	return resp, err
+}
+
+// parseRetryAfter parses a Retry-After header given as seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
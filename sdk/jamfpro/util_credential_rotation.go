@@ -0,0 +1,518 @@
+// util_credential_rotation.go
+// This utility coordinates the rotation of OAuth client credentials for Jamf Pro API integrations.
+// Refreshing credentials invalidates the previous secret on the server, so new secrets are written to
+// every sink straight away and then verified against the Jamf Pro token endpoint. Every rotation attempt
+// produces an audit record.
+package jamfpro
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const uriOAuthToken = "/api/oauth/token"
+
+// Rotation statuses recorded in CredentialRotationAuditRecord.Status
+const (
+	CredentialRotationStatusSucceeded          = "succeeded"
+	CredentialRotationStatusRotationFailed     = "rotation_failed"
+	CredentialRotationStatusVerificationFailed = "verification_failed"
+	CredentialRotationStatusSinkFailed         = "sink_failed"
+)
+
+// CredentialSink receives newly rotated client credentials for an API integration.
+type CredentialSink interface {
+	Name() string
+	WriteCredentials(integration ResourceApiIntegration, credentials ResourceClientCredentials) error
+}
+
+// CredentialRotationAuditRecord describes the outcome of a single credential rotation attempt.
+type CredentialRotationAuditRecord struct {
+	Timestamp       time.Time     `json:"timestamp"`
+	IntegrationID   int           `json:"integration_id"`
+	IntegrationName string        `json:"integration_name"`
+	ClientID        string        `json:"client_id"`
+	Status          string        `json:"status"`
+	Verified        bool          `json:"verified"`
+	SinksWritten    []string      `json:"sinks_written,omitempty"`
+	SinksFailed     []string      `json:"sinks_failed,omitempty"`
+	Error           string        `json:"error,omitempty"`
+	Duration        time.Duration `json:"duration"`
+
+	// Credentials holds the new credentials once they have been issued, so an Audit function can
+	// recover them when a sink fails. It is never included in the JSON form of the record.
+	Credentials *ResourceClientCredentials `json:"-"`
+}
+
+// CredentialRotator rotates client credentials for a selected set of API integrations, either once
+// via RotateOnce or on a schedule via Run.
+type CredentialRotator struct {
+	// Client is the Jamf Pro client used to look up integrations and request new credentials.
+	Client *Client
+
+	// IntegrationIDs and IntegrationNames select which API integrations are rotated.
+	IntegrationIDs   []string
+	IntegrationNames []string
+
+	// Sinks receive the new credentials as soon as they are issued. The previous secret stops working when
+	// new credentials are issued, so sinks are written whether or not verification succeeds.
+	Sinks []CredentialSink
+
+	// Interval is the time between rotations when using Run.
+	Interval time.Duration
+
+	// VerifyAttempts and VerifyDelay control how often a new credential is tried against the
+	// token endpoint before the rotation is marked as failed. Defaults are 5 attempts, 2 seconds apart.
+	VerifyAttempts int
+	VerifyDelay    time.Duration
+
+	// VerifyHTTPClient is used for token verification requests. Defaults to a client with a 30 second timeout.
+	VerifyHTTPClient *http.Client
+
+	// Audit is called with a record for every rotation attempt.
+	Audit func(record CredentialRotationAuditRecord)
+}
+
+// RotateOnce rotates the credentials of every selected integration and returns one audit record per integration.
+// An error is returned only when the selection itself cannot be resolved.
+func (r *CredentialRotator) RotateOnce() ([]CredentialRotationAuditRecord, error) {
+	if r.Client == nil {
+		return nil, fmt.Errorf("credential rotator has no client")
+	}
+
+	integrations, err := r.resolveIntegrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []CredentialRotationAuditRecord
+	for _, integration := range integrations {
+		record := r.rotate(integration)
+		if r.Audit != nil {
+			r.Audit(record)
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// Run rotates credentials immediately and then every Interval until the context is cancelled. A failure
+// to look up the selected integrations is logged and retried at the next interval.
+func (r *CredentialRotator) Run(ctx context.Context) error {
+	if r.Interval <= 0 {
+		return fmt.Errorf("credential rotator interval must be greater than 0")
+	}
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RotateOnce(); err != nil {
+			if r.Client == nil {
+				return err
+			}
+			r.Client.HTTP.Sugar.Errorw("Credential rotation failed, retrying at the next interval", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// resolveIntegrations looks up every integration selected by ID or name.
+func (r *CredentialRotator) resolveIntegrations() ([]ResourceApiIntegration, error) {
+	var integrations []ResourceApiIntegration
+
+	for _, id := range r.IntegrationIDs {
+		integration, err := r.Client.GetApiIntegrationByID(id)
+		if err != nil {
+			return nil, err
+		}
+		integrations = append(integrations, *integration)
+	}
+
+	for _, name := range r.IntegrationNames {
+		integration, err := r.Client.GetApiIntegrationByName(name)
+		if err != nil {
+			return nil, err
+		}
+		integrations = append(integrations, *integration)
+	}
+
+	if len(integrations) == 0 {
+		return nil, fmt.Errorf("credential rotator has no integrations selected")
+	}
+
+	return integrations, nil
+}
+
+// rotate requests new credentials for an integration, writes them to every sink and verifies them.
+func (r *CredentialRotator) rotate(integration ResourceApiIntegration) CredentialRotationAuditRecord {
+	start := time.Now()
+	record := CredentialRotationAuditRecord{
+		Timestamp:       start.UTC(),
+		IntegrationID:   integration.ID,
+		IntegrationName: integration.DisplayName,
+		ClientID:        integration.ClientID,
+	}
+
+	defer func() {
+		record.Duration = time.Since(start)
+	}()
+
+	credentials, err := r.Client.RefreshClientCredentialsByApiRoleID(strconv.Itoa(integration.ID))
+	if err != nil {
+		record.Status = CredentialRotationStatusRotationFailed
+		record.Error = err.Error()
+		return record
+	}
+	record.ClientID = credentials.ClientID
+	record.Credentials = credentials
+
+	// The previous secret no longer works, so the new one is persisted before it is verified.
+	var sinkErrors []string
+	for _, sink := range r.Sinks {
+		name := credentialSinkName(sink, integration)
+		if err := sink.WriteCredentials(integration, *credentials); err != nil {
+			record.SinksFailed = append(record.SinksFailed, name)
+			sinkErrors = append(sinkErrors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		record.SinksWritten = append(record.SinksWritten, name)
+	}
+
+	verifyErr := r.verify(*credentials)
+	record.Verified = verifyErr == nil
+
+	switch {
+	case len(sinkErrors) > 0:
+		record.Status = CredentialRotationStatusSinkFailed
+		if verifyErr != nil {
+			sinkErrors = append(sinkErrors, verifyErr.Error())
+		}
+		record.Error = strings.Join(sinkErrors, "; ")
+	case verifyErr != nil:
+		record.Status = CredentialRotationStatusVerificationFailed
+		record.Error = verifyErr.Error()
+	default:
+		record.Status = CredentialRotationStatusSucceeded
+	}
+	return record
+}
+
+// verify retries obtaining a token with the supplied credentials, allowing for propagation delay on the server.
+func (r *CredentialRotator) verify(credentials ResourceClientCredentials) error {
+	attempts := r.VerifyAttempts
+	if attempts <= 0 {
+		attempts = 5
+	}
+	delay := r.VerifyDelay
+	if delay <= 0 {
+		delay = 2 * time.Second
+	}
+	httpClient := r.VerifyHTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	fqdn := (*r.Client.HTTP.Integration).GetFQDN()
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = VerifyClientCredentials(httpClient, fqdn, credentials)
+		if err == nil {
+			return nil
+		}
+		if attempt < attempts {
+			time.Sleep(delay)
+		}
+	}
+
+	return fmt.Errorf("new credentials failed verification after %d attempts: %w", attempts, err)
+}
+
+// VerifyClientCredentials requests an access token from the Jamf Pro instance at fqdn using the supplied
+// client credentials and returns an error if no token is issued.
+func VerifyClientCredentials(httpClient *http.Client, fqdn string, credentials ResourceClientCredentials) error {
+	data := url.Values{}
+	data.Set("client_id", credentials.ClientID)
+	data.Set("client_secret", credentials.ClientSecret)
+	data.Set("grant_type", "client_credentials")
+
+	req, err := http.NewRequest("POST", strings.TrimSuffix(fqdn, "/")+uriOAuthToken, strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("token request returned status code %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("token response contained no access token")
+	}
+
+	return nil
+}
+
+// JSONLinesAuditLogger returns an audit function which writes each record to w as a single line of JSON.
+func JSONLinesAuditLogger(w io.Writer) func(record CredentialRotationAuditRecord) {
+	var mu sync.Mutex
+	return func(record CredentialRotationAuditRecord) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = json.NewEncoder(w).Encode(record)
+	}
+}
+
+// Sinks
+
+// FileCredentialSink writes credentials as a JSON document using the ConfigContainer key names,
+// replacing the file atomically.
+type FileCredentialSink struct {
+	Path string
+	Perm os.FileMode
+}
+
+// Name returns the sink name used in audit records.
+func (s *FileCredentialSink) Name() string {
+	return "file:" + s.Path
+}
+
+// WriteCredentials writes the credentials to the sink file.
+func (s *FileCredentialSink) WriteCredentials(_ ResourceApiIntegration, credentials ResourceClientCredentials) error {
+	data, err := json.MarshalIndent(map[string]string{
+		"client_id":     credentials.ClientID,
+		"client_secret": credentials.ClientSecret,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.Path, data, s.Perm)
+}
+
+// EnvFileCredentialSink updates KEY=VALUE entries in an env file, preserving all other lines.
+// Keys default to CLIENT_ID and CLIENT_SECRET to match BuildClientWithEnv.
+type EnvFileCredentialSink struct {
+	Path            string
+	ClientIDKey     string
+	ClientSecretKey string
+	Perm            os.FileMode
+}
+
+// Name returns the sink name used in audit records.
+func (s *EnvFileCredentialSink) Name() string {
+	return "envfile:" + s.Path
+}
+
+// WriteCredentials updates the env file with the new credentials.
+func (s *EnvFileCredentialSink) WriteCredentials(_ ResourceApiIntegration, credentials ResourceClientCredentials) error {
+	idKey := s.ClientIDKey
+	if idKey == "" {
+		idKey = "CLIENT_ID"
+	}
+	secretKey := s.ClientSecretKey
+	if secretKey == "" {
+		secretKey = "CLIENT_SECRET"
+	}
+
+	values := map[string]string{
+		idKey:     credentials.ClientID,
+		secretKey: credentials.ClientSecret,
+	}
+
+	existing, err := os.ReadFile(s.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var out bytes.Buffer
+	written := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(existing))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		prefix := ""
+		if strings.HasPrefix(trimmed, "export ") {
+			prefix = "export "
+			trimmed = strings.TrimPrefix(trimmed, prefix)
+		}
+		key, _, found := strings.Cut(trimmed, "=")
+		key = strings.TrimSpace(key)
+		if value, ok := values[key]; ok && found {
+			fmt.Fprintf(&out, "%s%s=%s\n", prefix, key, strconv.Quote(value))
+			written[key] = true
+			continue
+		}
+		out.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, key := range []string{idKey, secretKey} {
+		if !written[key] {
+			fmt.Fprintf(&out, "%s=%s\n", key, strconv.Quote(values[key]))
+		}
+	}
+
+	return writeFileAtomic(s.Path, out.Bytes(), s.Perm)
+}
+
+// VaultCredentialSink writes credentials to a HashiCorp Vault KV version 2 secrets engine,
+// such as the one provided by `vault server -dev`.
+type VaultCredentialSink struct {
+	Address    string // e.g. http://127.0.0.1:8200
+	Token      string
+	Mount      string // defaults to "secret"
+	Path       string // secret path within the mount; supports a %d verb for the integration ID
+	HTTPClient *http.Client
+}
+
+// Name returns the sink name, with {integration_id} in place of any %d verb in the path.
+func (s *VaultCredentialSink) Name() string {
+	return "vault:" + strings.ReplaceAll(s.Path, "%d", "{integration_id}")
+}
+
+// nameFor returns the sink name used in audit records for an integration.
+func (s *VaultCredentialSink) nameFor(integration ResourceApiIntegration) string {
+	return "vault:" + s.secretPath(integration)
+}
+
+// secretPath returns the secret path for an integration.
+func (s *VaultCredentialSink) secretPath(integration ResourceApiIntegration) string {
+	if strings.Contains(s.Path, "%d") {
+		return fmt.Sprintf(s.Path, integration.ID)
+	}
+	return s.Path
+}
+
+// WriteCredentials stores the credentials as a new version of the Vault secret.
+func (s *VaultCredentialSink) WriteCredentials(integration ResourceApiIntegration, credentials ResourceClientCredentials) error {
+	mount := s.Mount
+	if mount == "" {
+		mount = "secret"
+	}
+	secretPath := s.secretPath(integration)
+	httpClient := s.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"data": map[string]string{
+			"client_id":     credentials.ClientID,
+			"client_secret": credentials.ClientSecret,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/data/%s", strings.TrimSuffix(s.Address, "/"), mount, strings.TrimPrefix(secretPath, "/"))
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", s.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("vault returned status code %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+// CallbackCredentialSink hands credentials to an arbitrary function.
+type CallbackCredentialSink struct {
+	SinkName string
+	Callback func(integration ResourceApiIntegration, credentials ResourceClientCredentials) error
+}
+
+// Name returns the sink name used in audit records.
+func (s *CallbackCredentialSink) Name() string {
+	if s.SinkName == "" {
+		return "callback"
+	}
+	return s.SinkName
+}
+
+// WriteCredentials invokes the callback.
+func (s *CallbackCredentialSink) WriteCredentials(integration ResourceApiIntegration, credentials ResourceClientCredentials) error {
+	return s.Callback(integration, credentials)
+}
+
+// credentialSinkName returns the name recorded for a sink in an integration's audit record.
+func credentialSinkName(sink CredentialSink, integration ResourceApiIntegration) string {
+	if named, ok := sink.(interface {
+		nameFor(ResourceApiIntegration) string
+	}); ok {
+		return named.nameFor(integration)
+	}
+	return sink.Name()
+}
+
+// writeFileAtomic writes data to a temporary file alongside path and renames it into place.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if perm == 0 {
+		perm = 0600
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,568 @@
+// util_criteria.go
+// This utility parses and renders smart group and advanced search criteria using a small expression language,
+// and validates criteria slices before they are sent to Jamf Pro. For example
+//
+//	("Operating System Version" like "14." and "Last Check-in" less than 7 days ago) or "Computer Group" member of "Lab"
+//
+// parses into the []SharedSubsetCriteria used by computer groups, mobile device groups and the advanced
+// computer, mobile device and user searches, with priorities, and/or joins and parentheses filled in.
+//
+// Criterion names are double-quoted. Values are double-quoted, or bare when they contain no spaces or
+// parentheses. Operators are the Jamf Pro search types, e.g. `is`, `not like`, `member of`, except for the
+// date search types which are written `before` and `after`. Day based operators may embed their value,
+// e.g. `more than 30 days ago`, `in less than 14 days`. Jamf Pro supports a single level of parentheses.
+package jamfpro
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Criteria search types
+const (
+	CriteriaIs                 = "is"
+	CriteriaIsNot              = "is not"
+	CriteriaLike               = "like"
+	CriteriaNotLike            = "not like"
+	CriteriaHas                = "has"
+	CriteriaDoesNotHave        = "does not have"
+	CriteriaMoreThan           = "more than"
+	CriteriaLessThan           = "less than"
+	CriteriaGreaterThan        = "greater than"
+	CriteriaGreaterThanOrEqual = "greater than or equal"
+	CriteriaLessThanOrEqual    = "less than or equal"
+	CriteriaMemberOf           = "member of"
+	CriteriaNotMemberOf        = "not member of"
+	CriteriaBeforeDate         = "before (yyyy-mm-dd)"
+	CriteriaAfterDate          = "after (yyyy-mm-dd)"
+	CriteriaMoreThanDaysAgo    = "more than x days ago"
+	CriteriaLessThanDaysAgo    = "less than x days ago"
+	CriteriaInMoreThanDays     = "in more than x days"
+	CriteriaInLessThanDays     = "in less than x days"
+	CriteriaMatchesRegex       = "matches regex"
+	CriteriaDoesNotMatchRegex  = "does not match regex"
+)
+
+// criteriaDaysValuePlaceholder stands for the number of days in day based search types.
+const criteriaDaysValuePlaceholder = "x"
+
+// CriteriaTarget selects the criterion names accepted by ValidateCriteria.
+type CriteriaTarget string
+
+const (
+	// CriteriaTargetComputer covers computer smart groups and advanced computer searches.
+	CriteriaTargetComputer CriteriaTarget = "computer"
+	// CriteriaTargetMobileDevice covers mobile device smart groups and advanced mobile device searches.
+	CriteriaTargetMobileDevice CriteriaTarget = "mobile_device"
+	// CriteriaTargetUser covers advanced user searches.
+	CriteriaTargetUser CriteriaTarget = "user"
+	// CriteriaTargetAny accepts any criterion name.
+	CriteriaTargetAny CriteriaTarget = ""
+)
+
+// criteriaSearchTypes lists every supported search type.
+var criteriaSearchTypes = []string{
+	CriteriaIs, CriteriaIsNot, CriteriaLike, CriteriaNotLike, CriteriaHas, CriteriaDoesNotHave,
+	CriteriaMoreThan, CriteriaLessThan, CriteriaGreaterThan, CriteriaGreaterThanOrEqual, CriteriaLessThanOrEqual,
+	CriteriaMemberOf, CriteriaNotMemberOf, CriteriaBeforeDate, CriteriaAfterDate,
+	CriteriaMoreThanDaysAgo, CriteriaLessThanDaysAgo, CriteriaInMoreThanDays, CriteriaInLessThanDays,
+	CriteriaMatchesRegex, CriteriaDoesNotMatchRegex,
+}
+
+// criteriaDaysShorthand maps operator phrases with an embedded day count, written with "x" in place of the
+// number, to their search type.
+var criteriaDaysShorthand = map[string]string{
+	"more than x days ago": CriteriaMoreThanDaysAgo,
+	"more than x days":     CriteriaMoreThanDaysAgo,
+	"less than x days ago": CriteriaLessThanDaysAgo,
+	"less than x days":     CriteriaLessThanDaysAgo,
+	"in more than x days":  CriteriaInMoreThanDays,
+	"in less than x days":  CriteriaInLessThanDays,
+}
+
+// criteriaDateShorthand maps the operators written for date search types, whose names contain parentheses,
+// to their search type.
+var criteriaDateShorthand = map[string]string{
+	"before": CriteriaBeforeDate,
+	"after":  CriteriaAfterDate,
+}
+
+// criteriaOperatorPhrases maps every operator phrase accepted by the parser to its search type.
+var criteriaOperatorPhrases = func() map[string]string {
+	phrases := map[string]string{}
+	for _, searchType := range criteriaSearchTypes {
+		if searchType != CriteriaBeforeDate && searchType != CriteriaAfterDate {
+			phrases[searchType] = searchType
+		}
+	}
+	for phrase, searchType := range criteriaDateShorthand {
+		phrases[phrase] = searchType
+	}
+	for phrase, searchType := range criteriaDaysShorthand {
+		phrases[phrase] = searchType
+	}
+	return phrases
+}()
+
+// criteriaNames lists the built-in criterion names of each target. Extension attribute names are
+// instance specific and are supplied to ValidateCriteria separately.
+var criteriaNames = map[CriteriaTarget][]string{
+	CriteriaTargetComputer: {
+		"Active Directory Status", "Application Bundle ID", "Application Title", "Application Version",
+		"Architecture Type", "Asset Tag", "Available SWUs", "Bar Code", "Battery Capacity", "Boot Drive Available MB",
+		"Boot Drive Percentage Full", "Building", "Cached Packages", "Computer Group", "Computer Name",
+		"Department", "Drive Capacity MB", "Email Address", "Enrollment Method: PreStage enrollment",
+		"FileVault 2 Individual Key Validation", "FileVault 2 Partition Encryption State", "FileVault 2 Status",
+		"Font Title", "Full Name", "Gatekeeper", "IP Address", "Is Leased", "Is Purchased", "Jamf Binary Version",
+		"Last Check-in", "Last Enrollment", "Last Inventory Update", "Last Reported IP Address", "Lease Expiration",
+		"Licensed Software", "Local User Accounts", "MAC Address", "Make", "Managed", "Model", "Model Identifier",
+		"Number of Available Updates", "Number of Processors", "Operating System", "Operating System Build",
+		"Operating System Name", "Operating System Version", "Packages Installed By Casper",
+		"Packages Installed By Installer.app/SWU", "Phone Number", "Platform", "Plug-in Title", "PO Date", "PO Number",
+		"Position", "Printer Name", "Processor Speed MHz", "Processor Type", "Profile Identifier", "Profile Name",
+		"Purchase Price", "Recovery Lock Enabled", "Room", "Running Services", "Serial Number", "Site", "Supervised",
+		"System Integrity Protection", "Total Number of Cores", "Total RAM MB", "UDID", "User Approved MDM",
+		"Username", "Vendor", "Warranty Expiration", "XProtect Definitions Version",
+	},
+	CriteriaTargetMobileDevice: {
+		"Activation Lock Enabled", "App Identifier", "App Name", "App Version", "Asset Tag", "Available Space MB",
+		"Battery Level", "Block Level Encryption Capable", "Bluetooth MAC Address", "Building", "Capacity MB",
+		"Cellular Technology", "Current Carrier Network", "Data Protection", "Data Roaming Enabled", "Department",
+		"Device Name", "Device Ownership Type", "Display Name", "Email Address", "Enrollment Method: PreStage enrollment",
+		"File Level Encryption Capable", "Full Name", "Home Carrier Network", "ICCID", "IMEI", "IP Address",
+		"Last Backup", "Last Enrollment", "Last Inventory Update", "Lost Mode Enabled", "Managed", "Mobile Device Group",
+		"Model", "Model Identifier", "OS Build", "OS Version", "Passcode Status", "Percentage of Space Used",
+		"Phone Number", "PO Date", "PO Number", "Position", "Profile Identifier", "Profile Name", "Roaming", "Room",
+		"Serial Number", "Shared iPad", "Site", "Supervised", "UDID", "Username", "Vendor", "Voice Roaming Enabled",
+		"Warranty Expiration", "Wi-Fi MAC Address", "iOS Version",
+	},
+	CriteriaTargetUser: {
+		"Computer Name", "Email Address", "Full Name", "LDAP Server", "Mobile Device Name", "Phone Number", "Position",
+		"Roster Name", "Site", "User Group", "Username", "VPP Assignment Name",
+	},
+}
+
+// CriteriaError lists every problem found in a criteria expression or slice.
+type CriteriaError struct {
+	Problems []string
+}
+
+func (e *CriteriaError) Error() string {
+	return fmt.Sprintf("invalid criteria: %s", strings.Join(e.Problems, "; "))
+}
+
+// KnownCriteriaNames returns the built-in criterion names of a target.
+func KnownCriteriaNames(target CriteriaTarget) []string {
+	return append([]string{}, criteriaNames[target]...)
+}
+
+// Parsing
+
+// criteriaToken is a lexical token of a criteria expression.
+type criteriaToken struct {
+	text   string
+	quoted bool
+	pos    int
+}
+
+// tokenizeCriteria splits an expression into parentheses, quoted strings and bare words.
+func tokenizeCriteria(expression string) ([]criteriaToken, error) {
+	var tokens []criteriaToken
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, criteriaToken{text: string(r), pos: i})
+			i++
+		case r == '"':
+			start := i
+			var builder strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				builder.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			tokens = append(tokens, criteriaToken{text: builder.String(), quoted: true, pos: start})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+				i++
+			}
+			tokens = append(tokens, criteriaToken{text: string(runes[start:i]), pos: start})
+		}
+	}
+
+	return tokens, nil
+}
+
+// criteriaParser is a cursor over the tokens of an expression.
+type criteriaParser struct {
+	tokens []criteriaToken
+	next   int
+}
+
+func (p *criteriaParser) peek(offset int) (criteriaToken, bool) {
+	if p.next+offset >= len(p.tokens) {
+		return criteriaToken{}, false
+	}
+	return p.tokens[p.next+offset], true
+}
+
+// isWord reports whether a token is the given unquoted keyword, ignoring case.
+func (t criteriaToken) isWord(word string) bool {
+	return !t.quoted && strings.EqualFold(t.text, word)
+}
+
+// ParseCriteria parses a criteria expression into criteria with priorities starting at 0. The result is
+// checked for syntax, parentheses, search types and values but not criterion names; use ValidateCriteria
+// for that.
+func ParseCriteria(expression string) ([]SharedSubsetCriteria, error) {
+	tokens, err := tokenizeCriteria(expression)
+	if err != nil {
+		return nil, &CriteriaError{Problems: []string{err.Error()}}
+	}
+	if len(tokens) == 0 {
+		return nil, &CriteriaError{Problems: []string{"expression is empty"}}
+	}
+
+	p := &criteriaParser{tokens: tokens}
+	var criteria []SharedSubsetCriteria
+	depth := 0
+	expectJoin := false
+	andOr := "and"
+
+	for {
+		token, ok := p.peek(0)
+		if !ok {
+			break
+		}
+
+		if expectJoin {
+			switch {
+			case token.text == ")" && !token.quoted:
+				if depth == 0 {
+					return nil, criteriaSyntaxError(token, "unexpected )")
+				}
+				criteria[len(criteria)-1].ClosingParen = true
+				depth--
+				p.next++
+			case token.isWord("and") || token.isWord("or"):
+				andOr = strings.ToLower(token.text)
+				expectJoin = false
+				p.next++
+			default:
+				return nil, criteriaSyntaxError(token, "expected and, or or )")
+			}
+			continue
+		}
+
+		criterion := SharedSubsetCriteria{Priority: len(criteria), AndOr: andOr}
+		if token.text == "(" && !token.quoted {
+			if depth > 0 {
+				return nil, criteriaSyntaxError(token, "nested parentheses are not supported by Jamf Pro")
+			}
+			criterion.OpeningParen = true
+			depth++
+			p.next++
+		}
+
+		if err := p.parseCriterion(&criterion); err != nil {
+			return nil, err
+		}
+		criteria = append(criteria, criterion)
+		expectJoin = true
+	}
+
+	if !expectJoin {
+		return nil, &CriteriaError{Problems: []string{"expression ends with and/or"}}
+	}
+	if depth > 0 {
+		return nil, &CriteriaError{Problems: []string{"missing )"}}
+	}
+
+	if err := ValidateCriteria(criteria, CriteriaTargetAny); err != nil {
+		return nil, err
+	}
+
+	return criteria, nil
+}
+
+// parseCriterion reads a quoted name, an operator and a value.
+func (p *criteriaParser) parseCriterion(criterion *SharedSubsetCriteria) error {
+	name, ok := p.peek(0)
+	if !ok {
+		return &CriteriaError{Problems: []string{"expression ends before a criterion name"}}
+	}
+	if !name.quoted {
+		return criteriaSyntaxError(name, "expected a quoted criterion name")
+	}
+	criterion.Name = name.text
+	p.next++
+
+	searchType, value, consumed := p.matchOperator()
+	if consumed == 0 {
+		token, ok := p.peek(0)
+		if !ok {
+			return &CriteriaError{Problems: []string{fmt.Sprintf("criterion %q has no operator", criterion.Name)}}
+		}
+		return criteriaSyntaxError(token, fmt.Sprintf("unknown operator for criterion %q", criterion.Name))
+	}
+	p.next += consumed
+	criterion.SearchType = searchType
+
+	if value != nil {
+		criterion.Value = *value
+		return nil
+	}
+
+	token, ok := p.peek(0)
+	if !ok || (!token.quoted && (token.text == "(" || token.text == ")")) {
+		return &CriteriaError{Problems: []string{fmt.Sprintf("criterion %q has no value", criterion.Name)}}
+	}
+	criterion.Value = token.text
+	p.next++
+
+	return nil
+}
+
+// matchOperator finds the longest search type, or day shorthand with an embedded number, at the cursor.
+// It returns the search type, the embedded value if any and the number of tokens consumed.
+func (p *criteriaParser) matchOperator() (string, *string, int) {
+	bestType, bestLength := "", 0
+	var bestValue *string
+
+	for phrase, searchType := range criteriaOperatorPhrases {
+		words := strings.Fields(phrase)
+		_, isShorthand := criteriaDaysShorthand[phrase]
+		var embedded *string
+		matched := true
+
+		for i, word := range words {
+			token, ok := p.peek(i)
+			if !ok || token.quoted {
+				matched = false
+				break
+			}
+			if isShorthand && word == criteriaDaysValuePlaceholder {
+				if _, err := strconv.Atoi(token.text); err != nil {
+					matched = false
+					break
+				}
+				value := token.text
+				embedded = &value
+				continue
+			}
+			if !strings.EqualFold(token.text, word) {
+				matched = false
+				break
+			}
+		}
+
+		if matched && len(words) > bestLength {
+			bestType, bestLength, bestValue = searchType, len(words), embedded
+		}
+	}
+
+	return bestType, bestValue, bestLength
+}
+
+// criteriaSyntaxError reports a problem at a token.
+func criteriaSyntaxError(token criteriaToken, message string) error {
+	return &CriteriaError{Problems: []string{fmt.Sprintf("%s at position %d (%q)", message, token.pos, token.text)}}
+}
+
+// Rendering
+
+// FormatCriteria renders criteria, ordered by priority, as an expression accepted by ParseCriteria.
+func FormatCriteria(criteria []SharedSubsetCriteria) string {
+	ordered := sortedCriteria(criteria)
+
+	var builder strings.Builder
+	for i, criterion := range ordered {
+		if i > 0 {
+			andOr := strings.ToLower(criterion.AndOr)
+			if andOr == "" {
+				andOr = "and"
+			}
+			builder.WriteString(" " + andOr + " ")
+		}
+		if criterion.OpeningParen {
+			builder.WriteString("(")
+		}
+
+		builder.WriteString(quoteCriteriaString(criterion.Name))
+		builder.WriteString(" ")
+		switch criterion.SearchType {
+		case CriteriaBeforeDate, CriteriaAfterDate:
+			builder.WriteString(strings.Fields(criterion.SearchType)[0])
+			builder.WriteString(" ")
+			builder.WriteString(quoteCriteriaString(criterion.Value))
+		case CriteriaMoreThanDaysAgo, CriteriaLessThanDaysAgo, CriteriaInMoreThanDays, CriteriaInLessThanDays:
+			if _, err := strconv.Atoi(criterion.Value); err == nil {
+				builder.WriteString(strings.Replace(criterion.SearchType, criteriaDaysValuePlaceholder, criterion.Value, 1))
+				break
+			}
+			fallthrough
+		default:
+			builder.WriteString(criterion.SearchType)
+			builder.WriteString(" ")
+			builder.WriteString(quoteCriteriaString(criterion.Value))
+		}
+
+		if criterion.ClosingParen {
+			builder.WriteString(")")
+		}
+	}
+
+	return builder.String()
+}
+
+// quoteCriteriaString double-quotes a name or value, escaping quotes and backslashes.
+func quoteCriteriaString(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// sortedCriteria returns a copy of criteria ordered by priority.
+func sortedCriteria(criteria []SharedSubsetCriteria) []SharedSubsetCriteria {
+	ordered := append([]SharedSubsetCriteria{}, criteria...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority < ordered[j].Priority
+	})
+	return ordered
+}
+
+// Validation
+
+// ValidateCriteria checks criteria as Jamf Pro would: priorities must run from 0 without gaps or duplicates,
+// joins must be and/or, parentheses must be balanced and not nested, search types must be known and values
+// must suit their search type. Criterion names must be built-in names of the target or one of extraNames,
+// e.g. extension attribute names, unless the target is CriteriaTargetAny. Every problem found is reported.
+func ValidateCriteria(criteria []SharedSubsetCriteria, target CriteriaTarget, extraNames ...string) error {
+	var problems []string
+
+	names := map[string]bool{}
+	for _, name := range append(KnownCriteriaNames(target), extraNames...) {
+		names[strings.ToLower(name)] = true
+	}
+
+	searchTypes := map[string]bool{}
+	for _, searchType := range criteriaSearchTypes {
+		searchTypes[searchType] = true
+	}
+
+	ordered := sortedCriteria(criteria)
+	depth := 0
+	for i, criterion := range ordered {
+		label := fmt.Sprintf("criterion %d (%q)", i, criterion.Name)
+
+		if criterion.Priority != i {
+			problems = append(problems, fmt.Sprintf("%s has priority %d, expected %d", label, criterion.Priority, i))
+		}
+
+		switch strings.ToLower(criterion.AndOr) {
+		case "and", "":
+		case "or":
+			if i == 0 {
+				problems = append(problems, fmt.Sprintf("%s is the first criterion and must use and", label))
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("%s has invalid and_or %q", label, criterion.AndOr))
+		}
+
+		if criterion.Name == "" {
+			problems = append(problems, fmt.Sprintf("%s has no name", label))
+		} else if target != CriteriaTargetAny && !names[strings.ToLower(criterion.Name)] {
+			problems = append(problems, fmt.Sprintf("%s is not a known %s criterion", label, target))
+		}
+
+		if !searchTypes[criterion.SearchType] {
+			problems = append(problems, fmt.Sprintf("%s has unknown search type %q", label, criterion.SearchType))
+		} else if problem := validateCriteriaValue(criterion.SearchType, criterion.Value); problem != "" {
+			problems = append(problems, fmt.Sprintf("%s %s", label, problem))
+		}
+
+		if criterion.OpeningParen {
+			if depth > 0 {
+				problems = append(problems, fmt.Sprintf("%s opens a parenthesis inside another", label))
+			}
+			depth++
+		}
+		if criterion.ClosingParen {
+			if depth == 0 {
+				problems = append(problems, fmt.Sprintf("%s closes a parenthesis which was not opened", label))
+			} else {
+				depth--
+			}
+		}
+	}
+	if depth > 0 {
+		problems = append(problems, "parenthesis opened but not closed")
+	}
+
+	if len(problems) > 0 {
+		return &CriteriaError{Problems: problems}
+	}
+	return nil
+}
+
+// validateCriteriaValue checks a value against the format required by its search type.
+func validateCriteriaValue(searchType, value string) string {
+	switch searchType {
+	case CriteriaMoreThanDaysAgo, CriteriaLessThanDaysAgo, CriteriaInMoreThanDays, CriteriaInLessThanDays:
+		if days, err := strconv.Atoi(value); err != nil || days < 0 {
+			return fmt.Sprintf("requires a whole number of days, got %q", value)
+		}
+	case CriteriaBeforeDate, CriteriaAfterDate:
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return fmt.Sprintf("requires a yyyy-mm-dd date, got %q", value)
+		}
+	case CriteriaMatchesRegex, CriteriaDoesNotMatchRegex:
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Sprintf("has an invalid regular expression: %v", err)
+		}
+	}
+	return ""
+}
+
+// Containers
+
+// NewComputerGroupCriteria parses an expression into computer group criteria and validates it against
+// computer criterion names and extraNames.
+func NewComputerGroupCriteria(expression string, extraNames ...string) (*ComputerGroupSubsetContainerCriteria, error) {
+	criteria, err := ParseCriteria(expression)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateCriteria(criteria, CriteriaTargetComputer, extraNames...); err != nil {
+		return nil, err
+	}
+	return &ComputerGroupSubsetContainerCriteria{Size: len(criteria), Criterion: &criteria}, nil
+}
+
+// NewSharedContainerCriteria parses an expression into the criteria container used by mobile device groups
+// and advanced searches, validated against the target's criterion names and extraNames.
+func NewSharedContainerCriteria(target CriteriaTarget, expression string, extraNames ...string) (SharedContainerCriteria, error) {
+	criteria, err := ParseCriteria(expression)
+	if err != nil {
+		return SharedContainerCriteria{}, err
+	}
+	if err := ValidateCriteria(criteria, target, extraNames...); err != nil {
+		return SharedContainerCriteria{}, err
+	}
+	return SharedContainerCriteria{Size: len(criteria), Criterion: criteria}, nil
+}
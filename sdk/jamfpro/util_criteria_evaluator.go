@@ -0,0 +1,573 @@
+// util_criteria_evaluator.go
+// This utility evaluates smart group criteria against computer inventory records offline, so the membership
+// of a smart group can be previewed before its criteria are changed.
+//
+// Criteria are combined as Jamf Pro does: parenthesised criteria are evaluated first and "and" binds more
+// tightly than "or". String comparisons ignore case. The result is a prediction; values which Jamf Pro
+// derives server side, such as department and building names, must be supplied through Fields. A criterion
+// the evaluator cannot resolve is an error rather than a guess, as "is" would match no computer and "is not"
+// every computer.
+package jamfpro
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CriteriaFieldFunc returns the inventory values compared by a criterion. Repeated sections, such as
+// applications, return one value per item.
+type CriteriaFieldFunc func(computer *ResourceComputerInventory) []string
+
+// computerCriteriaFields maps built-in computer criterion names, lower-cased, to inventory values.
+var computerCriteriaFields = map[string]CriteriaFieldFunc{
+	"computer name":            computerCriteriaField(func(c *ResourceComputerInventory) string { return c.General.Name }),
+	"serial number":            computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Hardware.SerialNumber }),
+	"udid":                     computerCriteriaField(func(c *ResourceComputerInventory) string { return c.UDID }),
+	"operating system":         computerCriteriaField(func(c *ResourceComputerInventory) string { return c.OperatingSystem.Name }),
+	"operating system name":    computerCriteriaField(func(c *ResourceComputerInventory) string { return c.OperatingSystem.Name }),
+	"operating system version": computerCriteriaField(func(c *ResourceComputerInventory) string { return c.OperatingSystem.Version }),
+	"operating system build":   computerCriteriaField(func(c *ResourceComputerInventory) string { return c.OperatingSystem.Build }),
+	"active directory status":  computerCriteriaField(func(c *ResourceComputerInventory) string { return c.OperatingSystem.ActiveDirectoryStatus }),
+	"filevault 2 status":       computerCriteriaField(func(c *ResourceComputerInventory) string { return c.OperatingSystem.FileVault2Status }),
+	"last check-in":            computerCriteriaField(func(c *ResourceComputerInventory) string { return c.General.LastContactTime }),
+	"last inventory update":    computerCriteriaField(func(c *ResourceComputerInventory) string { return c.General.ReportDate }),
+	"last enrollment":          computerCriteriaField(func(c *ResourceComputerInventory) string { return c.General.LastEnrolledDate }),
+	"platform":                 computerCriteriaField(func(c *ResourceComputerInventory) string { return c.General.Platform }),
+	"ip address":               computerCriteriaField(func(c *ResourceComputerInventory) string { return c.General.LastIpAddress }),
+	"last reported ip address": computerCriteriaField(func(c *ResourceComputerInventory) string { return c.General.LastReportedIp }),
+	"jamf binary version":      computerCriteriaField(func(c *ResourceComputerInventory) string { return c.General.JamfBinaryVersion }),
+	"asset tag":                computerCriteriaField(func(c *ResourceComputerInventory) string { return c.General.AssetTag }),
+	"site":                     computerCriteriaField(func(c *ResourceComputerInventory) string { return c.General.Site.Name }),
+	"managed": computerCriteriaField(func(c *ResourceComputerInventory) string {
+		return strconv.FormatBool(c.General.RemoteManagement.Managed)
+	}),
+	"supervised":            computerCriteriaField(func(c *ResourceComputerInventory) string { return strconv.FormatBool(c.General.Supervised) }),
+	"user approved mdm":     computerCriteriaField(func(c *ResourceComputerInventory) string { return strconv.FormatBool(c.General.UserApprovedMdm) }),
+	"make":                  computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Hardware.Make }),
+	"model":                 computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Hardware.Model }),
+	"model identifier":      computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Hardware.ModelIdentifier }),
+	"processor type":        computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Hardware.ProcessorType }),
+	"architecture type":     computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Hardware.ProcessorArchitecture }),
+	"processor speed mhz":   computerCriteriaField(func(c *ResourceComputerInventory) string { return strconv.Itoa(c.Hardware.ProcessorSpeedMhz) }),
+	"number of processors":  computerCriteriaField(func(c *ResourceComputerInventory) string { return strconv.Itoa(c.Hardware.ProcessorCount) }),
+	"total number of cores": computerCriteriaField(func(c *ResourceComputerInventory) string { return strconv.Itoa(c.Hardware.CoreCount) }),
+	"total ram mb":          computerCriteriaField(func(c *ResourceComputerInventory) string { return strconv.Itoa(c.Hardware.TotalRamMegabytes) }),
+	"battery capacity":      computerCriteriaField(func(c *ResourceComputerInventory) string { return strconv.Itoa(c.Hardware.BatteryCapacityPercent) }),
+	"mac address":           computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Hardware.MacAddress }),
+	"boot drive available mb": computerCriteriaField(func(c *ResourceComputerInventory) string {
+		return strconv.Itoa(c.Storage.BootDriveAvailableSpaceMegabytes)
+	}),
+	"username":                     computerCriteriaField(func(c *ResourceComputerInventory) string { return c.UserAndLocation.Username }),
+	"full name":                    computerCriteriaField(func(c *ResourceComputerInventory) string { return c.UserAndLocation.Realname }),
+	"email address":                computerCriteriaField(func(c *ResourceComputerInventory) string { return c.UserAndLocation.Email }),
+	"position":                     computerCriteriaField(func(c *ResourceComputerInventory) string { return c.UserAndLocation.Position }),
+	"phone number":                 computerCriteriaField(func(c *ResourceComputerInventory) string { return c.UserAndLocation.Phone }),
+	"room":                         computerCriteriaField(func(c *ResourceComputerInventory) string { return c.UserAndLocation.Room }),
+	"is leased":                    computerCriteriaField(func(c *ResourceComputerInventory) string { return strconv.FormatBool(c.Purchasing.Leased) }),
+	"is purchased":                 computerCriteriaField(func(c *ResourceComputerInventory) string { return strconv.FormatBool(c.Purchasing.Purchased) }),
+	"po number":                    computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Purchasing.PoNumber }),
+	"po date":                      computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Purchasing.PoDate }),
+	"vendor":                       computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Purchasing.Vendor }),
+	"warranty expiration":          computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Purchasing.WarrantyDate }),
+	"lease expiration":             computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Purchasing.LeaseDate }),
+	"purchase price":               computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Purchasing.PurchasePrice }),
+	"system integrity protection":  computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Security.SipStatus }),
+	"gatekeeper":                   computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Security.GatekeeperStatus }),
+	"xprotect definitions version": computerCriteriaField(func(c *ResourceComputerInventory) string { return c.Security.XprotectVersion }),
+	"recovery lock enabled":        computerCriteriaField(func(c *ResourceComputerInventory) string { return strconv.FormatBool(c.Security.RecoveryLockEnabled) }),
+	"bar code": func(c *ResourceComputerInventory) []string {
+		return []string{c.General.Barcode1, c.General.Barcode2}
+	},
+	"application title": func(c *ResourceComputerInventory) []string {
+		return collectCriteriaValues(c.Applications, func(a ComputerInventorySubsetApplication) string { return a.Name })
+	},
+	"application version": func(c *ResourceComputerInventory) []string {
+		return collectCriteriaValues(c.Applications, func(a ComputerInventorySubsetApplication) string { return a.Version })
+	},
+	"application bundle id": func(c *ResourceComputerInventory) []string {
+		return collectCriteriaValues(c.Applications, func(a ComputerInventorySubsetApplication) string { return a.BundleId })
+	},
+	"computer group": func(c *ResourceComputerInventory) []string {
+		return collectCriteriaValues(c.GroupMemberships, func(g ComputerInventorySubsetGroupMembership) string { return g.GroupName })
+	},
+	"local user accounts": func(c *ResourceComputerInventory) []string {
+		return collectCriteriaValues(c.LocalUserAccounts, func(a ComputerInventorySubsetLocalUserAccount) string { return a.Username })
+	},
+	"profile name": func(c *ResourceComputerInventory) []string {
+		return collectCriteriaValues(c.ConfigurationProfiles, func(p ComputerInventorySubsetConfigurationProfile) string { return p.DisplayName })
+	},
+	"profile identifier": func(c *ResourceComputerInventory) []string {
+		return collectCriteriaValues(c.ConfigurationProfiles, func(p ComputerInventorySubsetConfigurationProfile) string { return p.ProfileIdentifier })
+	},
+	"printer name": func(c *ResourceComputerInventory) []string {
+		return collectCriteriaValues(c.Printers, func(p ComputerInventorySubsetPrinter) string { return p.Name })
+	},
+	"running services": func(c *ResourceComputerInventory) []string {
+		return collectCriteriaValues(c.Services, func(s ComputerInventorySubsetService) string { return s.Name })
+	},
+	"licensed software": func(c *ResourceComputerInventory) []string {
+		return collectCriteriaValues(c.LicensedSoftware, func(s ComputerInventorySubsetLicensedSoftware) string { return s.Name })
+	},
+	"font title": func(c *ResourceComputerInventory) []string {
+		return collectCriteriaValues(c.Fonts, func(f ComputerInventorySubsetFont) string { return f.Name })
+	},
+	"plug-in title": func(c *ResourceComputerInventory) []string {
+		return collectCriteriaValues(c.Plugins, func(p ComputerInventorySubsetPlugin) string { return p.Name })
+	},
+}
+
+// computerCriteriaField adapts a single-valued accessor to a CriteriaFieldFunc.
+func computerCriteriaField(field func(c *ResourceComputerInventory) string) CriteriaFieldFunc {
+	return func(c *ResourceComputerInventory) []string {
+		return []string{field(c)}
+	}
+}
+
+// collectCriteriaValues returns one value per item of a repeated section.
+func collectCriteriaValues[T any](items []T, field func(item T) string) []string {
+	values := make([]string, len(items))
+	for i, item := range items {
+		values[i] = field(item)
+	}
+	return values
+}
+
+// ComputerCriteriaEvaluator predicts smart group membership from computer inventory.
+type ComputerCriteriaEvaluator struct {
+	// Fields adds or overrides criterion names, e.g. "Department" resolved from department IDs.
+	Fields map[string]CriteriaFieldFunc
+
+	// ExtensionAttributes names the extension attributes criteria may refer to. Extension attributes
+	// present in the evaluated inventory are recognised without being listed. Any other criterion which is
+	// neither a field nor built-in is an error.
+	ExtensionAttributes []string
+
+	// Now is the reference time for day based criteria. Defaults to the current time.
+	Now time.Time
+}
+
+// compiledCriterion is a criterion prepared for repeated evaluation.
+type compiledCriterion struct {
+	SharedSubsetCriteria
+	field   CriteriaFieldFunc
+	pattern *regexp.Regexp
+}
+
+// compile validates criteria and resolves their fields. Extension attributes are recognised when listed in
+// ExtensionAttributes or present on one of the computers.
+func (e *ComputerCriteriaEvaluator) compile(criteria []SharedSubsetCriteria, computers []ResourceComputerInventory) ([]compiledCriterion, error) {
+	if err := ValidateCriteria(criteria, CriteriaTargetAny); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]CriteriaFieldFunc{}
+	for name, field := range e.Fields {
+		fields[strings.ToLower(name)] = field
+	}
+
+	attributes := map[string]bool{}
+	for _, name := range e.ExtensionAttributes {
+		attributes[strings.ToLower(name)] = true
+	}
+	for i := range computers {
+		for _, section := range computerExtensionAttributeSections(&computers[i]) {
+			for _, attribute := range section {
+				attributes[strings.ToLower(attribute.Name)] = true
+			}
+		}
+	}
+
+	var compiled []compiledCriterion
+	for _, criterion := range sortedCriteria(criteria) {
+		entry := compiledCriterion{SharedSubsetCriteria: criterion}
+
+		name := strings.ToLower(criterion.Name)
+		if field, ok := fields[name]; ok {
+			entry.field = field
+		} else if field, ok := computerCriteriaFields[name]; ok {
+			entry.field = field
+		} else if attributes[name] {
+			entry.field = extensionAttributeField(criterion.Name)
+		} else {
+			return nil, fmt.Errorf("criterion %q cannot be evaluated offline; supply it through Fields or list it in ExtensionAttributes", criterion.Name)
+		}
+
+		if criterion.SearchType == CriteriaMatchesRegex || criterion.SearchType == CriteriaDoesNotMatchRegex {
+			pattern, err := regexp.Compile("(?i)" + criterion.Value)
+			if err != nil {
+				return nil, fmt.Errorf("criterion %q has an invalid regular expression: %v", criterion.Name, err)
+			}
+			entry.pattern = pattern
+		}
+
+		compiled = append(compiled, entry)
+	}
+
+	return compiled, nil
+}
+
+// computerExtensionAttributeSections returns the extension attributes of every inventory section.
+func computerExtensionAttributeSections(c *ResourceComputerInventory) [][]ComputerInventorySubsetExtensionAttribute {
+	return [][]ComputerInventorySubsetExtensionAttribute{
+		c.ExtensionAttributes,
+		c.General.ExtensionAttributes,
+		c.Hardware.ExtensionAttributes,
+		c.OperatingSystem.ExtensionAttributes,
+		c.UserAndLocation.ExtensionAttributes,
+		c.Purchasing.ExtensionAttributes,
+	}
+}
+
+// extensionAttributeField returns the values of the extension attribute with a name from every inventory section.
+func extensionAttributeField(name string) CriteriaFieldFunc {
+	return func(c *ResourceComputerInventory) []string {
+		for _, attributes := range computerExtensionAttributeSections(c) {
+			for _, attribute := range attributes {
+				if strings.EqualFold(attribute.Name, name) {
+					if len(attribute.Values) == 0 {
+						return []string{""}
+					}
+					return attribute.Values
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// Matches reports whether a computer satisfies the criteria. Empty criteria match nothing.
+func (e *ComputerCriteriaEvaluator) Matches(criteria []SharedSubsetCriteria, computer *ResourceComputerInventory) (bool, error) {
+	compiled, err := e.compile(criteria, []ResourceComputerInventory{*computer})
+	if err != nil {
+		return false, err
+	}
+	return e.matches(compiled, computer), nil
+}
+
+// Members returns the computers satisfying the criteria.
+func (e *ComputerCriteriaEvaluator) Members(criteria []SharedSubsetCriteria, computers []ResourceComputerInventory) ([]ResourceComputerInventory, error) {
+	compiled, err := e.compile(criteria, computers)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []ResourceComputerInventory
+	for i := range computers {
+		if e.matches(compiled, &computers[i]) {
+			members = append(members, computers[i])
+		}
+	}
+	return members, nil
+}
+
+// matches combines criterion results. Parenthesised runs are reduced first, then "and" binds before "or".
+func (e *ComputerCriteriaEvaluator) matches(criteria []compiledCriterion, computer *ResourceComputerInventory) bool {
+	if len(criteria) == 0 {
+		return false
+	}
+
+	type term struct {
+		andOr string
+		value bool
+	}
+
+	var terms, group []term
+	inGroup := false
+	for _, criterion := range criteria {
+		current := term{andOr: strings.ToLower(criterion.AndOr), value: e.evaluate(criterion, computer)}
+
+		if criterion.OpeningParen {
+			inGroup = true
+			group = []term{current}
+		} else if inGroup {
+			group = append(group, current)
+		} else {
+			terms = append(terms, current)
+		}
+
+		if inGroup && criterion.ClosingParen {
+			inGroup = false
+			values := make([]bool, len(group))
+			joins := make([]string, len(group))
+			for i, t := range group {
+				values[i], joins[i] = t.value, t.andOr
+			}
+			terms = append(terms, term{andOr: group[0].andOr, value: combineCriteria(joins, values)})
+		}
+	}
+
+	values := make([]bool, len(terms))
+	joins := make([]string, len(terms))
+	for i, t := range terms {
+		values[i], joins[i] = t.value, t.andOr
+	}
+	return combineCriteria(joins, values)
+}
+
+// combineCriteria evaluates values joined by and/or, with "and" taking precedence.
+func combineCriteria(joins []string, values []bool) bool {
+	result, run := false, true
+	for i, value := range values {
+		if i > 0 && joins[i] == "or" {
+			result = result || run
+			run = true
+		}
+		run = run && value
+	}
+	return result || run
+}
+
+// evaluate applies a single criterion to a computer.
+func (e *ComputerCriteriaEvaluator) evaluate(criterion compiledCriterion, computer *ResourceComputerInventory) bool {
+	values := criterion.field(computer)
+	want := criterion.Value
+
+	anyValue := func(match func(value string) bool) bool {
+		for _, value := range values {
+			if match(value) {
+				return true
+			}
+		}
+		return false
+	}
+	isEmpty := len(values) == 0 || !anyValue(func(value string) bool { return value != "" })
+
+	switch criterion.SearchType {
+	case CriteriaIs, CriteriaHas:
+		if want == "" {
+			return isEmpty
+		}
+		return anyValue(func(value string) bool { return criteriaValuesEqual(value, want) })
+	case CriteriaIsNot, CriteriaDoesNotHave:
+		if want == "" {
+			return !isEmpty
+		}
+		return !anyValue(func(value string) bool { return criteriaValuesEqual(value, want) })
+	case CriteriaLike:
+		return anyValue(func(value string) bool { return strings.Contains(strings.ToLower(value), strings.ToLower(want)) })
+	case CriteriaNotLike:
+		return !anyValue(func(value string) bool { return strings.Contains(strings.ToLower(value), strings.ToLower(want)) })
+	case CriteriaMemberOf:
+		return anyValue(func(value string) bool { return strings.EqualFold(value, want) })
+	case CriteriaNotMemberOf:
+		return !anyValue(func(value string) bool { return strings.EqualFold(value, want) })
+	case CriteriaMatchesRegex:
+		return anyValue(criterion.pattern.MatchString)
+	case CriteriaDoesNotMatchRegex:
+		return !anyValue(criterion.pattern.MatchString)
+	case CriteriaGreaterThan, CriteriaMoreThan:
+		return anyValue(func(value string) bool { return value != "" && compareCriteriaValues(value, want) > 0 })
+	case CriteriaGreaterThanOrEqual:
+		return anyValue(func(value string) bool { return value != "" && compareCriteriaValues(value, want) >= 0 })
+	case CriteriaLessThan:
+		return anyValue(func(value string) bool { return value != "" && compareCriteriaValues(value, want) < 0 })
+	case CriteriaLessThanOrEqual:
+		return anyValue(func(value string) bool { return value != "" && compareCriteriaValues(value, want) <= 0 })
+	case CriteriaBeforeDate, CriteriaAfterDate:
+		date, err := time.Parse("2006-01-02", want)
+		if err != nil {
+			return false
+		}
+		return anyValue(func(value string) bool {
+			at, ok := parseCriteriaTime(value)
+			if !ok {
+				return false
+			}
+			if criterion.SearchType == CriteriaBeforeDate {
+				return at.Before(date)
+			}
+			return !at.Before(date.AddDate(0, 0, 1))
+		})
+	case CriteriaMoreThanDaysAgo, CriteriaLessThanDaysAgo, CriteriaInMoreThanDays, CriteriaInLessThanDays:
+		days, err := strconv.Atoi(want)
+		if err != nil {
+			return false
+		}
+		now := e.Now
+		if now.IsZero() {
+			now = time.Now()
+		}
+		return anyValue(func(value string) bool {
+			at, ok := parseCriteriaTime(value)
+			if !ok {
+				return false
+			}
+			switch criterion.SearchType {
+			case CriteriaMoreThanDaysAgo:
+				return at.Before(now.AddDate(0, 0, -days))
+			case CriteriaLessThanDaysAgo:
+				return !at.Before(now.AddDate(0, 0, -days))
+			case CriteriaInMoreThanDays:
+				return at.After(now.AddDate(0, 0, days))
+			default:
+				return at.After(now) && !at.After(now.AddDate(0, 0, days))
+			}
+		})
+	}
+
+	return false
+}
+
+// criteriaValuesEqual compares values ignoring case, treating yes/no as booleans.
+func criteriaValuesEqual(value, want string) bool {
+	if strings.EqualFold(value, want) {
+		return true
+	}
+	valueBool, valueOK := parseCriteriaBool(value)
+	wantBool, wantOK := parseCriteriaBool(want)
+	return valueOK && wantOK && valueBool == wantBool
+}
+
+// parseCriteriaBool parses true/false and yes/no.
+func parseCriteriaBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "true", "yes":
+		return true, true
+	case "false", "no":
+		return false, true
+	}
+	return false, false
+}
+
+// compareCriteriaValues compares numbers numerically, dotted versions component by component and other
+// values as case-insensitive strings.
+func compareCriteriaValues(value, want string) int {
+	if a, err := strconv.ParseFloat(value, 64); err == nil {
+		if b, err := strconv.ParseFloat(want, 64); err == nil {
+			switch {
+			case a < b:
+				return -1
+			case a > b:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+
+	if a, ok := parseCriteriaVersion(value); ok {
+		if b, ok := parseCriteriaVersion(want); ok {
+			for i := 0; i < len(a) || i < len(b); i++ {
+				var x, y int
+				if i < len(a) {
+					x = a[i]
+				}
+				if i < len(b) {
+					y = b[i]
+				}
+				if x != y {
+					if x < y {
+						return -1
+					}
+					return 1
+				}
+			}
+			return 0
+		}
+	}
+
+	return strings.Compare(strings.ToLower(value), strings.ToLower(want))
+}
+
+// parseCriteriaVersion parses a dotted numeric version such as 14.2.1.
+func parseCriteriaVersion(value string) ([]int, bool) {
+	parts := strings.Split(value, ".")
+	version := make([]int, len(parts))
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		version[i] = number
+	}
+	return version, true
+}
+
+// parseCriteriaTime parses the timestamp formats used in inventory records.
+func parseCriteriaTime(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if at, err := time.Parse(layout, value); err == nil {
+			return at, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Preview
+
+// ComputerGroupMembershipPreview compares the predicted membership of a computer group with its current members.
+type ComputerGroupMembershipPreview struct {
+	GroupID   int
+	GroupName string
+	Predicted []ComputerGroupSubsetComputer
+	Added     []ComputerGroupSubsetComputer // predicted but not currently members
+	Removed   []ComputerGroupSubsetComputer // currently members but not predicted
+	Unchanged int
+}
+
+// PreviewComputerGroupMembership evaluates criteria against computers and compares the result with the current
+// membership of a computer group. When criteria is nil the group's own criteria are evaluated. The inventory
+// must include the sections the criteria refer to; computers absent from it are reported as removed.
+func (c *Client) PreviewComputerGroupMembership(groupID string, criteria []SharedSubsetCriteria, computers []ResourceComputerInventory, evaluator *ComputerCriteriaEvaluator) (*ComputerGroupMembershipPreview, error) {
+	group, err := c.GetComputerGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if criteria == nil && group.Criteria != nil && group.Criteria.Criterion != nil {
+		criteria = *group.Criteria.Criterion
+	}
+	if evaluator == nil {
+		evaluator = &ComputerCriteriaEvaluator{}
+	}
+
+	members, err := evaluator.Members(criteria, computers)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &ComputerGroupMembershipPreview{GroupID: group.ID, GroupName: group.Name}
+
+	current := map[int]ComputerGroupSubsetComputer{}
+	if group.Computers != nil {
+		for _, computer := range *group.Computers {
+			current[computer.ID] = computer
+		}
+	}
+
+	predicted := map[int]bool{}
+	for _, member := range members {
+		id, err := strconv.Atoi(member.ID)
+		if err != nil {
+			return nil, fmt.Errorf("computer %q has a non-numeric ID", member.ID)
+		}
+		predicted[id] = true
+
+		computer := ComputerGroupSubsetComputer{
+			ID:           id,
+			Name:         member.General.Name,
+			SerialNumber: member.Hardware.SerialNumber,
+			MacAddress:   member.Hardware.MacAddress,
+		}
+		preview.Predicted = append(preview.Predicted, computer)
+
+		if _, ok := current[id]; ok {
+			preview.Unchanged++
+		} else {
+			preview.Added = append(preview.Added, computer)
+		}
+	}
+
+	for id, computer := range current {
+		if !predicted[id] {
+			preview.Removed = append(preview.Removed, computer)
+		}
+	}
+	sort.Slice(preview.Removed, func(i, j int) bool { return preview.Removed[i].ID < preview.Removed[j].ID })
+
+	return preview, nil
+}
@@ -0,0 +1,165 @@
+// util_criteria_evaluator_test.go
+// Unit tests for offline smart group criteria evaluation.
+package jamfpro
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// criteria numbers criteria in order, as Jamf Pro requires.
+func criteria(items ...SharedSubsetCriteria) []SharedSubsetCriteria {
+	for i := range items {
+		items[i].Priority = i
+	}
+	return items
+}
+
+func testComputer() *ResourceComputerInventory {
+	computer := &ResourceComputerInventory{ID: "1", UDID: "UDID-1"}
+	computer.General.Name = "Finance-MBP-01"
+	computer.General.LastContactTime = "2024-06-10T09:00:00Z"
+	computer.General.RemoteManagement.Managed = true
+	computer.Hardware.SerialNumber = "C02ABC123"
+	computer.Hardware.TotalRamMegabytes = 16384
+	computer.OperatingSystem.Version = "14.2.1"
+	computer.Applications = []ComputerInventorySubsetApplication{{Name: "Safari.app"}, {Name: "Slack.app"}}
+	computer.ExtensionAttributes = []ComputerInventorySubsetExtensionAttribute{{Name: "Cost Centre", Values: []string{"CC-100"}}}
+	return computer
+}
+
+func TestComputerCriteriaEvaluatorOperators(t *testing.T) {
+	evaluator := &ComputerCriteriaEvaluator{Now: time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name       string
+		field      string
+		searchType string
+		value      string
+		want       bool
+	}{
+		{"is ignores case", "Computer Name", CriteriaIs, "finance-mbp-01", true},
+		{"is different value", "Computer Name", CriteriaIs, "HR-MBP-01", false},
+		{"is empty value matches empty field", "Asset Tag", CriteriaIs, "", true},
+		{"is not", "Computer Name", CriteriaIsNot, "HR-MBP-01", true},
+		{"is not same value", "Computer Name", CriteriaIsNot, "Finance-MBP-01", false},
+		{"is boolean as yes", "Managed", CriteriaIs, "Yes", true},
+		{"like", "Computer Name", CriteriaLike, "mbp", true},
+		{"not like", "Computer Name", CriteriaNotLike, "mbp", false},
+		{"has repeated value", "Application Title", CriteriaHas, "Slack.app", true},
+		{"does not have repeated value", "Application Title", CriteriaDoesNotHave, "Zoom.app", true},
+		{"greater than number", "Total RAM MB", CriteriaGreaterThan, "8192", true},
+		{"less than number", "Total RAM MB", CriteriaLessThan, "8192", false},
+		{"greater than or equal version", "Operating System Version", CriteriaGreaterThanOrEqual, "14.2", true},
+		{"less than version", "Operating System Version", CriteriaLessThan, "14.10", true},
+		{"less than or equal version", "Operating System Version", CriteriaLessThanOrEqual, "14.2.1", true},
+		{"matches regex", "Serial Number", CriteriaMatchesRegex, "^c02", true},
+		{"does not match regex", "Serial Number", CriteriaDoesNotMatchRegex, "^c02", false},
+		{"before date", "Last Check-in", CriteriaBeforeDate, "2024-06-11", true},
+		{"after date", "Last Check-in", CriteriaAfterDate, "2024-06-10", false},
+		{"more than days ago", "Last Check-in", CriteriaMoreThanDaysAgo, "3", true},
+		{"less than days ago", "Last Check-in", CriteriaLessThanDaysAgo, "3", false},
+		{"extension attribute from inventory", "Cost Centre", CriteriaIs, "cc-100", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evaluator.Matches(criteria(SharedSubsetCriteria{
+				Name: tt.field, AndOr: "and", SearchType: tt.searchType, Value: tt.value,
+			}), testComputer())
+			if err != nil {
+				t.Fatalf("Matches() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputerCriteriaEvaluatorPrecedence(t *testing.T) {
+	evaluator := &ComputerCriteriaEvaluator{}
+
+	// Each criterion compares the computer name, so its result is known in advance.
+	result := func(value bool) SharedSubsetCriteria {
+		if value {
+			return SharedSubsetCriteria{Name: "Computer Name", SearchType: CriteriaIs, Value: "Finance-MBP-01"}
+		}
+		return SharedSubsetCriteria{Name: "Computer Name", SearchType: CriteriaIs, Value: "none"}
+	}
+	join := func(andOr string, criterion SharedSubsetCriteria) SharedSubsetCriteria {
+		criterion.AndOr = andOr
+		return criterion
+	}
+	open := func(criterion SharedSubsetCriteria) SharedSubsetCriteria {
+		criterion.OpeningParen = true
+		return criterion
+	}
+	closing := func(criterion SharedSubsetCriteria) SharedSubsetCriteria {
+		criterion.ClosingParen = true
+		return criterion
+	}
+
+	tests := []struct {
+		name     string
+		criteria []SharedSubsetCriteria
+		want     bool
+	}{
+		{"true and false", criteria(join("and", result(true)), join("and", result(false))), false},
+		{"false or true", criteria(join("and", result(false)), join("or", result(true))), true},
+		// true or (false and false): and binds first
+		{"and binds before or", criteria(join("and", result(true)), join("or", result(false)), join("and", result(false))), true},
+		// false and false or true
+		{"or after and run", criteria(join("and", result(false)), join("and", result(false)), join("or", result(true))), true},
+		// (true or false) and false
+		{"parentheses group or", criteria(open(join("and", result(true))), closing(join("or", result(false))), join("and", result(false))), false},
+		// false and (false or true)
+		{"parentheses after and", criteria(join("and", result(false)), open(join("and", result(false))), closing(join("or", result(true)))), false},
+		// true and (false or true)
+		{"parentheses satisfied", criteria(join("and", result(true)), open(join("and", result(false))), closing(join("or", result(true)))), true},
+		{"empty criteria", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := evaluator.Matches(tt.criteria, testComputer())
+			if err != nil {
+				t.Fatalf("Matches() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputerCriteriaEvaluatorUnresolvedCriteria(t *testing.T) {
+	tests := []struct {
+		name      string
+		evaluator *ComputerCriteriaEvaluator
+		field     string
+		wantErr   bool
+	}{
+		{"built-in name without a field", &ComputerCriteriaEvaluator{}, "Department", true},
+		{"unknown extension attribute", &ComputerCriteriaEvaluator{}, "Battery Health", true},
+		{"listed extension attribute", &ComputerCriteriaEvaluator{ExtensionAttributes: []string{"Battery Health"}}, "Battery Health", false},
+		{"field supplied", &ComputerCriteriaEvaluator{Fields: map[string]CriteriaFieldFunc{
+			"Department": func(*ResourceComputerInventory) []string { return []string{"Finance"} },
+		}}, "Department", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.evaluator.Members(criteria(SharedSubsetCriteria{
+				Name: tt.field, AndOr: "and", SearchType: CriteriaIsNot, Value: "Finance",
+			}), []ResourceComputerInventory{*testComputer()})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Members() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.field) {
+				t.Errorf("Members() error = %q, want it to name %q", err, tt.field)
+			}
+		})
+	}
+}
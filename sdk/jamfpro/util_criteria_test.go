@@ -0,0 +1,346 @@
+// util_criteria_test.go
+// Unit tests for parsing, rendering and validating smart group and advanced search criteria.
+package jamfpro
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCriteria(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       []SharedSubsetCriteria
+	}{
+		{
+			"single criterion",
+			`"Computer Name" is "Finance-MBP-01"`,
+			criteria(SharedSubsetCriteria{Name: "Computer Name", AndOr: "and", SearchType: CriteriaIs, Value: "Finance-MBP-01"}),
+		},
+		{
+			"bare value and multi-word operator",
+			`"Computer Group" not member of Lab`,
+			criteria(SharedSubsetCriteria{Name: "Computer Group", AndOr: "and", SearchType: CriteriaNotMemberOf, Value: "Lab"}),
+		},
+		{
+			"operators ignore case",
+			`"Operating System Version" LIKE 14. OR "Managed" Is Yes`,
+			criteria(
+				SharedSubsetCriteria{Name: "Operating System Version", AndOr: "and", SearchType: CriteriaLike, Value: "14."},
+				SharedSubsetCriteria{Name: "Managed", AndOr: "or", SearchType: CriteriaIs, Value: "Yes"},
+			),
+		},
+		{
+			"longest operator wins",
+			`"Total RAM MB" greater than or equal 8192`,
+			criteria(SharedSubsetCriteria{Name: "Total RAM MB", AndOr: "and", SearchType: CriteriaGreaterThanOrEqual, Value: "8192"}),
+		},
+		{
+			"embedded day count",
+			`"Last Check-in" more than 30 days ago and "Warranty Expiration" in less than 14 days`,
+			criteria(
+				SharedSubsetCriteria{Name: "Last Check-in", AndOr: "and", SearchType: CriteriaMoreThanDaysAgo, Value: "30"},
+				SharedSubsetCriteria{Name: "Warranty Expiration", AndOr: "and", SearchType: CriteriaInLessThanDays, Value: "14"},
+			),
+		},
+		{
+			"date shorthand",
+			`"Last Enrollment" before 2024-01-31`,
+			criteria(SharedSubsetCriteria{Name: "Last Enrollment", AndOr: "and", SearchType: CriteriaBeforeDate, Value: "2024-01-31"}),
+		},
+		{
+			"escaped quotes in value",
+			`"Computer Name" is "Bob\"s \\ Mac"`,
+			criteria(SharedSubsetCriteria{Name: "Computer Name", AndOr: "and", SearchType: CriteriaIs, Value: `Bob"s \ Mac`}),
+		},
+		{
+			"joins keep expression order",
+			`"Building" is HQ or "Room" is 1 and "Managed" is Yes`,
+			criteria(
+				SharedSubsetCriteria{Name: "Building", AndOr: "and", SearchType: CriteriaIs, Value: "HQ"},
+				SharedSubsetCriteria{Name: "Room", AndOr: "or", SearchType: CriteriaIs, Value: "1"},
+				SharedSubsetCriteria{Name: "Managed", AndOr: "and", SearchType: CriteriaIs, Value: "Yes"},
+			),
+		},
+		{
+			"parentheses",
+			`("Building" is HQ or "Room" is 1) and "Managed" is Yes`,
+			criteria(
+				SharedSubsetCriteria{Name: "Building", AndOr: "and", SearchType: CriteriaIs, Value: "HQ", OpeningParen: true},
+				SharedSubsetCriteria{Name: "Room", AndOr: "or", SearchType: CriteriaIs, Value: "1", ClosingParen: true},
+				SharedSubsetCriteria{Name: "Managed", AndOr: "and", SearchType: CriteriaIs, Value: "Yes"},
+			),
+		},
+		{
+			"parenthesised single criterion",
+			`"Managed" is Yes and ("Building" is HQ)`,
+			criteria(
+				SharedSubsetCriteria{Name: "Managed", AndOr: "and", SearchType: CriteriaIs, Value: "Yes"},
+				SharedSubsetCriteria{Name: "Building", AndOr: "and", SearchType: CriteriaIs, Value: "HQ", OpeningParen: true, ClosingParen: true},
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCriteria(tt.expression)
+			if err != nil {
+				t.Fatalf("ParseCriteria() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCriteria() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCriteriaErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantErr    string
+	}{
+		{"empty", "  ", "expression is empty"},
+		{"unterminated string", `"Computer Name is Mac`, "unterminated string"},
+		{"unquoted name", `Building is HQ`, "expected a quoted criterion name"},
+		{"unknown operator", `"Building" resembles HQ`, "unknown operator"},
+		{"missing operator", `"Building"`, "has no operator"},
+		{"missing value", `"Building" is`, "has no value"},
+		{"missing join", `"Building" is HQ "Room" is 1`, "expected and, or or )"},
+		{"trailing join", `"Building" is HQ and`, "ends with and/or"},
+		{"nested parentheses", `("Building" is HQ and ("Room" is 1))`, "nested parentheses"},
+		{"unclosed parenthesis", `("Building" is HQ`, "missing )"},
+		{"unopened parenthesis", `"Building" is HQ)`, "unexpected )"},
+		{"invalid date", `"Last Enrollment" after 31/01/2024`, "yyyy-mm-dd"},
+		{"invalid regex", `"Computer Name" matches regex "(["`, "invalid regular expression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCriteria(tt.expression)
+			var criteriaErr *CriteriaError
+			if !errors.As(err, &criteriaErr) {
+				t.Fatalf("ParseCriteria() error = %v, want a *CriteriaError", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseCriteria() error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatCriteriaRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{"single criterion", `"Computer Name" is "Finance-MBP-01"`},
+		{"and or joins", `"Building" is "HQ" or "Room" is "1" and "Managed" is "Yes"`},
+		{"parentheses", `("Building" is "HQ" or "Room" is "1") and "Managed" is "Yes"`},
+		{"day based operators", `"Last Check-in" less than 7 days ago and "Warranty Expiration" in more than 30 days`},
+		{"date operators", `"Last Enrollment" after "2024-01-01" and "Last Enrollment" before "2024-02-01"`},
+		{"escaped value", `"Computer Name" like "Bob\"s \\ Mac"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseCriteria(tt.expression)
+			if err != nil {
+				t.Fatalf("ParseCriteria() error = %v", err)
+			}
+			formatted := FormatCriteria(parsed)
+			if formatted != tt.expression {
+				t.Errorf("FormatCriteria() = %q, want %q", formatted, tt.expression)
+			}
+			reparsed, err := ParseCriteria(formatted)
+			if err != nil {
+				t.Fatalf("ParseCriteria(FormatCriteria()) error = %v", err)
+			}
+			if !reflect.DeepEqual(reparsed, parsed) {
+				t.Errorf("ParseCriteria(FormatCriteria()) = %+v, want %+v", reparsed, parsed)
+			}
+		})
+	}
+}
+
+func TestFormatCriteria(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria []SharedSubsetCriteria
+		want     string
+	}{
+		{
+			"orders by priority",
+			[]SharedSubsetCriteria{
+				{Name: "Room", Priority: 1, AndOr: "or", SearchType: CriteriaIs, Value: "1"},
+				{Name: "Building", Priority: 0, AndOr: "and", SearchType: CriteriaIs, Value: "HQ"},
+			},
+			`"Building" is "HQ" or "Room" is "1"`,
+		},
+		{
+			"empty join renders as and",
+			criteria(
+				SharedSubsetCriteria{Name: "Building", SearchType: CriteriaIs, Value: "HQ"},
+				SharedSubsetCriteria{Name: "Room", SearchType: CriteriaIs, Value: "1"},
+			),
+			`"Building" is "HQ" and "Room" is "1"`,
+		},
+		{
+			"non-numeric day value keeps search type",
+			criteria(SharedSubsetCriteria{Name: "Last Check-in", SearchType: CriteriaMoreThanDaysAgo, Value: ""}),
+			`"Last Check-in" more than x days ago ""`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatCriteria(tt.criteria); got != tt.want {
+				t.Errorf("FormatCriteria() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCriteria(t *testing.T) {
+	valid := func() SharedSubsetCriteria {
+		return SharedSubsetCriteria{Name: "Building", AndOr: "and", SearchType: CriteriaIs, Value: "HQ"}
+	}
+	with := func(change func(*SharedSubsetCriteria)) SharedSubsetCriteria {
+		criterion := valid()
+		change(&criterion)
+		return criterion
+	}
+
+	tests := []struct {
+		name       string
+		criteria   []SharedSubsetCriteria
+		target     CriteriaTarget
+		extraNames []string
+		wantErrs   []string
+	}{
+		{"valid computer criteria", criteria(valid(), valid()), CriteriaTargetComputer, nil, nil},
+		{"empty criteria", nil, CriteriaTargetComputer, nil, nil},
+		{"names ignore case", criteria(with(func(c *SharedSubsetCriteria) { c.Name = "building" })), CriteriaTargetComputer, nil, nil},
+		{"extension attribute name", criteria(with(func(c *SharedSubsetCriteria) { c.Name = "Cost Centre" })), CriteriaTargetComputer, []string{"Cost Centre"}, nil},
+		{"any target accepts any name", criteria(with(func(c *SharedSubsetCriteria) { c.Name = "Cost Centre" })), CriteriaTargetAny, nil, nil},
+		{
+			"unknown name for target",
+			criteria(with(func(c *SharedSubsetCriteria) { c.Name = "Cost Centre" })),
+			CriteriaTargetComputer, nil,
+			[]string{`criterion 0 ("Cost Centre") is not a known computer criterion`},
+		},
+		{
+			"name of another target",
+			criteria(with(func(c *SharedSubsetCriteria) { c.Name = "Device Name" })),
+			CriteriaTargetComputer, nil,
+			[]string{"is not a known computer criterion"},
+		},
+		{
+			"missing name",
+			criteria(with(func(c *SharedSubsetCriteria) { c.Name = "" })),
+			CriteriaTargetAny, nil,
+			[]string{"has no name"},
+		},
+		{
+			"priority gap",
+			[]SharedSubsetCriteria{valid(), with(func(c *SharedSubsetCriteria) { c.Priority = 2 })},
+			CriteriaTargetAny, nil,
+			[]string{"has priority 2, expected 1"},
+		},
+		{
+			"duplicate priority",
+			[]SharedSubsetCriteria{valid(), valid()},
+			CriteriaTargetAny, nil,
+			[]string{"has priority 0, expected 1"},
+		},
+		{
+			"first criterion joined with or",
+			criteria(with(func(c *SharedSubsetCriteria) { c.AndOr = "or" })),
+			CriteriaTargetAny, nil,
+			[]string{"is the first criterion and must use and"},
+		},
+		{
+			"invalid join",
+			criteria(valid(), with(func(c *SharedSubsetCriteria) { c.AndOr = "xor" })),
+			CriteriaTargetAny, nil,
+			[]string{`has invalid and_or "xor"`},
+		},
+		{
+			"unknown search type",
+			criteria(with(func(c *SharedSubsetCriteria) { c.SearchType = "resembles" })),
+			CriteriaTargetAny, nil,
+			[]string{`has unknown search type "resembles"`},
+		},
+		{
+			"negative day count",
+			criteria(with(func(c *SharedSubsetCriteria) { c.SearchType, c.Value = CriteriaLessThanDaysAgo, "-1" })),
+			CriteriaTargetAny, nil,
+			[]string{"requires a whole number of days"},
+		},
+		{
+			"invalid date",
+			criteria(with(func(c *SharedSubsetCriteria) { c.SearchType, c.Value = CriteriaAfterDate, "2024-13-01" })),
+			CriteriaTargetAny, nil,
+			[]string{"requires a yyyy-mm-dd date"},
+		},
+		{
+			"nested parentheses",
+			criteria(
+				with(func(c *SharedSubsetCriteria) { c.OpeningParen = true }),
+				with(func(c *SharedSubsetCriteria) { c.OpeningParen, c.ClosingParen = true, true }),
+				with(func(c *SharedSubsetCriteria) { c.ClosingParen = true }),
+			),
+			CriteriaTargetAny, nil,
+			[]string{"opens a parenthesis inside another"},
+		},
+		{
+			"unopened parenthesis",
+			criteria(with(func(c *SharedSubsetCriteria) { c.ClosingParen = true })),
+			CriteriaTargetAny, nil,
+			[]string{"closes a parenthesis which was not opened"},
+		},
+		{
+			"unclosed parenthesis",
+			criteria(with(func(c *SharedSubsetCriteria) { c.OpeningParen = true })),
+			CriteriaTargetAny, nil,
+			[]string{"parenthesis opened but not closed"},
+		},
+		{
+			"every problem reported",
+			[]SharedSubsetCriteria{
+				with(func(c *SharedSubsetCriteria) { c.AndOr = "or" }),
+				with(func(c *SharedSubsetCriteria) { c.Priority, c.Name, c.SearchType = 1, "", "resembles" }),
+			},
+			CriteriaTargetComputer, nil,
+			[]string{"must use and", "has no name", "unknown search type"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCriteria(tt.criteria, tt.target, tt.extraNames...)
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("ValidateCriteria() error = %v", err)
+				}
+				return
+			}
+
+			var criteriaErr *CriteriaError
+			if !errors.As(err, &criteriaErr) {
+				t.Fatalf("ValidateCriteria() error = %v, want a *CriteriaError", err)
+			}
+			if len(criteriaErr.Problems) != len(tt.wantErrs) {
+				t.Errorf("ValidateCriteria() problems = %q, want %d", criteriaErr.Problems, len(tt.wantErrs))
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("ValidateCriteria() error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
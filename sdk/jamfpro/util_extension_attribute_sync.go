@@ -0,0 +1,515 @@
+// util_extension_attribute_sync.go
+// This utility manages computer extension attributes from a local directory. Each script file carries its
+// metadata as YAML front matter in a comment block after the shebang, e.g.
+//
+//	#!/bin/bash
+//	# ---
+//	# name: Department Code
+//	# description: Department code from the local config profile
+//	# data_type: String
+//	# inventory_display: User and Location
+//	# ---
+//	echo "<result>$(defaults read /Library/Preferences/com.example.plist Department)</result>"
+//
+// Extension attributes without a script, such as pop-up menus, are described by a .yaml or .yml file holding
+// the same keys plus input_type and choices. SyncComputerExtensionAttributes creates, updates or disables
+// extension attributes to match, and ComputerExtensionAttributeUsageReport finds extension attributes which
+// are not referenced by any smart group or advanced computer search.
+package jamfpro
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Extension attribute sync actions reported in ExtensionAttributeSyncAction.Action
+const (
+	ExtensionAttributeCreate    = "create"
+	ExtensionAttributeUpdate    = "update"
+	ExtensionAttributeUnchanged = "unchanged"
+	ExtensionAttributeDisable   = "disable"
+)
+
+var (
+	extensionAttributeDataTypes         = []string{"String", "Integer", "Date"}
+	extensionAttributeInputTypes        = []string{"script", "Text Field", "Pop-up Menu"}
+	extensionAttributeInventoryDisplays = []string{"General", "Hardware", "Operating System", "User and Location", "Purchasing", "Extension Attributes"}
+)
+
+// ExtensionAttributeDefinition is a computer extension attribute described by a local file.
+type ExtensionAttributeDefinition struct {
+	Path             string   `yaml:"-"`
+	Name             string   `yaml:"name"`
+	Description      string   `yaml:"description"`
+	DataType         string   `yaml:"data_type"`         // String (default), Integer or Date
+	InventoryDisplay string   `yaml:"inventory_display"` // defaults to Extension Attributes
+	InputType        string   `yaml:"input_type"`        // script (default for script files), Text Field or Pop-up Menu
+	Platform         string   `yaml:"platform"`          // defaults to Mac for scripts
+	Enabled          *bool    `yaml:"enabled"`           // defaults to true
+	Choices          []string `yaml:"choices"`
+	Script           string   `yaml:"-"`
+}
+
+// ExtensionAttributeSyncOptions configures SyncComputerExtensionAttributes.
+type ExtensionAttributeSyncOptions struct {
+	// DisableMissing disables enabled extension attributes which have no local definition.
+	DisableMissing bool
+
+	// DisableMissingPrefix limits DisableMissing to extension attributes whose names start with the prefix.
+	DisableMissingPrefix string
+
+	// DryRun reports the actions without making any changes.
+	DryRun bool
+}
+
+// ExtensionAttributeSyncAction describes a change made, or planned in a dry run, to an extension attribute.
+type ExtensionAttributeSyncAction struct {
+	Action string
+	ID     int
+	Name   string
+	Path   string
+	Err    error
+}
+
+// ComputerExtensionAttributeUsage lists where a computer extension attribute is referenced.
+type ComputerExtensionAttributeUsage struct {
+	ID               int
+	Name             string
+	Enabled          bool
+	SmartGroups      []string
+	AdvancedSearches []string
+}
+
+// Unused reports whether no smart group or advanced search references the extension attribute.
+func (u ComputerExtensionAttributeUsage) Unused() bool {
+	return len(u.SmartGroups) == 0 && len(u.AdvancedSearches) == 0
+}
+
+// Loading
+
+// LoadExtensionAttributeDefinitions reads every definition in dir. Files without front matter are skipped,
+// except .yaml and .yml files which are definitions in their entirety.
+func LoadExtensionAttributeDefinitions(dir string) ([]ExtensionAttributeDefinition, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read extension attribute directory: %w", err)
+	}
+
+	var definitions []ExtensionAttributeDefinition
+	names := map[string]string{}
+	var problems []string
+
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		definition, found, err := loadExtensionAttributeDefinition(path)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if !found {
+			continue
+		}
+
+		if other, exists := names[strings.ToLower(definition.Name)]; exists {
+			problems = append(problems, fmt.Sprintf("%s: name %q is already defined by %s", path, definition.Name, other))
+			continue
+		}
+		names[strings.ToLower(definition.Name)] = path
+		definitions = append(definitions, definition)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid extension attribute definitions: %s", strings.Join(problems, "; "))
+	}
+
+	return definitions, nil
+}
+
+// loadExtensionAttributeDefinition reads and validates a single definition file.
+func loadExtensionAttributeDefinition(path string) (ExtensionAttributeDefinition, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ExtensionAttributeDefinition{}, false, fmt.Errorf("%s: %v", path, err)
+	}
+
+	definition := ExtensionAttributeDefinition{Path: path}
+	ext := strings.ToLower(filepath.Ext(path))
+
+	if ext == ".yaml" || ext == ".yml" {
+		if err := yaml.Unmarshal(data, &definition); err != nil {
+			return definition, false, fmt.Errorf("%s: %v", path, err)
+		}
+		if definition.InputType == "" {
+			definition.InputType = "Text Field"
+		}
+	} else {
+		frontMatter, found := extractScriptFrontMatter(string(data))
+		if !found {
+			return definition, false, nil
+		}
+		if err := yaml.Unmarshal([]byte(frontMatter), &definition); err != nil {
+			return definition, false, fmt.Errorf("%s: invalid front matter: %v", path, err)
+		}
+		definition.Script = string(data)
+		if definition.InputType == "" {
+			definition.InputType = "script"
+		}
+		if definition.Platform == "" {
+			definition.Platform = "Mac"
+		}
+	}
+
+	if definition.DataType == "" {
+		definition.DataType = "String"
+	}
+	if definition.InventoryDisplay == "" {
+		definition.InventoryDisplay = "Extension Attributes"
+	}
+	if definition.Enabled == nil {
+		enabled := true
+		definition.Enabled = &enabled
+	}
+
+	if err := definition.validate(); err != nil {
+		return definition, false, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return definition, true, nil
+}
+
+// extractScriptFrontMatter returns the YAML between "---" lines in the leading comment block of a script.
+func extractScriptFrontMatter(script string) (string, bool) {
+	scanner := bufio.NewScanner(strings.NewReader(script))
+	var lines []string
+	inside := false
+
+	for first := true; scanner.Scan(); first = false {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if first && strings.HasPrefix(line, "#!") {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		var content string
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			content = strings.TrimPrefix(trimmed, "#")
+		case strings.HasPrefix(trimmed, "//"):
+			content = strings.TrimPrefix(trimmed, "//")
+		case trimmed == "" && !inside:
+			continue
+		default:
+			return "", false
+		}
+		content = strings.TrimPrefix(content, " ")
+
+		if strings.TrimSpace(content) == "---" {
+			if inside {
+				return strings.Join(lines, "\n"), true
+			}
+			inside = true
+			continue
+		}
+		if inside {
+			lines = append(lines, content)
+		} else if strings.TrimSpace(content) != "" {
+			// Comments before the front matter, e.g. licence headers, are allowed.
+			continue
+		}
+	}
+
+	return "", false
+}
+
+// validate checks the definition's values.
+func (d ExtensionAttributeDefinition) validate() error {
+	var problems []string
+	if d.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	if !containsFold(extensionAttributeDataTypes, d.DataType) {
+		problems = append(problems, fmt.Sprintf("data_type must be one of %s", strings.Join(extensionAttributeDataTypes, ", ")))
+	}
+	if !containsFold(extensionAttributeInputTypes, d.InputType) {
+		problems = append(problems, fmt.Sprintf("input_type must be one of %s", strings.Join(extensionAttributeInputTypes, ", ")))
+	}
+	if !containsFold(extensionAttributeInventoryDisplays, d.InventoryDisplay) {
+		problems = append(problems, fmt.Sprintf("inventory_display must be one of %s", strings.Join(extensionAttributeInventoryDisplays, ", ")))
+	}
+	if strings.EqualFold(d.InputType, "Pop-up Menu") && len(d.Choices) == 0 {
+		problems = append(problems, "choices are required for a Pop-up Menu")
+	}
+	if strings.EqualFold(d.InputType, "script") && strings.TrimSpace(d.Script) == "" {
+		problems = append(problems, "script input requires a script file")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// resource converts the definition into an extension attribute resource.
+func (d ExtensionAttributeDefinition) resource() ResourceComputerExtensionAttribute {
+	attribute := ResourceComputerExtensionAttribute{
+		Name:             d.Name,
+		Enabled:          d.Enabled == nil || *d.Enabled,
+		Description:      d.Description,
+		DataType:         d.DataType,
+		InventoryDisplay: d.InventoryDisplay,
+		InputType: ComputerExtensionAttributeSubsetInputType{
+			Type:    d.InputType,
+			Choices: d.Choices,
+		},
+	}
+	if strings.EqualFold(d.InputType, "script") {
+		attribute.InputType.Platform = d.Platform
+		attribute.InputType.Script = d.Script
+	}
+	return attribute
+}
+
+// containsFold reports whether values contains value, ignoring case.
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sync
+
+// SyncComputerExtensionAttributes brings the computer extension attributes in Jamf Pro in line with the
+// definitions in dir. Every change is attempted; the returned actions carry individual errors. An error is
+// returned only when the definitions are invalid or the existing extension attributes cannot be read.
+func (c *Client) SyncComputerExtensionAttributes(dir string, options ExtensionAttributeSyncOptions) ([]ExtensionAttributeSyncAction, error) {
+	definitions, err := LoadExtensionAttributeDefinitions(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := c.GetComputerExtensionAttributes()
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]ComputerExtenstionAttributeListItem{}
+	for _, item := range list.Results {
+		existing[strings.ToLower(item.Name)] = item
+	}
+
+	var actions []ExtensionAttributeSyncAction
+	defined := map[string]bool{}
+
+	for _, definition := range definitions {
+		defined[strings.ToLower(definition.Name)] = true
+		want := definition.resource()
+		action := ExtensionAttributeSyncAction{Name: definition.Name, Path: definition.Path}
+
+		item, found := existing[strings.ToLower(definition.Name)]
+		if !found {
+			action.Action = ExtensionAttributeCreate
+			if !options.DryRun {
+				created, err := c.writeComputerExtensionAttribute("POST", "0", &want)
+				action.Err = err
+				if created != nil {
+					action.ID = created.ID
+				}
+			}
+			actions = append(actions, action)
+			continue
+		}
+
+		action.ID = item.ID
+		current, err := c.GetComputerExtensionAttributeByID(strconv.Itoa(item.ID))
+		if err != nil {
+			action.Action, action.Err = ExtensionAttributeUpdate, err
+			actions = append(actions, action)
+			continue
+		}
+
+		if extensionAttributeMatches(current, &want) {
+			action.Action = ExtensionAttributeUnchanged
+		} else {
+			action.Action = ExtensionAttributeUpdate
+			if !options.DryRun {
+				want.ID = item.ID
+				_, action.Err = c.writeComputerExtensionAttribute("PUT", strconv.Itoa(item.ID), &want)
+			}
+		}
+		actions = append(actions, action)
+	}
+
+	if !options.DisableMissing {
+		return actions, nil
+	}
+
+	for _, item := range list.Results {
+		if defined[strings.ToLower(item.Name)] || !strings.HasPrefix(item.Name, options.DisableMissingPrefix) {
+			continue
+		}
+
+		action := ExtensionAttributeSyncAction{Action: ExtensionAttributeDisable, ID: item.ID, Name: item.Name}
+		current, err := c.GetComputerExtensionAttributeByID(strconv.Itoa(item.ID))
+		if err != nil {
+			action.Err = err
+			actions = append(actions, action)
+			continue
+		}
+		if !current.Enabled {
+			continue
+		}
+		if !options.DryRun {
+			current.Enabled = false
+			_, action.Err = c.writeComputerExtensionAttribute("PUT", strconv.Itoa(item.ID), current)
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
+
+// writeComputerExtensionAttribute creates or updates an extension attribute, always sending the enabled
+// flag, which the resource omits when false.
+func (c *Client) writeComputerExtensionAttribute(method, id string, attribute *ResourceComputerExtensionAttribute) (*ResourceComputerExtensionAttribute, error) {
+	endpoint := fmt.Sprintf("%s/id/%s", uriComputerExtensionAttributes, id)
+
+	requestBody := struct {
+		XMLName xml.Name `xml:"computer_extension_attribute"`
+		*ResourceComputerExtensionAttribute
+		Enabled bool `xml:"enabled"`
+	}{
+		ResourceComputerExtensionAttribute: attribute,
+		Enabled:                            attribute.Enabled,
+	}
+
+	var response ResourceComputerExtensionAttribute
+	resp, err := c.HTTP.DoRequest(method, endpoint, &requestBody, &response)
+	if err != nil {
+		if method == "POST" {
+			return nil, fmt.Errorf(errMsgFailedCreate, "computer extension attribute", err)
+		}
+		return nil, fmt.Errorf(errMsgFailedUpdateByID, "computer extension attribute", id, err)
+	}
+
+	if resp != nil && resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	return &response, nil
+}
+
+// extensionAttributeMatches compares the fields managed by the sync. Scripts are compared ignoring
+// trailing whitespace and line ending differences.
+func extensionAttributeMatches(current, want *ResourceComputerExtensionAttribute) bool {
+	normalise := func(script string) string {
+		return strings.TrimSpace(strings.ReplaceAll(script, "\r\n", "\n"))
+	}
+
+	return current.Enabled == want.Enabled &&
+		current.Description == want.Description &&
+		strings.EqualFold(current.DataType, want.DataType) &&
+		strings.EqualFold(current.InventoryDisplay, want.InventoryDisplay) &&
+		strings.EqualFold(current.InputType.Type, want.InputType.Type) &&
+		(want.InputType.Platform == "" || current.InputType.Platform == want.InputType.Platform) &&
+		normalise(current.InputType.Script) == normalise(want.InputType.Script) &&
+		strings.Join(current.InputType.Choices, "\n") == strings.Join(want.InputType.Choices, "\n")
+}
+
+// Usage
+
+// ComputerExtensionAttributeUsageReport lists every computer extension attribute with the smart computer
+// groups and advanced computer searches which reference it in their criteria or display fields, ordered by name.
+func (c *Client) ComputerExtensionAttributeUsageReport() ([]ComputerExtensionAttributeUsage, error) {
+	list, err := c.GetComputerExtensionAttributes()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := map[string]*ComputerExtensionAttributeUsage{}
+	for _, item := range list.Results {
+		usage[strings.ToLower(item.Name)] = &ComputerExtensionAttributeUsage{ID: item.ID, Name: item.Name, Enabled: item.Enabled}
+	}
+
+	groups, err := c.GetComputerGroups()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range groups.Results {
+		if !item.IsSmart {
+			continue
+		}
+		group, err := c.GetComputerGroupByID(strconv.Itoa(item.ID))
+		if err != nil {
+			return nil, err
+		}
+		if group.Criteria == nil || group.Criteria.Criterion == nil {
+			continue
+		}
+		for _, name := range criteriaReferences(*group.Criteria.Criterion, nil) {
+			if entry, ok := usage[name]; ok {
+				entry.SmartGroups = append(entry.SmartGroups, group.Name)
+			}
+		}
+	}
+
+	searches, err := c.GetAdvancedComputerSearches()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range searches.AdvancedComputerSearches {
+		search, err := c.GetAdvancedComputerSearchByID(strconv.Itoa(item.ID))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range criteriaReferences(search.Criteria.Criterion, search.DisplayFields) {
+			if entry, ok := usage[name]; ok {
+				entry.AdvancedSearches = append(entry.AdvancedSearches, search.Name)
+			}
+		}
+	}
+
+	report := make([]ComputerExtensionAttributeUsage, 0, len(usage))
+	for _, entry := range usage {
+		report = append(report, *entry)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return strings.ToLower(report[i].Name) < strings.ToLower(report[j].Name)
+	})
+
+	return report, nil
+}
+
+// criteriaReferences returns the distinct lower-cased names referenced by criteria and display fields.
+func criteriaReferences(criteria []SharedSubsetCriteria, displayFields []DisplayField) []string {
+	seen := map[string]bool{}
+	var names []string
+	add := func(name string) {
+		key := strings.ToLower(name)
+		if !seen[key] {
+			seen[key] = true
+			names = append(names, key)
+		}
+	}
+
+	for _, criterion := range criteria {
+		add(criterion.Name)
+	}
+	for _, field := range displayFields {
+		add(field.Name)
+	}
+	return names
+}
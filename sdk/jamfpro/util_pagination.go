@@ -4,6 +4,7 @@ package jamfpro
 
 import (
 	"fmt"
+	"strings"
 )
 
 type StandardPaginatedResponse struct {
@@ -27,6 +28,8 @@ type StandardPaginatedResponse struct {
 //     'sort=<field_name>[:sort_direction][,<secondary_sort_field_name>[:sort_direction]]*'. The default sort
 //     direction is 'asc' (Ascending). Use 'desc' for Descending ordering. Additional sort parameters are
 //     supported and determine the order of results that have equivalent values for previous sort parameters.
+//     Further query parameters may follow, separated by '&', e.g. 'sort=id:desc&filter=name=="Finance"'. A
+//     leading '&' is accepted but not required.
 //
 // The method returns a pointer to a StandardPaginatedResponse containing the aggregated results from all
 // fetched pages, or an error if the fetch operation fails at any point.
@@ -44,18 +47,46 @@ func (c *Client) DoPaginatedGet(
 	sort_filter string,
 ) (*StandardPaginatedResponse, error) {
 
+	var OutStruct StandardPaginatedResponse
+	var OutData []interface{}
+
+	err := c.DoPaginatedGetPages(endpoint_root, maxPageSize, startingPageNumber, sort_filter, func(page *StandardPaginatedResponse) error {
+		OutData = append(OutData, page.Results...)
+		OutStruct.Size = page.Size
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	OutStruct.Results = OutData
+
+	return &OutStruct, nil
+
+}
+
+// DoPaginatedGetPages performs the same paginated GET request as DoPaginatedGet, but hands each page to
+// pageFn as it arrives instead of accumulating the results, so callers can process large collections
+// without holding them in memory. Returning an error from pageFn stops pagination and returns that error.
+func (c *Client) DoPaginatedGetPages(
+	endpoint_root string,
+	maxPageSize, startingPageNumber int,
+	sort_filter string,
+	pageFn func(page *StandardPaginatedResponse) error,
+) error {
+
 	if maxPageSize == 0 {
 		maxPageSize = 200
 	}
+	sort_filter = normalizeQuerySuffix(sort_filter)
 
-	var OutStruct StandardPaginatedResponse
-	var TargetObjectAccumulator StandardPaginatedResponse
-	var OutData []interface{}
+	var fetched int
 	var page = startingPageNumber
+	defer func() { c.HTTP.recordPages(endpoint_root, page-startingPageNumber+1) }()
 
 	for {
-		endpoint := fmt.Sprintf("%s?page=%d&page-size=%d%s", endpoint_root, startingPageNumber, maxPageSize, sort_filter)
-		fmt.Println(endpoint)
+		var TargetObjectAccumulator StandardPaginatedResponse
+		endpoint := fmt.Sprintf("%s?page=%d&page-size=%d%s", endpoint_root, page, maxPageSize, sort_filter)
 		resp, err := c.HTTP.DoRequest(
 			"GET",
 			endpoint,
@@ -64,16 +95,20 @@ func (c *Client) DoPaginatedGet(
 		)
 
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch obj %v", err)
+			return fmt.Errorf("failed to fetch obj %v", err)
 		}
 
-		if resp != nil {
-			defer resp.Body.Close()
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
 		}
 
-		OutData = append(OutData, TargetObjectAccumulator.Results...)
+		fetched += len(TargetObjectAccumulator.Results)
 
-		if len(OutData) >= TargetObjectAccumulator.Size ||
+		if err := pageFn(&TargetObjectAccumulator); err != nil {
+			return err
+		}
+
+		if fetched >= TargetObjectAccumulator.Size ||
 			len(TargetObjectAccumulator.Results) < maxPageSize ||
 			len(TargetObjectAccumulator.Results) == 0 {
 			break
@@ -83,9 +118,16 @@ func (c *Client) DoPaginatedGet(
 
 	}
 
-	OutStruct.Size = TargetObjectAccumulator.Size
-	OutStruct.Results = OutData
+	return nil
 
-	return &OutStruct, nil
+}
 
+// normalizeQuerySuffix returns query parameters ready to append after other parameters, with a single
+// leading '&', or an empty string when there are none.
+func normalizeQuerySuffix(query string) string {
+	query = strings.TrimLeft(strings.TrimSpace(query), "?&")
+	if query == "" {
+		return ""
+	}
+	return "&" + query
 }
@@ -0,0 +1,590 @@
+// util_policy_builder.go
+// This utility builds policies with a fluent API. Categories, sites, scripts, packages, computer groups,
+// buildings and departments are referred to by name and resolved to IDs when the policy is built, and
+// combinations Jamf Pro would reject or silently ignore are reported before anything is uploaded.
+//
+//	policy, err := jamfpro.NewPolicyBuilder("Install Firefox").
+//		Category("Browsers").
+//		TriggerCustom("install-firefox").
+//		Frequency(jamfpro.PolicyFrequencyOngoing).
+//		Package("Firefox-125.0.pkg", jamfpro.PolicyPackageInstall).
+//		Recon().
+//		ScopeComputerGroups("All Managed Clients").
+//		SelfService("Firefox", "Installs the latest Firefox").
+//		Build(client)
+package jamfpro
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Policy frequencies
+const (
+	PolicyFrequencyOncePerComputer        = "Once per computer"
+	PolicyFrequencyOncePerUserPerComputer = "Once per user per computer"
+	PolicyFrequencyOncePerUser            = "Once per user"
+	PolicyFrequencyOnceEveryDay           = "Once every day"
+	PolicyFrequencyOnceEveryWeek          = "Once every week"
+	PolicyFrequencyOnceEveryMonth         = "Once every month"
+	PolicyFrequencyOngoing                = "Ongoing"
+)
+
+// Policy retry events
+const (
+	PolicyRetryNone    = "none"
+	PolicyRetryTrigger = "trigger"
+	PolicyRetryCheckin = "check-in"
+)
+
+// Policy package actions
+const (
+	PolicyPackageInstall       = "Install"
+	PolicyPackageCache         = "Cache"
+	PolicyPackageInstallCached = "Install Cached"
+	PolicyPackageUninstall     = "Uninstall"
+)
+
+// Policy script priorities
+const (
+	PolicyScriptBefore = "Before"
+	PolicyScriptAfter  = "After"
+)
+
+var policyFrequencies = []string{
+	PolicyFrequencyOncePerComputer,
+	PolicyFrequencyOncePerUserPerComputer,
+	PolicyFrequencyOncePerUser,
+	PolicyFrequencyOnceEveryDay,
+	PolicyFrequencyOnceEveryWeek,
+	PolicyFrequencyOnceEveryMonth,
+	PolicyFrequencyOngoing,
+}
+
+var policyPackageActions = []string{PolicyPackageInstall, PolicyPackageCache, PolicyPackageInstallCached, PolicyPackageUninstall}
+
+// PolicyBuilderError lists every problem found while validating or building a policy.
+type PolicyBuilderError struct {
+	Problems []string
+}
+
+func (e *PolicyBuilderError) Error() string {
+	return fmt.Sprintf("invalid policy: %s", strings.Join(e.Problems, "; "))
+}
+
+// PolicyBuilder builds a ResourcePolicy. Methods record settings and never fail; problems are reported by
+// Validate and Build.
+type PolicyBuilder struct {
+	policy ResourcePolicy
+
+	category              string
+	site                  string
+	selfServiceCategories []policyBuilderSelfServiceCategory
+	scripts               []policyBuilderScript
+	packages              []policyBuilderPackage
+	scopeGroups           []string
+	excludeGroups         []string
+	scopeBuildings        []string
+	scopeDepartments      []string
+}
+
+type policyBuilderSelfServiceCategory struct {
+	name     string
+	featured bool
+}
+
+type policyBuilderScript struct {
+	name       string
+	priority   string
+	parameters []string
+}
+
+type policyBuilderPackage struct {
+	name   string
+	action string
+}
+
+// NewPolicyBuilder starts an enabled policy which runs once per computer.
+func NewPolicyBuilder(name string) *PolicyBuilder {
+	return &PolicyBuilder{
+		policy: ResourcePolicy{
+			General: PolicySubsetGeneral{
+				Name:          name,
+				Enabled:       true,
+				Frequency:     PolicyFrequencyOncePerComputer,
+				RetryEvent:    PolicyRetryNone,
+				RetryAttempts: -1,
+			},
+		},
+	}
+}
+
+// General
+
+// Enabled sets whether the policy is enabled.
+func (b *PolicyBuilder) Enabled(enabled bool) *PolicyBuilder {
+	b.policy.General.Enabled = enabled
+	return b
+}
+
+// Category assigns the policy to a category by name, matched without regard to case.
+func (b *PolicyBuilder) Category(name string) *PolicyBuilder {
+	b.category = name
+	return b
+}
+
+// Site assigns the policy to a site by name.
+func (b *PolicyBuilder) Site(name string) *PolicyBuilder {
+	b.site = name
+	return b
+}
+
+// Frequency sets the execution frequency, one of the PolicyFrequency constants.
+func (b *PolicyBuilder) Frequency(frequency string) *PolicyBuilder {
+	b.policy.General.Frequency = frequency
+	return b
+}
+
+// Retry retries failed runs on the next trigger or check-in, up to attempts times.
+func (b *PolicyBuilder) Retry(event string, attempts int) *PolicyBuilder {
+	b.policy.General.RetryEvent = event
+	b.policy.General.RetryAttempts = attempts
+	return b
+}
+
+// Offline makes the policy available when the computer cannot reach Jamf Pro.
+func (b *PolicyBuilder) Offline() *PolicyBuilder {
+	b.policy.General.Offline = true
+	return b
+}
+
+// Triggers
+
+// TriggerCheckin runs the policy at recurring check-in.
+func (b *PolicyBuilder) TriggerCheckin() *PolicyBuilder {
+	b.policy.General.TriggerCheckin = true
+	return b
+}
+
+// TriggerEnrollmentComplete runs the policy when enrollment completes.
+func (b *PolicyBuilder) TriggerEnrollmentComplete() *PolicyBuilder {
+	b.policy.General.TriggerEnrollmentComplete = true
+	return b
+}
+
+// TriggerLogin runs the policy at login.
+func (b *PolicyBuilder) TriggerLogin() *PolicyBuilder {
+	b.policy.General.TriggerLogin = true
+	return b
+}
+
+// TriggerLogout runs the policy at logout.
+func (b *PolicyBuilder) TriggerLogout() *PolicyBuilder {
+	b.policy.General.TriggerLogout = true
+	return b
+}
+
+// TriggerNetworkStateChanged runs the policy when the network state changes.
+func (b *PolicyBuilder) TriggerNetworkStateChanged() *PolicyBuilder {
+	b.policy.General.TriggerNetworkStateChanged = true
+	return b
+}
+
+// TriggerStartup runs the policy at startup.
+func (b *PolicyBuilder) TriggerStartup() *PolicyBuilder {
+	b.policy.General.TriggerStartup = true
+	return b
+}
+
+// TriggerCustom runs the policy with `jamf policy -event <event>`.
+func (b *PolicyBuilder) TriggerCustom(event string) *PolicyBuilder {
+	b.policy.General.TriggerOther = event
+	return b
+}
+
+// Payloads
+
+// Script runs a script by name with parameters 4 onwards.
+func (b *PolicyBuilder) Script(name, priority string, parameters ...string) *PolicyBuilder {
+	b.scripts = append(b.scripts, policyBuilderScript{name: name, priority: priority, parameters: parameters})
+	return b
+}
+
+// Package installs, caches or uninstalls a package by name.
+func (b *PolicyBuilder) Package(name, action string) *PolicyBuilder {
+	b.packages = append(b.packages, policyBuilderPackage{name: name, action: action})
+	return b
+}
+
+// Recon updates inventory after the policy runs.
+func (b *PolicyBuilder) Recon() *PolicyBuilder {
+	b.maintenance().Recon = true
+	return b
+}
+
+// Maintenance applies the maintenance payload settings.
+func (b *PolicyBuilder) Maintenance(configure func(*PolicySubsetMaintenance)) *PolicyBuilder {
+	configure(b.maintenance())
+	return b
+}
+
+// RunCommand runs a command as root after the policy's other payloads.
+func (b *PolicyBuilder) RunCommand(command string) *PolicyBuilder {
+	if b.policy.FilesProcesses == nil {
+		b.policy.FilesProcesses = &PolicySubsetFilesProcesses{}
+	}
+	b.policy.FilesProcesses.RunCommand = command
+	return b
+}
+
+// Reboot sets the restart options.
+func (b *PolicyBuilder) Reboot(reboot PolicySubsetReboot) *PolicyBuilder {
+	b.policy.Reboot = &reboot
+	return b
+}
+
+// UserMessages sets the messages shown before and after the policy runs.
+func (b *PolicyBuilder) UserMessages(start, finish string) *PolicyBuilder {
+	if b.policy.UserInteraction == nil {
+		b.policy.UserInteraction = &PolicySubsetUserInteraction{}
+	}
+	b.policy.UserInteraction.MessageStart = start
+	b.policy.UserInteraction.MessageFinish = finish
+	return b
+}
+
+func (b *PolicyBuilder) maintenance() *PolicySubsetMaintenance {
+	if b.policy.Maintenance == nil {
+		b.policy.Maintenance = &PolicySubsetMaintenance{}
+	}
+	return b.policy.Maintenance
+}
+
+// Self Service
+
+// SelfService makes the policy available in Self Service.
+func (b *PolicyBuilder) SelfService(displayName, description string) *PolicyBuilder {
+	if b.policy.SelfService == nil {
+		b.policy.SelfService = &PolicySubsetSelfService{InstallButtonText: "Install", ReinstallButtonText: "Reinstall"}
+	}
+	b.policy.SelfService.UseForSelfService = true
+	b.policy.SelfService.SelfServiceDisplayName = displayName
+	b.policy.SelfService.SelfServiceDescription = description
+	return b
+}
+
+// SelfServiceCategory lists the policy in a Self Service category by name.
+func (b *PolicyBuilder) SelfServiceCategory(name string, featured bool) *PolicyBuilder {
+	b.selfServiceCategories = append(b.selfServiceCategories, policyBuilderSelfServiceCategory{name: name, featured: featured})
+	return b
+}
+
+// Scope
+
+// ScopeAllComputers scopes the policy to every computer.
+func (b *PolicyBuilder) ScopeAllComputers() *PolicyBuilder {
+	b.scope().AllComputers = true
+	return b
+}
+
+// ScopeComputerGroups adds computer groups to the scope by name.
+func (b *PolicyBuilder) ScopeComputerGroups(names ...string) *PolicyBuilder {
+	b.scopeGroups = append(b.scopeGroups, names...)
+	return b
+}
+
+// ExcludeComputerGroups excludes computer groups from the scope by name.
+func (b *PolicyBuilder) ExcludeComputerGroups(names ...string) *PolicyBuilder {
+	b.excludeGroups = append(b.excludeGroups, names...)
+	return b
+}
+
+// ScopeBuildings adds buildings to the scope by name.
+func (b *PolicyBuilder) ScopeBuildings(names ...string) *PolicyBuilder {
+	b.scopeBuildings = append(b.scopeBuildings, names...)
+	return b
+}
+
+// ScopeDepartments adds departments to the scope by name.
+func (b *PolicyBuilder) ScopeDepartments(names ...string) *PolicyBuilder {
+	b.scopeDepartments = append(b.scopeDepartments, names...)
+	return b
+}
+
+func (b *PolicyBuilder) scope() *PolicySubsetScope {
+	if b.policy.Scope == nil {
+		b.policy.Scope = &PolicySubsetScope{}
+	}
+	return b.policy.Scope
+}
+
+// Validation
+
+// Validate checks the policy for invalid combinations without contacting Jamf Pro.
+func (b *PolicyBuilder) Validate() error {
+	var problems []string
+	general := &b.policy.General
+
+	if strings.TrimSpace(general.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+	if !containsFold(policyFrequencies, general.Frequency) {
+		problems = append(problems, fmt.Sprintf("frequency %q must be one of %s", general.Frequency, strings.Join(policyFrequencies, ", ")))
+	}
+
+	hasTrigger := general.TriggerCheckin || general.TriggerEnrollmentComplete || general.TriggerLogin ||
+		general.TriggerLogout || general.TriggerNetworkStateChanged || general.TriggerStartup || general.TriggerOther != ""
+	selfService := b.policy.SelfService != nil && b.policy.SelfService.UseForSelfService
+
+	if selfService && !hasTrigger {
+		problems = append(problems, "self service policies need a trigger, use TriggerCustom to add an event")
+	}
+	if !selfService && len(b.selfServiceCategories) > 0 {
+		problems = append(problems, "self service categories are set but the policy is not available in Self Service")
+	}
+
+	if general.Offline {
+		if general.TriggerCheckin {
+			problems = append(problems, "offline policies cannot use the recurring check-in trigger")
+		}
+		if general.Frequency != PolicyFrequencyOngoing {
+			problems = append(problems, "offline policies must use the Ongoing frequency")
+		}
+	}
+
+	switch general.RetryEvent {
+	case "", PolicyRetryNone:
+	case PolicyRetryTrigger, PolicyRetryCheckin:
+		if general.Frequency != PolicyFrequencyOncePerComputer {
+			problems = append(problems, "retries are only available for policies which run once per computer")
+		}
+		if general.RetryAttempts < 1 || general.RetryAttempts > 10 {
+			problems = append(problems, fmt.Sprintf("retry attempts must be between 1 and 10, got %d", general.RetryAttempts))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("retry event %q must be one of none, trigger or check-in", general.RetryEvent))
+	}
+
+	for _, script := range b.scripts {
+		if script.priority != PolicyScriptBefore && script.priority != PolicyScriptAfter {
+			problems = append(problems, fmt.Sprintf("script %q priority must be Before or After, got %q", script.name, script.priority))
+		}
+		if len(script.parameters) > 8 {
+			problems = append(problems, fmt.Sprintf("script %q has %d parameters, only parameters 4 to 11 are available", script.name, len(script.parameters)))
+		}
+	}
+	for _, pkg := range b.packages {
+		if !containsFold(policyPackageActions, pkg.action) {
+			problems = append(problems, fmt.Sprintf("package %q action must be one of %s, got %q", pkg.name, strings.Join(policyPackageActions, ", "), pkg.action))
+		}
+	}
+
+	if b.policy.Reboot != nil && b.policy.Reboot.MinutesUntilReboot < 0 {
+		problems = append(problems, "minutes until reboot cannot be negative")
+	}
+
+	if len(problems) > 0 {
+		return &PolicyBuilderError{Problems: problems}
+	}
+	return nil
+}
+
+// Building
+
+// Build validates the policy and resolves every name to its ID. All resolution problems are reported together.
+func (b *PolicyBuilder) Build(c *Client) (*ResourcePolicy, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	policy := b.policy
+	var problems []string
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// Categories are keyed by lower-cased name, as Jamf Pro matches category names without regard to case.
+	categoryIDs := map[string]SharedResourceCategory{}
+	if b.category != "" || len(b.selfServiceCategories) > 0 {
+		categories, err := c.GetCategories("")
+		if err != nil {
+			return nil, err
+		}
+		for _, category := range categories.Results {
+			id, _ := strconv.Atoi(category.Id)
+			categoryIDs[strings.ToLower(category.Name)] = SharedResourceCategory{ID: id, Name: category.Name}
+		}
+	}
+
+	if b.category != "" {
+		if category, ok := categoryIDs[strings.ToLower(b.category)]; ok {
+			policy.General.Category = &category
+		} else {
+			fail("category %q does not exist", b.category)
+		}
+	}
+
+	if b.site != "" {
+		site, err := c.GetSiteByName(b.site)
+		if err != nil {
+			fail("site %q: %v", b.site, err)
+		} else {
+			policy.General.Site = &SharedResourceSite{ID: site.ID, Name: site.Name}
+		}
+	}
+
+	if policy.SelfService != nil {
+		selfService := *policy.SelfService
+		var categories []PolicySubsetSelfServiceCategory
+		for _, category := range b.selfServiceCategories {
+			existing, ok := categoryIDs[strings.ToLower(category.name)]
+			if !ok {
+				fail("self service category %q does not exist", category.name)
+				continue
+			}
+			categories = append(categories, PolicySubsetSelfServiceCategory{ID: existing.ID, Name: existing.Name, DisplayIn: true, FeatureIn: category.featured})
+		}
+		if len(categories) > 0 {
+			selfService.SelfServiceCategories = &categories
+		}
+		policy.SelfService = &selfService
+	}
+
+	if len(b.scripts) > 0 {
+		var scripts []PolicySubsetScript
+		for _, script := range b.scripts {
+			resource, err := c.GetScriptByName(script.name)
+			if err != nil {
+				fail("script %q: %v", script.name, err)
+				continue
+			}
+			parameters := make([]string, 8)
+			copy(parameters, script.parameters)
+			scripts = append(scripts, PolicySubsetScript{
+				ID:          resource.ID,
+				Name:        resource.Name,
+				Priority:    script.priority,
+				Parameter4:  parameters[0],
+				Parameter5:  parameters[1],
+				Parameter6:  parameters[2],
+				Parameter7:  parameters[3],
+				Parameter8:  parameters[4],
+				Parameter9:  parameters[5],
+				Parameter10: parameters[6],
+				Parameter11: parameters[7],
+			})
+		}
+		policy.Scripts = &scripts
+	}
+
+	if len(b.packages) > 0 {
+		configuration := PolicySubsetPackageConfiguration{DistributionPoint: "default"}
+		for _, pkg := range b.packages {
+			id, err := c.resolvePackageID(pkg.name)
+			if err != nil {
+				fail("package %q: %v", pkg.name, err)
+				continue
+			}
+			configuration.Packages = append(configuration.Packages, PolicySubsetPackageConfigurationPackage{ID: id, Name: pkg.name, Action: pkg.action})
+		}
+		policy.PackageConfiguration = &configuration
+	}
+
+	if b.policy.Scope != nil || len(b.scopeGroups)+len(b.excludeGroups)+len(b.scopeBuildings)+len(b.scopeDepartments) > 0 {
+		scope := PolicySubsetScope{}
+		if b.policy.Scope != nil {
+			scope = *b.policy.Scope
+		}
+
+		if groups := c.resolvePolicyComputerGroups(b.scopeGroups, fail); len(groups) > 0 {
+			scope.ComputerGroups = &groups
+		}
+		if groups := c.resolvePolicyComputerGroups(b.excludeGroups, fail); len(groups) > 0 {
+			scope.Exclusions = &PolicySubsetScopeExclusions{ComputerGroups: &groups}
+		}
+
+		var buildings []PolicySubsetBuilding
+		for _, name := range b.scopeBuildings {
+			building, err := c.GetBuildingByName(name)
+			if err != nil {
+				fail("building %q: %v", name, err)
+				continue
+			}
+			id, _ := strconv.Atoi(building.ID)
+			buildings = append(buildings, PolicySubsetBuilding{ID: id, Name: building.Name})
+		}
+		if len(buildings) > 0 {
+			scope.Buildings = &buildings
+		}
+
+		var departments []PolicySubsetDepartment
+		for _, name := range b.scopeDepartments {
+			department, err := c.GetDepartmentByName(name)
+			if err != nil {
+				fail("department %q: %v", name, err)
+				continue
+			}
+			id, _ := strconv.Atoi(department.ID)
+			departments = append(departments, PolicySubsetDepartment{ID: id, Name: department.Name})
+		}
+		if len(departments) > 0 {
+			scope.Departments = &departments
+		}
+
+		policy.Scope = &scope
+	}
+
+	if len(problems) > 0 {
+		return nil, &PolicyBuilderError{Problems: problems}
+	}
+
+	return &policy, nil
+}
+
+// Create builds the policy and creates it in Jamf Pro.
+func (b *PolicyBuilder) Create(c *Client) (*ResponsePolicyCreateAndUpdate, error) {
+	policy, err := b.Build(c)
+	if err != nil {
+		return nil, err
+	}
+	return c.CreatePolicy(policy)
+}
+
+// Update builds the policy and replaces the policy with the given ID.
+func (b *PolicyBuilder) Update(c *Client, id string) (*ResponsePolicyCreateAndUpdate, error) {
+	policy, err := b.Build(c)
+	if err != nil {
+		return nil, err
+	}
+	return c.UpdatePolicyByID(id, policy)
+}
+
+// resolvePolicyComputerGroups looks up computer groups by name, reporting missing groups through fail.
+func (c *Client) resolvePolicyComputerGroups(names []string, fail func(string, ...interface{})) []PolicySubsetComputerGroup {
+	var groups []PolicySubsetComputerGroup
+	for _, name := range names {
+		group, err := c.GetComputerGroupByName(name)
+		if err != nil {
+			fail("computer group %q: %v", name, err)
+			continue
+		}
+		groups = append(groups, PolicySubsetComputerGroup{ID: group.ID, Name: group.Name})
+	}
+	return groups
+}
+
+// resolvePackageID returns the ID of the package with the given display name, ignoring case as the
+// RSQL filter does.
+func (c *Client) resolvePackageID(name string) (int, error) {
+	packages, err := c.GetPackages("", "packageName=="+RSQLQuote(name))
+	if err != nil {
+		return 0, err
+	}
+	for _, pkg := range packages.Results {
+		if strings.EqualFold(pkg.PackageName, name) {
+			return strconv.Atoi(pkg.ID)
+		}
+	}
+	return 0, fmt.Errorf("package does not exist")
+}
@@ -0,0 +1,397 @@
+// util_policy_log_analytics.go
+// This utility builds a fleet view of policy execution from the policy logs in computer history. Logs are
+// gathered concurrently for a set of computers and summarised per policy: how many computers last ran it
+// successfully, failed or are pending, when it last ran, which computers in its scope have never run it, and
+// which failure messages occur most often.
+//
+// Computer history reports the policy logs of a computer, and computer management reports the policies a
+// computer is in scope for. Scope is only gathered when requested, as it doubles the number of requests.
+// Computer history reports the status of each run but not the script or command output, so failures are
+// grouped by the status text Jamf Pro records for the run.
+package jamfpro
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const uriComputerManagement = "/JSSResource/computermanagement"
+
+const defaultPolicyLogConcurrency = 8
+
+// PolicyRunState is the outcome of a policy run.
+type PolicyRunState string
+
+const (
+	PolicyRunSucceeded PolicyRunState = "succeeded"
+	PolicyRunFailed    PolicyRunState = "failed"
+	PolicyRunPending   PolicyRunState = "pending"
+)
+
+// PolicyLogAnalyticsOptions configures AnalyzePolicyLogs.
+type PolicyLogAnalyticsOptions struct {
+	// Concurrency limits concurrent requests. Defaults to 8.
+	Concurrency int
+
+	// Since ignores runs before this time when set.
+	Since time.Time
+
+	// IncludeScope reads the policies each computer is in scope for, to report computers which never ran them.
+	IncludeScope bool
+
+	// PolicyIDs limits the report to these policies when set.
+	PolicyIDs []int
+}
+
+// PolicyLogEntry is a single policy run on a computer.
+type PolicyLogEntry struct {
+	ComputerID   int
+	ComputerName string
+	PolicyID     int
+	PolicyName   string
+	Username     string
+	Time         time.Time
+	Status       string
+	State        PolicyRunState
+}
+
+// PolicyFailureGroup collects failed runs reporting the same status text.
+type PolicyFailureGroup struct {
+	Message   string
+	Count     int
+	Computers []int
+	LastSeen  time.Time
+}
+
+// PolicyRunStats summarises the runs of a policy. The state counts are of computers, by their latest run.
+type PolicyRunStats struct {
+	PolicyID    int
+	PolicyName  string
+	Runs        int
+	Succeeded   int
+	Failed      int
+	Pending     int
+	LastRun     time.Time
+	LastSuccess time.Time
+	LastFailure time.Time
+	InScope     int                  // computers in scope, when scope was gathered
+	NeverRan    []int                // IDs of computers in scope without a run
+	FailedOn    []int                // IDs of computers whose latest run failed
+	Failures    []PolicyFailureGroup // failed runs grouped by message, most frequent first
+}
+
+// PolicyLogReport is the result of AnalyzePolicyLogs.
+type PolicyLogReport struct {
+	Computers int
+	Policies  []PolicyRunStats // ordered by policy name
+	Entries   []PolicyLogEntry
+	Errors    map[int]error // computers whose logs could not be read
+}
+
+// Policy returns the stats of a policy, or nil when it has no runs and no computers in scope.
+func (r *PolicyLogReport) Policy(id int) *PolicyRunStats {
+	for i := range r.Policies {
+		if r.Policies[i].PolicyID == id {
+			return &r.Policies[i]
+		}
+	}
+	return nil
+}
+
+// computerManagementPolicies lists the policies a computer is in scope for.
+type computerManagementPolicies struct {
+	Policies []struct {
+		ID   int    `xml:"id"`
+		Name string `xml:"name"`
+	} `xml:"policies>policy"`
+}
+
+// AnalyzePolicyLogs gathers the policy logs of the given computers and summarises them per policy. Computers
+// whose logs cannot be read are reported in Errors and left out of the summary. An error is returned only when
+// ctx is cancelled.
+func (c *Client) AnalyzePolicyLogs(ctx context.Context, computerIDs []int, options PolicyLogAnalyticsOptions) (*PolicyLogReport, error) {
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultPolicyLogConcurrency
+	}
+
+	wanted := map[int]bool{}
+	for _, id := range options.PolicyIDs {
+		wanted[id] = true
+	}
+	include := func(policyID int) bool {
+		return len(wanted) == 0 || wanted[policyID]
+	}
+
+	report := &PolicyLogReport{Computers: len(computerIDs), Errors: map[int]error{}}
+	scope := map[int]map[int]bool{} // policy ID to the computers in scope
+	scopeNames := map[int]string{}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	semaphore := make(chan struct{}, concurrency)
+
+	for _, computerID := range computerIDs {
+		wg.Add(1)
+		go func(computerID int) {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+				defer func() { <-semaphore }()
+			case <-ctx.Done():
+				return
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			entries, err := c.computerPolicyLogs(computerID)
+			var inScope map[int]string
+			if err == nil && options.IncludeScope {
+				inScope, err = c.computerPoliciesInScope(computerID)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				report.Errors[computerID] = err
+				return
+			}
+			for _, entry := range entries {
+				if include(entry.PolicyID) && !entry.Time.Before(options.Since) {
+					report.Entries = append(report.Entries, entry)
+				}
+			}
+			for policyID, name := range inScope {
+				if !include(policyID) {
+					continue
+				}
+				if scope[policyID] == nil {
+					scope[policyID] = map[int]bool{}
+				}
+				scope[policyID][computerID] = true
+				scopeNames[policyID] = name
+			}
+		}(computerID)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report.Policies = summarizePolicyLogs(report.Entries, scope, scopeNames)
+	return report, nil
+}
+
+// computerPolicyLogs reads the policy logs of a computer.
+func (c *Client) computerPolicyLogs(computerID int) ([]PolicyLogEntry, error) {
+	endpoint := fmt.Sprintf("%s/id/%d/subset/General&PolicyLogs", uriComputerHistory, computerID)
+
+	var history ResourceComputerHistory
+	resp, err := c.HTTP.DoRequest("GET", endpoint, nil, &history)
+	if err != nil {
+		return nil, fmt.Errorf(errMsgFailedGetByID, "computer history", strconv.Itoa(computerID), err)
+	}
+
+	if resp != nil && resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	entries := make([]PolicyLogEntry, 0, len(history.PolicyLogs))
+	for _, log := range history.PolicyLogs {
+		entries = append(entries, PolicyLogEntry{
+			ComputerID:   computerID,
+			ComputerName: history.General.Name,
+			PolicyID:     log.PolicyID,
+			PolicyName:   log.PolicyName,
+			Username:     log.Username,
+			Time:         policyLogTime(log),
+			Status:       strings.TrimSpace(log.Status),
+			State:        policyRunState(log.Status),
+		})
+	}
+
+	return entries, nil
+}
+
+// computerPoliciesInScope reads the IDs and names of the policies a computer is in scope for.
+func (c *Client) computerPoliciesInScope(computerID int) (map[int]string, error) {
+	endpoint := fmt.Sprintf("%s/id/%d/subset/policies", uriComputerManagement, computerID)
+
+	var management computerManagementPolicies
+	resp, err := c.HTTP.DoRequest("GET", endpoint, nil, &management)
+	if err != nil {
+		return nil, fmt.Errorf(errMsgFailedGetByID, "computer management", strconv.Itoa(computerID), err)
+	}
+
+	if resp != nil && resp.Body != nil {
+		defer resp.Body.Close()
+	}
+
+	policies := make(map[int]string, len(management.Policies))
+	for _, policy := range management.Policies {
+		policies[policy.ID] = policy.Name
+	}
+	return policies, nil
+}
+
+// policyLogTime returns the time of a run, preferring the completion date to the date_time fields and the
+// epoch in milliseconds to the formatted date.
+func policyLogTime(log ComputerHistorySubsetPolicyDetails) time.Time {
+	for _, epoch := range []int64{log.DateCompletedEpoch, log.DateTimeEpoch} {
+		if epoch > 0 {
+			return time.UnixMilli(epoch).UTC()
+		}
+	}
+	for _, value := range []string{log.DateCompletedUTC, log.DateTimeUTC} {
+		for _, layout := range []string{"2006-01-02T15:04:05.000-0700", time.RFC3339, "2006-01-02 15:04:05"} {
+			if parsed, err := time.Parse(layout, value); err == nil {
+				return parsed.UTC()
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// policyRunState classifies a log status. Jamf Pro reports "Completed" and "Failed"; anything else, such as
+// a run awaiting retry, is treated as pending.
+func policyRunState(status string) PolicyRunState {
+	status = strings.ToLower(status)
+	switch {
+	case strings.Contains(status, "complete"):
+		return PolicyRunSucceeded
+	case strings.Contains(status, "fail"), strings.Contains(status, "error"):
+		return PolicyRunFailed
+	default:
+		return PolicyRunPending
+	}
+}
+
+// summarizePolicyLogs builds the per-policy stats from runs and scope.
+func summarizePolicyLogs(entries []PolicyLogEntry, scope map[int]map[int]bool, scopeNames map[int]string) []PolicyRunStats {
+	stats := map[int]*PolicyRunStats{}
+	get := func(policyID int, name string) *PolicyRunStats {
+		if stats[policyID] == nil {
+			stats[policyID] = &PolicyRunStats{PolicyID: policyID, PolicyName: name}
+		}
+		return stats[policyID]
+	}
+
+	latest := map[int]map[int]PolicyLogEntry{} // policy ID to the latest run per computer
+	failures := map[int]map[string]*PolicyFailureGroup{}
+
+	for _, entry := range entries {
+		policy := get(entry.PolicyID, entry.PolicyName)
+		policy.Runs++
+		if entry.Time.After(policy.LastRun) {
+			policy.LastRun = entry.Time
+		}
+
+		switch entry.State {
+		case PolicyRunSucceeded:
+			if entry.Time.After(policy.LastSuccess) {
+				policy.LastSuccess = entry.Time
+			}
+		case PolicyRunFailed:
+			if entry.Time.After(policy.LastFailure) {
+				policy.LastFailure = entry.Time
+			}
+			if failures[entry.PolicyID] == nil {
+				failures[entry.PolicyID] = map[string]*PolicyFailureGroup{}
+			}
+			message := strings.Join(strings.Fields(entry.Status), " ")
+			group := failures[entry.PolicyID][message]
+			if group == nil {
+				group = &PolicyFailureGroup{Message: message}
+				failures[entry.PolicyID][message] = group
+			}
+			group.Count++
+			if !containsInt(group.Computers, entry.ComputerID) {
+				group.Computers = append(group.Computers, entry.ComputerID)
+			}
+			if entry.Time.After(group.LastSeen) {
+				group.LastSeen = entry.Time
+			}
+		}
+
+		if latest[entry.PolicyID] == nil {
+			latest[entry.PolicyID] = map[int]PolicyLogEntry{}
+		}
+		if current, ok := latest[entry.PolicyID][entry.ComputerID]; !ok || !entry.Time.Before(current.Time) {
+			latest[entry.PolicyID][entry.ComputerID] = entry
+		}
+	}
+
+	for policyID, computers := range latest {
+		policy := stats[policyID]
+		for computerID, entry := range computers {
+			switch entry.State {
+			case PolicyRunSucceeded:
+				policy.Succeeded++
+			case PolicyRunFailed:
+				policy.Failed++
+				policy.FailedOn = append(policy.FailedOn, computerID)
+			default:
+				policy.Pending++
+			}
+		}
+		sort.Ints(policy.FailedOn)
+	}
+
+	for policyID, computers := range scope {
+		policy := get(policyID, scopeNames[policyID])
+		policy.InScope = len(computers)
+		for computerID := range computers {
+			if _, ran := latest[policyID][computerID]; !ran {
+				policy.NeverRan = append(policy.NeverRan, computerID)
+			}
+		}
+		sort.Ints(policy.NeverRan)
+	}
+
+	for policyID, groups := range failures {
+		policy := stats[policyID]
+		for _, group := range groups {
+			sort.Ints(group.Computers)
+			policy.Failures = append(policy.Failures, *group)
+		}
+		sort.Slice(policy.Failures, func(i, j int) bool {
+			if policy.Failures[i].Count != policy.Failures[j].Count {
+				return policy.Failures[i].Count > policy.Failures[j].Count
+			}
+			return policy.Failures[i].Message < policy.Failures[j].Message
+		})
+	}
+
+	result := make([]PolicyRunStats, 0, len(stats))
+	for _, policy := range stats {
+		result = append(result, *policy)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].PolicyName != result[j].PolicyName {
+			return result[i].PolicyName < result[j].PolicyName
+		}
+		return result[i].PolicyID < result[j].PolicyID
+	})
+
+	return result
+}
+
+// containsInt reports whether values contains value.
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
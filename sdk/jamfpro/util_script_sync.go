@@ -0,0 +1,438 @@
+// util_script_sync.go
+// This utility maps a directory of .sh, .zsh, .bash and .py files onto Jamf Pro scripts. Metadata is read from
+// YAML front matter in the leading comment block, in the same format as extension attribute definitions:
+//
+//	#!/bin/zsh
+//	# ---
+//	# name: Rename Computer
+//	# category: Maintenance
+//	# priority: After
+//	# os_requirements: "13.x, 14.x"
+//	# parameters:
+//	#   4: New computer name
+//	#   5: Reboot (yes/no)
+//	# ---
+//
+// Scripts without front matter are synced with their file name as the script name. Content drift is detected
+// by comparing SHA-256 hashes of the normalised local and remote contents, and every script is run through the
+// configured validators before anything is pushed.
+package jamfpro
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Script sync actions reported in ScriptSyncAction.Action
+const (
+	ScriptSyncCreate    = "create"
+	ScriptSyncUpdate    = "update"
+	ScriptSyncUnchanged = "unchanged"
+	ScriptSyncInvalid   = "invalid"
+)
+
+// scriptSyncExtensions are the file extensions synced as scripts.
+var scriptSyncExtensions = []string{".sh", ".zsh", ".bash", ".py"}
+
+// scriptPriorities maps the accepted priority spellings to the Jamf Pro API values.
+var scriptPriorities = map[string]string{
+	"before":    "BEFORE",
+	"after":     "AFTER",
+	"at reboot": "AT_REBOOT",
+	"at_reboot": "AT_REBOOT",
+}
+
+// ScriptDefinition is a script read from a local file.
+type ScriptDefinition struct {
+	Path           string         `yaml:"-"`
+	Name           string         `yaml:"name"`
+	Category       string         `yaml:"category"`
+	Priority       string         `yaml:"priority"`
+	Info           string         `yaml:"info"`
+	Notes          string         `yaml:"notes"`
+	OSRequirements string         `yaml:"os_requirements"`
+	Parameters     map[int]string `yaml:"parameters"` // labels of parameters 4 to 11
+	Contents       string         `yaml:"-"`
+}
+
+// Hash returns the SHA-256 hash of the script's normalised contents.
+func (d *ScriptDefinition) Hash() string {
+	return scriptContentHash(d.Contents)
+}
+
+// ScriptValidator checks a script before it is uploaded.
+type ScriptValidator interface {
+	Name() string
+	Validate(script *ScriptDefinition) error
+}
+
+// ScriptSyncOptions configures SyncScripts.
+type ScriptSyncOptions struct {
+	// Validators run against every script. A script failing any validator is not uploaded.
+	Validators []ScriptValidator
+
+	// DryRun reports the actions without making any changes.
+	DryRun bool
+}
+
+// ScriptSyncAction describes a change made, or planned in a dry run, to a single script.
+type ScriptSyncAction struct {
+	Action     string
+	ID         string
+	Name       string
+	Path       string
+	LocalHash  string
+	RemoteHash string
+	Changes    []string // fields which differ from Jamf Pro
+	Err        error
+}
+
+// Loading
+
+// LoadScriptDefinitions reads every script file in dir.
+func LoadScriptDefinitions(dir string) ([]ScriptDefinition, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read script directory: %w", err)
+	}
+
+	var definitions []ScriptDefinition
+	names := map[string]string{}
+	var problems []string
+
+	for _, entry := range entries {
+		if entry.IsDir() || !containsFold(scriptSyncExtensions, filepath.Ext(entry.Name())) {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+
+		definition := ScriptDefinition{Path: path, Contents: string(data)}
+		if frontMatter, found := extractScriptFrontMatter(definition.Contents); found {
+			if err := yaml.Unmarshal([]byte(frontMatter), &definition); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: invalid front matter: %v", path, err))
+				continue
+			}
+		}
+		if definition.Name == "" {
+			definition.Name = entry.Name()
+		}
+
+		if err := definition.validateMetadata(); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+
+		if other, exists := names[strings.ToLower(definition.Name)]; exists {
+			problems = append(problems, fmt.Sprintf("%s: name %q is already used by %s", path, definition.Name, other))
+			continue
+		}
+		names[strings.ToLower(definition.Name)] = path
+		definitions = append(definitions, definition)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid script definitions: %s", strings.Join(problems, "; "))
+	}
+
+	return definitions, nil
+}
+
+// validateMetadata checks the priority and parameter numbers.
+func (d *ScriptDefinition) validateMetadata() error {
+	if d.Priority != "" {
+		if _, ok := scriptPriorities[strings.ToLower(d.Priority)]; !ok && !containsFold([]string{"BEFORE", "AFTER", "AT_REBOOT"}, d.Priority) {
+			return fmt.Errorf("priority must be Before, After or At Reboot, got %q", d.Priority)
+		}
+	}
+	for number := range d.Parameters {
+		if number < 4 || number > 11 {
+			return fmt.Errorf("parameter %d is out of range, only parameters 4 to 11 can be labelled", number)
+		}
+	}
+	return nil
+}
+
+// resource converts the definition into a script resource, with the category ID resolved by the caller.
+func (d *ScriptDefinition) resource(categoryID string) ResourceScript {
+	script := ResourceScript{
+		Name:           d.Name,
+		CategoryId:     categoryID,
+		Info:           d.Info,
+		Notes:          d.Notes,
+		OSRequirements: d.OSRequirements,
+		Priority:       "AFTER",
+		ScriptContents: d.Contents,
+		Parameter4:     d.Parameters[4],
+		Parameter5:     d.Parameters[5],
+		Parameter6:     d.Parameters[6],
+		Parameter7:     d.Parameters[7],
+		Parameter8:     d.Parameters[8],
+		Parameter9:     d.Parameters[9],
+		Parameter10:    d.Parameters[10],
+		Parameter11:    d.Parameters[11],
+	}
+	if priority, ok := scriptPriorities[strings.ToLower(d.Priority)]; ok {
+		script.Priority = priority
+	} else if d.Priority != "" {
+		script.Priority = strings.ToUpper(d.Priority)
+	}
+	return script
+}
+
+// scriptContentHash hashes script contents with line endings and trailing whitespace normalised.
+func scriptContentHash(contents string) string {
+	normalised := strings.TrimSpace(strings.ReplaceAll(contents, "\r\n", "\n"))
+	sum := sha256.Sum256([]byte(normalised))
+	return hex.EncodeToString(sum[:])
+}
+
+// Sync
+
+// SyncScripts creates or updates Jamf Pro scripts to match the script files in dir. Scripts failing a
+// validator are reported as invalid and not uploaded. Every change is attempted; the returned actions carry
+// individual errors. An error is returned only when the directory cannot be read or Jamf Pro cannot be queried.
+func (c *Client) SyncScripts(dir string, options ScriptSyncOptions) ([]ScriptSyncAction, error) {
+	definitions, err := LoadScriptDefinitions(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	scripts, err := c.GetScripts("")
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]ResourceScript{}
+	for _, script := range scripts.Results {
+		existing[strings.ToLower(script.Name)] = script
+	}
+
+	categories, err := c.GetCategories("")
+	if err != nil {
+		return nil, err
+	}
+	categoryIDs := map[string]string{"": "-1", "none": "-1"}
+	for _, category := range categories.Results {
+		categoryIDs[strings.ToLower(category.Name)] = category.Id
+	}
+
+	var actions []ScriptSyncAction
+	for i := range definitions {
+		definition := &definitions[i]
+		action := ScriptSyncAction{Name: definition.Name, Path: definition.Path, LocalHash: definition.Hash()}
+
+		var failures []string
+		for _, validator := range options.Validators {
+			if err := validator.Validate(definition); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", validator.Name(), err))
+			}
+		}
+		categoryID, ok := categoryIDs[strings.ToLower(definition.Category)]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("category %q does not exist", definition.Category))
+		}
+		if len(failures) > 0 {
+			action.Action = ScriptSyncInvalid
+			action.Err = fmt.Errorf("%s", strings.Join(failures, "; "))
+			actions = append(actions, action)
+			continue
+		}
+
+		want := definition.resource(categoryID)
+		current, found := existing[strings.ToLower(definition.Name)]
+		if !found {
+			action.Action = ScriptSyncCreate
+			if !options.DryRun {
+				created, err := c.CreateScript(&want)
+				action.Err = err
+				if created != nil {
+					action.ID = created.ID
+				}
+			}
+			actions = append(actions, action)
+			continue
+		}
+
+		action.ID = current.ID
+		action.RemoteHash = scriptContentHash(current.ScriptContents)
+		action.Changes = scriptChanges(&current, &want, action.LocalHash, action.RemoteHash)
+		if len(action.Changes) == 0 {
+			action.Action = ScriptSyncUnchanged
+		} else {
+			action.Action = ScriptSyncUpdate
+			if !options.DryRun {
+				want.ID = current.ID
+				_, action.Err = c.UpdateScriptByID(current.ID, &want)
+			}
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
+
+// scriptChanges lists the fields of the remote script which differ from the local definition.
+func scriptChanges(current, want *ResourceScript, localHash, remoteHash string) []string {
+	var changes []string
+	compare := func(field, a, b string) {
+		if a != b {
+			changes = append(changes, field)
+		}
+	}
+
+	compare("scriptContents", remoteHash, localHash)
+	compare("categoryId", current.CategoryId, want.CategoryId)
+	compare("priority", current.Priority, want.Priority)
+	compare("info", current.Info, want.Info)
+	compare("notes", current.Notes, want.Notes)
+	compare("osRequirements", current.OSRequirements, want.OSRequirements)
+	compare("parameter4", current.Parameter4, want.Parameter4)
+	compare("parameter5", current.Parameter5, want.Parameter5)
+	compare("parameter6", current.Parameter6, want.Parameter6)
+	compare("parameter7", current.Parameter7, want.Parameter7)
+	compare("parameter8", current.Parameter8, want.Parameter8)
+	compare("parameter9", current.Parameter9, want.Parameter9)
+	compare("parameter10", current.Parameter10, want.Parameter10)
+	compare("parameter11", current.Parameter11, want.Parameter11)
+
+	return changes
+}
+
+// Validators
+
+// ShebangValidator requires scripts to start with a shebang line. When Allowed is set, the interpreter must
+// be one of the listed paths, e.g. "/bin/zsh". Python files must use a python interpreter and shell files
+// must not.
+type ShebangValidator struct {
+	Allowed []string
+}
+
+func (v ShebangValidator) Name() string { return "shebang" }
+
+func (v ShebangValidator) Validate(script *ScriptDefinition) error {
+	firstLine, _, _ := strings.Cut(script.Contents, "\n")
+	firstLine = strings.TrimSpace(firstLine)
+	if !strings.HasPrefix(firstLine, "#!") {
+		return fmt.Errorf("missing shebang line")
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(firstLine, "#!"))
+	if len(fields) == 0 {
+		return fmt.Errorf("empty shebang line")
+	}
+	interpreter := fields[0]
+	if filepath.Base(interpreter) == "env" && len(fields) > 1 {
+		interpreter = fields[1]
+	}
+
+	if len(v.Allowed) > 0 && !containsFold(v.Allowed, fields[0]) && !containsFold(v.Allowed, interpreter) {
+		return fmt.Errorf("interpreter %s is not allowed", interpreter)
+	}
+
+	isPython := strings.Contains(filepath.Base(interpreter), "python")
+	if strings.EqualFold(filepath.Ext(script.Path), ".py") != isPython {
+		return fmt.Errorf("interpreter %s does not match file extension %s", interpreter, filepath.Ext(script.Path))
+	}
+
+	return nil
+}
+
+// CommandValidator runs an external linter, such as shellcheck, with the script path appended to Args. The
+// script fails validation when the command exits non-zero; its output is included in the error. Extensions
+// limits the files checked, e.g. []string{".sh", ".zsh"}; all scripts are checked when empty.
+type CommandValidator struct {
+	Label      string
+	Command    string
+	Args       []string
+	Extensions []string
+}
+
+func (v CommandValidator) Name() string {
+	if v.Label != "" {
+		return v.Label
+	}
+	return filepath.Base(v.Command)
+}
+
+func (v CommandValidator) Validate(script *ScriptDefinition) error {
+	if len(v.Extensions) > 0 && !containsFold(v.Extensions, filepath.Ext(script.Path)) {
+		return nil
+	}
+
+	cmd := exec.Command(v.Command, append(append([]string{}, v.Args...), script.Path)...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Run(); err != nil {
+		if details := strings.TrimSpace(output.String()); details != "" {
+			return fmt.Errorf("%v: %s", err, details)
+		}
+		return err
+	}
+	return nil
+}
+
+// SecretsValidator rejects scripts containing likely credentials. Patterns defaults to
+// DefaultSecretPatterns; lines containing AllowMarker, e.g. "# secrets:allow", are ignored.
+type SecretsValidator struct {
+	Patterns    map[string]*regexp.Regexp
+	AllowMarker string
+}
+
+// DefaultSecretPatterns detects common credential formats and hard-coded passwords.
+var DefaultSecretPatterns = map[string]*regexp.Regexp{
+	"AWS access key":      regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),
+	"private key":         regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+	"GitHub token":        regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	"Slack token":         regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`),
+	"hard-coded password": regexp.MustCompile(`(?i)\b(password|passwd|pwd|secret|api_?key|client_?secret)\s*=\s*["'][^"'$\s]{6,}["']`),
+	"basic auth URL":      regexp.MustCompile(`\bhttps?://[^/\s:@]+:[^/\s@$]+@`),
+}
+
+func (v SecretsValidator) Name() string { return "secrets" }
+
+func (v SecretsValidator) Validate(script *ScriptDefinition) error {
+	patterns := v.Patterns
+	if patterns == nil {
+		patterns = DefaultSecretPatterns
+	}
+
+	labels := make([]string, 0, len(patterns))
+	for label := range patterns {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	var findings []string
+	for number, line := range strings.Split(script.Contents, "\n") {
+		if v.AllowMarker != "" && strings.Contains(line, v.AllowMarker) {
+			continue
+		}
+		for _, label := range labels {
+			if patterns[label].MatchString(line) {
+				findings = append(findings, fmt.Sprintf("line %d: %s", number+1, label))
+			}
+		}
+	}
+
+	if len(findings) > 0 {
+		return fmt.Errorf("possible secrets found: %s", strings.Join(findings, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,385 @@
+// store.go
+// Store is an embedded bbolt database holding a local mirror of Jamf Pro computer and mobile device inventory.
+// Records are stored as JSON keyed by Jamf Pro ID, with a secondary index on serial number.
+//
+// bbolt allows a single writer process. Readers in other processes should open the store with
+// Options.ReadOnly and a Timeout; they are blocked only while a Syncer holds the store open for writing.
+
+package warehouse
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Record kinds held by the store
+const (
+	KindComputers     = "computers"
+	KindMobileDevices = "mobile_devices"
+)
+
+var (
+	bucketComputers           = []byte(KindComputers)
+	bucketMobileDevices       = []byte(KindMobileDevices)
+	bucketComputerSerials     = []byte("computer_serials")
+	bucketMobileDeviceSerials = []byte("mobile_device_serials")
+	bucketMeta                = []byte("meta")
+)
+
+// Options configures how a store is opened.
+type Options struct {
+	// ReadOnly opens the store with a shared lock, allowing several reader processes.
+	ReadOnly bool
+
+	// Timeout is how long to wait for the file lock. Zero waits indefinitely.
+	Timeout time.Duration
+}
+
+// Store is a local inventory mirror.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the store at path.
+func Open(path string, opts *Options) (*Store, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: opts.ReadOnly, Timeout: opts.Timeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open inventory store: %w", err)
+	}
+
+	if !opts.ReadOnly {
+		err = db.Update(func(tx *bolt.Tx) error {
+			for _, name := range [][]byte{bucketComputers, bucketMobileDevices, bucketComputerSerials, bucketMobileDeviceSerials, bucketMeta} {
+				if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to initialise inventory store: %w", err)
+		}
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the store and releases its file lock.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Computers
+
+// Computer returns the stored inventory of a computer, or nil if it is not stored.
+func (s *Store) Computer(id string) (*jamfpro.ResourceComputerInventory, error) {
+	var out *jamfpro.ResourceComputerInventory
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := bucket(tx, bucketComputers).Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		out = &jamfpro.ResourceComputerInventory{}
+		return json.Unmarshal(data, out)
+	})
+	return out, err
+}
+
+// ComputerBySerialNumber returns the stored inventory of the computer with a serial number, or nil if none is stored.
+func (s *Store) ComputerBySerialNumber(serialNumber string) (*jamfpro.ResourceComputerInventory, error) {
+	var id []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		id = append(id, bucket(tx, bucketComputerSerials).Get([]byte(serialNumber))...)
+		return nil
+	})
+	if err != nil || id == nil {
+		return nil, err
+	}
+	return s.Computer(string(id))
+}
+
+// ForEachComputer calls fn for every stored computer. Returning an error from fn stops the iteration.
+func (s *Store) ForEachComputer(fn func(computer *jamfpro.ResourceComputerInventory) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return bucket(tx, bucketComputers).ForEach(func(_, data []byte) error {
+			var computer jamfpro.ResourceComputerInventory
+			if err := json.Unmarshal(data, &computer); err != nil {
+				return err
+			}
+			return fn(&computer)
+		})
+	})
+}
+
+// QueryComputers returns every stored computer for which match returns true.
+func (s *Store) QueryComputers(match func(computer *jamfpro.ResourceComputerInventory) bool) ([]jamfpro.ResourceComputerInventory, error) {
+	var out []jamfpro.ResourceComputerInventory
+	err := s.ForEachComputer(func(computer *jamfpro.ResourceComputerInventory) error {
+		if match(computer) {
+			out = append(out, *computer)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// PutComputers stores computers in a single transaction, replacing any existing records with the same ID.
+func (s *Store) PutComputers(computers []jamfpro.ResourceComputerInventory) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, computer := range computers {
+			if err := putRecord(tx, bucketComputers, bucketComputerSerials, computer.ID, computer.Hardware.SerialNumber, computer, computerSerialNumber); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ComputerIDs returns the IDs of every stored computer.
+func (s *Store) ComputerIDs() ([]string, error) {
+	return s.ids(bucketComputers)
+}
+
+// DeleteComputers removes computers by ID.
+func (s *Store) DeleteComputers(ids []string) error {
+	return s.deleteRecords(bucketComputers, bucketComputerSerials, ids, computerSerialNumber)
+}
+
+// computerSerialNumber returns the serial number of a stored computer record.
+func computerSerialNumber(data []byte) string {
+	var computer jamfpro.ResourceComputerInventory
+	if json.Unmarshal(data, &computer) != nil {
+		return ""
+	}
+	return computer.Hardware.SerialNumber
+}
+
+// Mobile devices
+
+// MobileDevice returns the stored inventory of a mobile device, or nil if it is not stored.
+func (s *Store) MobileDevice(id int) (*jamfpro.ResourceMobileDevice, error) {
+	var out *jamfpro.ResourceMobileDevice
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := bucket(tx, bucketMobileDevices).Get([]byte(strconv.Itoa(id)))
+		if data == nil {
+			return nil
+		}
+		out = &jamfpro.ResourceMobileDevice{}
+		return json.Unmarshal(data, out)
+	})
+	return out, err
+}
+
+// MobileDeviceBySerialNumber returns the stored inventory of the mobile device with a serial number, or nil if none is stored.
+func (s *Store) MobileDeviceBySerialNumber(serialNumber string) (*jamfpro.ResourceMobileDevice, error) {
+	var id []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		id = append(id, bucket(tx, bucketMobileDeviceSerials).Get([]byte(serialNumber))...)
+		return nil
+	})
+	if err != nil || id == nil {
+		return nil, err
+	}
+	numericID, err := strconv.Atoi(string(id))
+	if err != nil {
+		return nil, err
+	}
+	return s.MobileDevice(numericID)
+}
+
+// ForEachMobileDevice calls fn for every stored mobile device. Returning an error from fn stops the iteration.
+func (s *Store) ForEachMobileDevice(fn func(device *jamfpro.ResourceMobileDevice) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return bucket(tx, bucketMobileDevices).ForEach(func(_, data []byte) error {
+			var device jamfpro.ResourceMobileDevice
+			if err := json.Unmarshal(data, &device); err != nil {
+				return err
+			}
+			return fn(&device)
+		})
+	})
+}
+
+// QueryMobileDevices returns every stored mobile device for which match returns true.
+func (s *Store) QueryMobileDevices(match func(device *jamfpro.ResourceMobileDevice) bool) ([]jamfpro.ResourceMobileDevice, error) {
+	var out []jamfpro.ResourceMobileDevice
+	err := s.ForEachMobileDevice(func(device *jamfpro.ResourceMobileDevice) error {
+		if match(device) {
+			out = append(out, *device)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// PutMobileDevices stores mobile devices in a single transaction, replacing any existing records with the same ID.
+func (s *Store) PutMobileDevices(devices []jamfpro.ResourceMobileDevice) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, device := range devices {
+			if err := putRecord(tx, bucketMobileDevices, bucketMobileDeviceSerials, strconv.Itoa(device.General.ID), device.General.SerialNumber, device, mobileDeviceSerialNumber); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MobileDeviceIDs returns the IDs of every stored mobile device.
+func (s *Store) MobileDeviceIDs() ([]string, error) {
+	return s.ids(bucketMobileDevices)
+}
+
+// DeleteMobileDevices removes mobile devices by ID.
+func (s *Store) DeleteMobileDevices(ids []string) error {
+	return s.deleteRecords(bucketMobileDevices, bucketMobileDeviceSerials, ids, mobileDeviceSerialNumber)
+}
+
+// mobileDeviceSerialNumber returns the serial number of a stored mobile device record.
+func mobileDeviceSerialNumber(data []byte) string {
+	var device jamfpro.ResourceMobileDevice
+	if json.Unmarshal(data, &device) != nil {
+		return ""
+	}
+	return device.General.SerialNumber
+}
+
+// Sync metadata
+
+// LastSync returns the start time of the last successful sync of a kind, or the zero time if it has never been synced.
+func (s *Store) LastSync(kind string) (time.Time, error) {
+	var out time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := bucket(tx, bucketMeta).Get([]byte("last_sync:" + kind))
+		if data == nil {
+			return nil
+		}
+		return out.UnmarshalText(data)
+	})
+	return out, err
+}
+
+// SetLastSync records the start time of a successful sync of a kind.
+func (s *Store) SetLastSync(kind string, at time.Time) error {
+	data, err := at.UTC().MarshalText()
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketMeta).Put([]byte("last_sync:"+kind), data)
+	})
+}
+
+// Stats reports the number of stored records of each kind.
+func (s *Store) Stats() (map[string]int, error) {
+	out := map[string]int{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		out[KindComputers] = bucket(tx, bucketComputers).Stats().KeyN
+		out[KindMobileDevices] = bucket(tx, bucketMobileDevices).Stats().KeyN
+		return nil
+	})
+	return out, err
+}
+
+// Helpers
+
+// bucket returns a bucket for reading, or a nil-safe empty bucket when it does not exist yet.
+func bucket(tx *bolt.Tx, name []byte) readBucket {
+	if b := tx.Bucket(name); b != nil {
+		return b
+	}
+	return missingBucket{}
+}
+
+// readBucket is the read-only subset of *bolt.Bucket used by the store.
+type readBucket interface {
+	Get(key []byte) []byte
+	ForEach(fn func(k, v []byte) error) error
+	Stats() bolt.BucketStats
+}
+
+// missingBucket behaves as an empty bucket.
+type missingBucket struct{}
+
+func (missingBucket) Get([]byte) []byte                     { return nil }
+func (missingBucket) ForEach(func(k, v []byte) error) error { return nil }
+func (missingBucket) Stats() bolt.BucketStats               { return bolt.BucketStats{} }
+
+// putRecord stores a JSON record and maintains its serial number index, removing the entry for the
+// previous record's serial number when it has changed.
+func putRecord(tx *bolt.Tx, records, serials []byte, id, serialNumber string, record interface{}, serialOf func(data []byte) string) error {
+	if id == "" {
+		return fmt.Errorf("cannot store %s record without an ID", records)
+	}
+
+	if previous := tx.Bucket(records).Get([]byte(id)); previous != nil {
+		if oldSerial := serialOf(previous); oldSerial != "" && oldSerial != serialNumber {
+			if err := deleteSerial(tx, serials, oldSerial, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(records).Put([]byte(id), data); err != nil {
+		return err
+	}
+	if serialNumber != "" {
+		return tx.Bucket(serials).Put([]byte(serialNumber), []byte(id))
+	}
+	return nil
+}
+
+// deleteSerial removes a serial number's index entry if it still refers to the record with id, leaving it
+// when another record has since taken the serial number.
+func deleteSerial(tx *bolt.Tx, serials []byte, serialNumber, id string) error {
+	if string(tx.Bucket(serials).Get([]byte(serialNumber))) != id {
+		return nil
+	}
+	return tx.Bucket(serials).Delete([]byte(serialNumber))
+}
+
+// ids returns every key of a record bucket.
+func (s *Store) ids(records []byte) ([]string, error) {
+	var out []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return bucket(tx, records).ForEach(func(key, _ []byte) error {
+			out = append(out, string(key))
+			return nil
+		})
+	})
+	return out, err
+}
+
+// deleteRecords removes records and their serial number index entries.
+func (s *Store) deleteRecords(records, serials []byte, ids []string, serialOf func(data []byte) string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			data := tx.Bucket(records).Get([]byte(id))
+			if data == nil {
+				continue
+			}
+			if serialNumber := serialOf(data); serialNumber != "" {
+				if err := deleteSerial(tx, serials, serialNumber, id); err != nil {
+					return err
+				}
+			}
+			if err := tx.Bucket(records).Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
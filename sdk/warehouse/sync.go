@@ -0,0 +1,358 @@
+// sync.go
+// Syncer keeps a Store current with Jamf Pro. The first sync of each kind is a full sync; later syncs only
+// fetch records changed since the previous run.
+//
+// Computers are fetched through the Jamf Pro API computers-inventory endpoint, filtered on ChangeField.
+// Mobile devices changed since the last sync are found through the Jamf Pro API mobile-devices detail
+// endpoint, filtered on MobileDeviceChangeField, and only those are fetched in full from the Classic API.
+
+package warehouse
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+const (
+	defaultChangeField         = "general.reportDate"
+	defaultMobileChangeField   = "general.lastInventoryUpdateDate"
+	defaultOverlap             = 5 * time.Minute
+	defaultMobileConcurrency   = 4
+	computerWriteBatchSize     = 500
+	mobileDeviceWriteBatchSize = 100
+)
+
+// SyncResult reports the outcome of syncing a single kind of record.
+type SyncResult struct {
+	Kind      string
+	Full      bool
+	Upserted  int
+	Unchanged int
+	Deleted   int
+	Started   time.Time
+	Duration  time.Duration
+}
+
+// Syncer mirrors Jamf Pro inventory into a Store.
+type Syncer struct {
+	Client *jamfpro.Client
+	Store  *Store
+
+	// ComputerSections are the inventory sections stored for each computer. Defaults to all sections.
+	ComputerSections []jamfpro.ComputerInventorySection
+
+	// ChangeField is the computer inventory field compared against the last sync time, e.g.
+	// "general.reportDate" (default) or "general.lastContactTime".
+	ChangeField string
+
+	// Overlap is subtracted from the last sync time to allow for clock skew and in-flight inventory updates.
+	// Defaults to 5 minutes.
+	Overlap time.Duration
+
+	// PageSize is the number of computers fetched per request. Defaults to 200.
+	PageSize int
+
+	// PruneDeleted removes computers no longer present in Jamf Pro after an incremental sync, at the cost
+	// of listing every computer ID. Full syncs and mobile device syncs always prune.
+	PruneDeleted bool
+
+	// MobileDeviceChangeField is the mobile device detail field compared against the last sync time.
+	// Defaults to "general.lastInventoryUpdateDate".
+	MobileDeviceChangeField string
+
+	// MobileDeviceConcurrency limits concurrent mobile device requests. Defaults to 4.
+	MobileDeviceConcurrency int
+
+	// ForceFull ignores the last sync time and fetches every record.
+	ForceFull bool
+}
+
+// Sync syncs computers and then mobile devices.
+func (s *Syncer) Sync() ([]SyncResult, error) {
+	computers, err := s.SyncComputers()
+	if err != nil {
+		return nil, err
+	}
+
+	mobileDevices, err := s.SyncMobileDevices()
+	if err != nil {
+		return []SyncResult{computers}, err
+	}
+
+	return []SyncResult{computers, mobileDevices}, nil
+}
+
+// Run syncs immediately and then every interval until ctx is cancelled. Results and errors of each run are
+// passed to report, which may be nil. A failed run is retried at the next interval.
+func (s *Syncer) Run(ctx context.Context, interval time.Duration, report func(results []SyncResult, err error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		results, err := s.Sync()
+		if report != nil {
+			report(results, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncComputers fetches computers changed since the last sync and stores them.
+func (s *Syncer) SyncComputers() (SyncResult, error) {
+	result := SyncResult{Kind: KindComputers, Started: time.Now().UTC()}
+	defer func() { result.Duration = time.Since(result.Started) }()
+
+	since, err := s.Store.LastSync(KindComputers)
+	if err != nil {
+		return result, err
+	}
+
+	sections := s.ComputerSections
+	if len(sections) == 0 {
+		sections = jamfpro.AllComputerInventorySections
+	}
+
+	query := jamfpro.ComputerInventoryQuery{Sections: sections, PageSize: s.PageSize}
+	result.Full = s.ForceFull || since.IsZero()
+	if !result.Full {
+		query.Filter = fmt.Sprintf("%s=ge=%s", s.changeField(), jamfpro.RSQLQuote(since.Add(-s.overlap()).Format(time.RFC3339)))
+	}
+
+	seen := map[string]struct{}{}
+	var batch []jamfpro.ResourceComputerInventory
+	_, err = s.Client.ForEachComputerInventory(query, func(computer jamfpro.ResourceComputerInventory) error {
+		seen[computer.ID] = struct{}{}
+		batch = append(batch, computer)
+		if len(batch) < computerWriteBatchSize {
+			return nil
+		}
+		err := s.Store.PutComputers(batch)
+		result.Upserted += len(batch)
+		batch = batch[:0]
+		return err
+	})
+	if err == nil && len(batch) > 0 {
+		err = s.Store.PutComputers(batch)
+		result.Upserted += len(batch)
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to sync computers: %w", err)
+	}
+
+	if !result.Full && s.PruneDeleted {
+		seen, err = s.computerIDs()
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if result.Full || s.PruneDeleted {
+		result.Deleted, err = pruneMissing(seen, s.Store.ComputerIDs, s.Store.DeleteComputers)
+		if err != nil {
+			return result, fmt.Errorf("failed to prune computers: %w", err)
+		}
+	}
+
+	return result, s.Store.SetLastSync(KindComputers, result.Started)
+}
+
+// SyncMobileDevices fetches mobile devices whose inventory changed since the last sync and stores them.
+// Every sync lists the device IDs once, to prune deleted devices; an incremental sync fetches only the
+// devices the change filter returns.
+func (s *Syncer) SyncMobileDevices() (SyncResult, error) {
+	result := SyncResult{Kind: KindMobileDevices, Started: time.Now().UTC()}
+	defer func() { result.Duration = time.Since(result.Started) }()
+
+	since, err := s.Store.LastSync(KindMobileDevices)
+	if err != nil {
+		return result, err
+	}
+	result.Full = s.ForceFull || since.IsZero()
+
+	list, err := s.Client.GetMobileDevices()
+	if err != nil {
+		return result, fmt.Errorf("failed to list mobile devices: %w", err)
+	}
+
+	seen := map[string]struct{}{}
+	var ids []int
+	for _, item := range list.MobileDevices {
+		seen[strconv.Itoa(item.ID)] = struct{}{}
+		ids = append(ids, item.ID)
+	}
+
+	if !result.Full {
+		changed, err := s.changedMobileDeviceIDs(since.Add(-s.overlap()))
+		if err != nil {
+			return result, err
+		}
+		var changedIDs []int
+		for _, id := range ids {
+			if _, ok := changed[id]; ok {
+				changedIDs = append(changedIDs, id)
+			}
+		}
+		result.Unchanged = len(ids) - len(changedIDs)
+		ids = changedIDs
+	}
+
+	concurrency := s.MobileDeviceConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultMobileConcurrency
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		batch    []jamfpro.ResourceMobileDevice
+	)
+	semaphore := make(chan struct{}, concurrency)
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr != nil
+	}
+
+	for _, id := range ids {
+		semaphore <- struct{}{}
+		if failed() {
+			<-semaphore
+			break
+		}
+
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			device, err := s.Client.GetMobileDeviceByID(strconv.Itoa(id))
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			batch = append(batch, *device)
+			if len(batch) >= mobileDeviceWriteBatchSize {
+				if err := s.Store.PutMobileDevices(batch); err != nil && firstErr == nil {
+					firstErr = err
+				}
+				result.Upserted += len(batch)
+				batch = nil
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if firstErr == nil && len(batch) > 0 {
+		firstErr = s.Store.PutMobileDevices(batch)
+		result.Upserted += len(batch)
+	}
+	if firstErr != nil {
+		return result, fmt.Errorf("failed to sync mobile devices: %w", firstErr)
+	}
+
+	result.Deleted, err = pruneMissing(seen, s.Store.MobileDeviceIDs, s.Store.DeleteMobileDevices)
+	if err != nil {
+		return result, fmt.Errorf("failed to prune mobile devices: %w", err)
+	}
+
+	return result, s.Store.SetLastSync(KindMobileDevices, result.Started)
+}
+
+// changedMobileDeviceIDs returns the IDs of mobile devices whose inventory changed at or after since.
+func (s *Syncer) changedMobileDeviceIDs(since time.Time) (map[int]struct{}, error) {
+	filter := fmt.Sprintf("section=GENERAL&filter=%s",
+		url.QueryEscape(fmt.Sprintf("%s=ge=%s", s.mobileDeviceChangeField(), jamfpro.RSQLQuote(since.Format(time.RFC3339)))))
+
+	details, err := s.Client.GetMobileDevicesDetail(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed mobile devices: %w", err)
+	}
+
+	changed := map[int]struct{}{}
+	for _, detail := range details.Results {
+		id, err := strconv.Atoi(detail.MobileDeviceID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mobile device ID %q: %w", detail.MobileDeviceID, err)
+		}
+		changed[id] = struct{}{}
+	}
+	return changed, nil
+}
+
+// computerIDs lists the ID of every computer in Jamf Pro.
+func (s *Syncer) computerIDs() (map[string]struct{}, error) {
+	ids := map[string]struct{}{}
+	query := jamfpro.ComputerInventoryQuery{
+		Sections: []jamfpro.ComputerInventorySection{jamfpro.ComputerInventorySectionGeneral},
+		PageSize: 2000,
+	}
+	_, err := s.Client.ForEachComputerInventory(query, func(computer jamfpro.ResourceComputerInventory) error {
+		ids[computer.ID] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list computer IDs: %w", err)
+	}
+	return ids, nil
+}
+
+// changeField returns the computer inventory field used for incremental syncs.
+func (s *Syncer) changeField() string {
+	if s.ChangeField == "" {
+		return defaultChangeField
+	}
+	return s.ChangeField
+}
+
+// mobileDeviceChangeField returns the mobile device detail field used for incremental syncs.
+func (s *Syncer) mobileDeviceChangeField() string {
+	if s.MobileDeviceChangeField == "" {
+		return defaultMobileChangeField
+	}
+	return s.MobileDeviceChangeField
+}
+
+// overlap returns the window subtracted from the last sync time.
+func (s *Syncer) overlap() time.Duration {
+	if s.Overlap <= 0 {
+		return defaultOverlap
+	}
+	return s.Overlap
+}
+
+// pruneMissing deletes stored records whose IDs are not in seen and returns how many were deleted.
+func pruneMissing(seen map[string]struct{}, storedIDs func() ([]string, error), remove func(ids []string) error) (int, error) {
+	ids, err := storedIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if _, ok := seen[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return 0, nil
+	}
+
+	return len(missing), remove(missing)
+}
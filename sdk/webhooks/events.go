@@ -0,0 +1,422 @@
+// events.go
+// Typed payloads for every Jamf Pro webhook event.
+// Jamf Pro sends each event as an envelope with "webhook" metadata and an "event" body, encoded as JSON or XML
+// depending on the webhook content type. Element names are identical in both encodings.
+// api reference: https://developer.jamf.com/developer-guide/docs/webhooks
+
+package webhooks
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+)
+
+// Event types, matching ResourceWebhook.Event
+const (
+	EventComputerAdded                          = "ComputerAdded"
+	EventComputerCheckIn                        = "ComputerCheckIn"
+	EventComputerInventoryCompleted             = "ComputerInventoryCompleted"
+	EventComputerPatchPolicyCompleted           = "ComputerPatchPolicyCompleted"
+	EventComputerPolicyFinished                 = "ComputerPolicyFinished"
+	EventComputerPushCapabilityChanged          = "ComputerPushCapabilityChanged"
+	EventDeviceAddedToDEP                       = "DeviceAddedToDEP"
+	EventJSSShutdown                            = "JSSShutdown"
+	EventJSSStartup                             = "JSSStartup"
+	EventMobileDeviceCheckIn                    = "MobileDeviceCheckIn"
+	EventMobileDeviceCommandCompleted           = "MobileDeviceCommandCompleted"
+	EventMobileDeviceEnrolled                   = "MobileDeviceEnrolled"
+	EventMobileDeviceInventoryCompleted         = "MobileDeviceInventoryCompleted"
+	EventMobileDevicePushSent                   = "MobileDevicePushSent"
+	EventMobileDeviceUnEnrolled                 = "MobileDeviceUnEnrolled"
+	EventPatchSoftwareTitleUpdated              = "PatchSoftwareTitleUpdated"
+	EventPushSent                               = "PushSent"
+	EventRestAPIOperation                       = "RestAPIOperation"
+	EventSCEPChallenge                          = "SCEPChallenge"
+	EventSmartGroupComputerMembershipChange     = "SmartGroupComputerMembershipChange"
+	EventSmartGroupMobileDeviceMembershipChange = "SmartGroupMobileDeviceMembershipChange"
+	EventSmartGroupUserMembershipChange         = "SmartGroupUserMembershipChange"
+)
+
+// Content types supported by Jamf Pro webhooks, matching ResourceWebhook.ContentType
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeXML  = "text/xml"
+)
+
+// Payload is implemented by every typed event body.
+type Payload interface {
+	EventType() string
+}
+
+// payloadFactories creates an empty typed payload for each known event type.
+var payloadFactories = map[string]func() Payload{
+	EventComputerAdded:                          func() Payload { return &ComputerAddedEvent{} },
+	EventComputerCheckIn:                        func() Payload { return &ComputerCheckInEvent{} },
+	EventComputerInventoryCompleted:             func() Payload { return &ComputerInventoryCompletedEvent{} },
+	EventComputerPatchPolicyCompleted:           func() Payload { return &ComputerPatchPolicyCompletedEvent{} },
+	EventComputerPolicyFinished:                 func() Payload { return &ComputerPolicyFinishedEvent{} },
+	EventComputerPushCapabilityChanged:          func() Payload { return &ComputerPushCapabilityChangedEvent{} },
+	EventDeviceAddedToDEP:                       func() Payload { return &DeviceAddedToDEPEvent{} },
+	EventJSSShutdown:                            func() Payload { return &JSSShutdownEvent{} },
+	EventJSSStartup:                             func() Payload { return &JSSStartupEvent{} },
+	EventMobileDeviceCheckIn:                    func() Payload { return &MobileDeviceCheckInEvent{} },
+	EventMobileDeviceCommandCompleted:           func() Payload { return &MobileDeviceCommandCompletedEvent{} },
+	EventMobileDeviceEnrolled:                   func() Payload { return &MobileDeviceEnrolledEvent{} },
+	EventMobileDeviceInventoryCompleted:         func() Payload { return &MobileDeviceInventoryCompletedEvent{} },
+	EventMobileDevicePushSent:                   func() Payload { return &MobileDevicePushSentEvent{} },
+	EventMobileDeviceUnEnrolled:                 func() Payload { return &MobileDeviceUnEnrolledEvent{} },
+	EventPatchSoftwareTitleUpdated:              func() Payload { return &PatchSoftwareTitleUpdatedEvent{} },
+	EventPushSent:                               func() Payload { return &PushSentEvent{} },
+	EventRestAPIOperation:                       func() Payload { return &RestAPIOperationEvent{} },
+	EventSCEPChallenge:                          func() Payload { return &SCEPChallengeEvent{} },
+	EventSmartGroupComputerMembershipChange:     func() Payload { return &SmartGroupComputerMembershipChangeEvent{} },
+	EventSmartGroupMobileDeviceMembershipChange: func() Payload { return &SmartGroupMobileDeviceMembershipChangeEvent{} },
+	EventSmartGroupUserMembershipChange:         func() Payload { return &SmartGroupUserMembershipChangeEvent{} },
+}
+
+// EventTypes returns every event type with a typed payload, sorted.
+func EventTypes() []string {
+	types := make([]string, 0, len(payloadFactories))
+	for eventType := range payloadFactories {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Envelope
+
+// Webhook is the metadata Jamf Pro sends with every event.
+type Webhook struct {
+	ID             int    `json:"id" xml:"id"`
+	Name           string `json:"name" xml:"name"`
+	WebhookEvent   string `json:"webhookEvent" xml:"webhookEvent"`
+	EventTimestamp int64  `json:"eventTimestamp,omitempty" xml:"eventTimestamp,omitempty"`
+}
+
+// Event is a decoded webhook delivery. Payload holds a pointer to the typed event struct for known
+// event types, and is nil for unknown ones. Raw holds the undecoded request body.
+type Event struct {
+	Webhook     Webhook
+	Payload     Payload
+	ContentType string
+	Raw         []byte
+}
+
+// Type returns the event type reported by the webhook metadata.
+func (e *Event) Type() string {
+	return e.Webhook.WebhookEvent
+}
+
+// Decode decodes a webhook request body. The content type selects JSON or XML decoding; anything other
+// than an XML media type is treated as JSON.
+func Decode(contentType string, body []byte) (*Event, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	isXML := strings.HasSuffix(mediaType, "/xml")
+
+	var envelope struct {
+		Webhook   Webhook         `json:"webhook" xml:"webhook"`
+		EventJSON json.RawMessage `json:"event" xml:"-"`
+		EventXML  struct {
+			Inner []byte `xml:",innerxml"`
+		} `json:"-" xml:"event"`
+	}
+	var err error
+	if isXML {
+		err = xml.Unmarshal(body, &envelope)
+	} else {
+		err = json.Unmarshal(body, &envelope)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode webhook envelope: %w", err)
+	}
+	if envelope.Webhook.WebhookEvent == "" {
+		return nil, fmt.Errorf("webhook envelope has no webhookEvent")
+	}
+
+	event := &Event{
+		Webhook:     envelope.Webhook,
+		ContentType: mediaType,
+		Raw:         body,
+	}
+
+	factory, ok := payloadFactories[envelope.Webhook.WebhookEvent]
+	if !ok {
+		return event, nil
+	}
+
+	payload := factory()
+	if isXML {
+		err = xml.Unmarshal(append(append([]byte("<event>"), envelope.EventXML.Inner...), "</event>"...), payload)
+	} else if len(envelope.EventJSON) > 0 {
+		err = json.Unmarshal(envelope.EventJSON, payload)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s event: %w", envelope.Webhook.WebhookEvent, err)
+	}
+	event.Payload = payload
+
+	return event, nil
+}
+
+// Shared
+
+// Computer is the computer record included in computer events.
+type Computer struct {
+	AlternateMacAddress string `json:"alternateMacAddress" xml:"alternateMacAddress"`
+	Building            string `json:"building" xml:"building"`
+	Department          string `json:"department" xml:"department"`
+	DeviceName          string `json:"deviceName" xml:"deviceName"`
+	EmailAddress        string `json:"emailAddress" xml:"emailAddress"`
+	JssID               int    `json:"jssID" xml:"jssID"`
+	MacAddress          string `json:"macAddress" xml:"macAddress"`
+	Model               string `json:"model" xml:"model"`
+	OsBuild             string `json:"osBuild" xml:"osBuild"`
+	OsVersion           string `json:"osVersion" xml:"osVersion"`
+	Phone               string `json:"phone" xml:"phone"`
+	Position            string `json:"position" xml:"position"`
+	RealName            string `json:"realName" xml:"realName"`
+	Room                string `json:"room" xml:"room"`
+	SerialNumber        string `json:"serialNumber" xml:"serialNumber"`
+	UDID                string `json:"udid" xml:"udid"`
+	UserDirectoryID     string `json:"userDirectoryID" xml:"userDirectoryID"`
+	Username            string `json:"username" xml:"username"`
+}
+
+// MobileDevice is the mobile device record included in mobile device events.
+type MobileDevice struct {
+	BluetoothMacAddress string `json:"bluetoothMacAddress" xml:"bluetoothMacAddress"`
+	DeviceName          string `json:"deviceName" xml:"deviceName"`
+	IccID               string `json:"icciID" xml:"icciID"`
+	IMEI                string `json:"imei" xml:"imei"`
+	IPAddress           string `json:"ipAddress" xml:"ipAddress"`
+	JssID               int    `json:"jssID" xml:"jssID"`
+	Model               string `json:"model" xml:"model"`
+	ModelDisplay        string `json:"modelDisplay" xml:"modelDisplay"`
+	OsBuild             string `json:"osBuild" xml:"osBuild"`
+	OsVersion           string `json:"osVersion" xml:"osVersion"`
+	Product             string `json:"product" xml:"product"`
+	Room                string `json:"room" xml:"room"`
+	SerialNumber        string `json:"serialNumber" xml:"serialNumber"`
+	UDID                string `json:"udid" xml:"udid"`
+	UserDirectoryID     string `json:"userDirectoryID" xml:"userDirectoryID"`
+	Username            string `json:"username" xml:"username"`
+	Version             string `json:"version" xml:"version"`
+	WifiMacAddress      string `json:"wifiMacAddress" xml:"wifiMacAddress"`
+}
+
+// JSSServer describes the Jamf Pro server in startup and shutdown events.
+type JSSServer struct {
+	HostAddress        string `json:"hostAddress" xml:"hostAddress"`
+	Institution        string `json:"institution" xml:"institution"`
+	IsClusterMaster    bool   `json:"isClusterMaster" xml:"isClusterMaster"`
+	JssUrl             string `json:"jssUrl" xml:"jssUrl"`
+	WebApplicationPath string `json:"webApplicationPath" xml:"webApplicationPath"`
+}
+
+// SmartGroupMembershipChange is the body shared by the smart group membership change events.
+type SmartGroupMembershipChange struct {
+	Computer               bool   `json:"computer" xml:"computer"`
+	GroupAddedDevicesIds   []int  `json:"groupAddedDevicesIds" xml:"groupAddedDevicesIds"`
+	GroupRemovedDevicesIds []int  `json:"groupRemovedDevicesIds" xml:"groupRemovedDevicesIds"`
+	JssID                  int    `json:"jssid" xml:"jssid"`
+	Name                   string `json:"name" xml:"name"`
+	SmartGroup             bool   `json:"smartGroup" xml:"smartGroup"`
+}
+
+// Computer events
+
+// ComputerAddedEvent is sent when a computer is added to Jamf Pro.
+type ComputerAddedEvent struct {
+	Computer
+}
+
+// ComputerCheckInEvent is sent when a computer checks in.
+type ComputerCheckInEvent struct {
+	Computer Computer `json:"computer" xml:"computer"`
+	Trigger  string   `json:"trigger" xml:"trigger"`
+	Username string   `json:"username" xml:"username"`
+}
+
+// ComputerInventoryCompletedEvent is sent when a computer submits inventory.
+type ComputerInventoryCompletedEvent struct {
+	Computer
+}
+
+// ComputerPatchPolicyCompletedEvent is sent when a patch policy completes on a computer.
+type ComputerPatchPolicyCompletedEvent struct {
+	DeviceName      string `json:"deviceName" xml:"deviceName"`
+	EventActions    string `json:"eventActions" xml:"eventActions"`
+	PatchPolicyID   int    `json:"patchPolicyId" xml:"patchPolicyId"`
+	PatchPolicyName string `json:"patchPolicyName" xml:"patchPolicyName"`
+	SoftwareTitleID int    `json:"softwareTitleId" xml:"softwareTitleId"`
+	Successful      bool   `json:"successful" xml:"successful"`
+	UDID            string `json:"udid" xml:"udid"`
+}
+
+// ComputerPolicyFinishedEvent is sent when a policy finishes running on a computer.
+type ComputerPolicyFinishedEvent struct {
+	Computer   Computer `json:"computer" xml:"computer"`
+	PolicyID   int      `json:"policyId" xml:"policyId"`
+	Successful bool     `json:"successful" xml:"successful"`
+}
+
+// ComputerPushCapabilityChangedEvent is sent when a computer's push capability changes.
+type ComputerPushCapabilityChangedEvent struct {
+	Computer
+}
+
+// Device enrollment events
+
+// DeviceAddedToDEPEvent is sent when a device is added to an Automated Device Enrollment instance.
+type DeviceAddedToDEPEvent struct {
+	AssetTag                          string `json:"assetTag" xml:"assetTag"`
+	Description                       string `json:"description" xml:"description"`
+	DeviceAssignedDate                string `json:"deviceAssignedDate" xml:"deviceAssignedDate"`
+	DeviceEnrollmentProgramInstanceID int    `json:"deviceEnrollmentProgramInstanceId" xml:"deviceEnrollmentProgramInstanceId"`
+	Model                             string `json:"model" xml:"model"`
+	SerialNumber                      string `json:"serialNumber" xml:"serialNumber"`
+}
+
+// Server events
+
+// JSSShutdownEvent is sent when Jamf Pro shuts down.
+type JSSShutdownEvent struct {
+	JSSServer
+}
+
+// JSSStartupEvent is sent when Jamf Pro starts.
+type JSSStartupEvent struct {
+	JSSServer
+}
+
+// Mobile device events
+
+// MobileDeviceCheckInEvent is sent when a mobile device checks in.
+type MobileDeviceCheckInEvent struct {
+	MobileDevice
+}
+
+// MobileDeviceCommandCompletedEvent is sent when a mobile device completes an MDM command.
+type MobileDeviceCommandCompletedEvent struct {
+	MobileDevice
+	Command string `json:"command" xml:"command"`
+}
+
+// MobileDeviceEnrolledEvent is sent when a mobile device enrolls.
+type MobileDeviceEnrolledEvent struct {
+	MobileDevice
+}
+
+// MobileDeviceInventoryCompletedEvent is sent when a mobile device submits inventory.
+type MobileDeviceInventoryCompletedEvent struct {
+	MobileDevice
+}
+
+// MobileDevicePushSentEvent is sent when a push notification is sent to a mobile device.
+type MobileDevicePushSentEvent struct {
+	MobileDevice
+}
+
+// MobileDeviceUnEnrolledEvent is sent when a mobile device unenrolls.
+type MobileDeviceUnEnrolledEvent struct {
+	MobileDevice
+}
+
+// Other events
+
+// PatchSoftwareTitleUpdatedEvent is sent when a patch software title receives a new version.
+type PatchSoftwareTitleUpdatedEvent struct {
+	JssID         int    `json:"jssID" xml:"jssID"`
+	LastUpdate    int64  `json:"lastUpdate" xml:"lastUpdate"`
+	LatestVersion string `json:"latestVersion" xml:"latestVersion"`
+	Name          string `json:"name" xml:"name"`
+	ReportUrl     string `json:"reportUrl" xml:"reportUrl"`
+}
+
+// PushSentEvent is sent when a push notification is sent.
+type PushSentEvent struct {
+	Type string `json:"type" xml:"type"`
+}
+
+// RestAPIOperationEvent is sent when an operation is performed through the Classic API.
+type RestAPIOperationEvent struct {
+	AuthorizedUsername   string `json:"authorizedUsername" xml:"authorizedUsername"`
+	ObjectID             int    `json:"objectID" xml:"objectID"`
+	ObjectName           string `json:"objectName" xml:"objectName"`
+	ObjectTypeName       string `json:"objectTypeName" xml:"objectTypeName"`
+	OperationSuccessful  bool   `json:"operationSuccessful" xml:"operationSuccessful"`
+	RestAPIOperationType string `json:"restAPIOperationType" xml:"restAPIOperationType"`
+}
+
+// SCEPChallengeEvent is sent when Jamf Pro requests a SCEP challenge from an external service.
+type SCEPChallengeEvent struct {
+	EnrollmentType string       `json:"enrollmentType" xml:"enrollmentType"`
+	TargetDevice   MobileDevice `json:"targetDevice" xml:"targetDevice"`
+	TemplateName   string       `json:"templateName" xml:"templateName"`
+	Username       string       `json:"username" xml:"username"`
+}
+
+// Smart group events
+
+// SmartGroupComputerMembershipChangeEvent is sent when the membership of a computer smart group changes.
+type SmartGroupComputerMembershipChangeEvent struct {
+	SmartGroupMembershipChange
+}
+
+// SmartGroupMobileDeviceMembershipChangeEvent is sent when the membership of a mobile device smart group changes.
+type SmartGroupMobileDeviceMembershipChangeEvent struct {
+	SmartGroupMembershipChange
+}
+
+// SmartGroupUserMembershipChangeEvent is sent when the membership of a user smart group changes.
+type SmartGroupUserMembershipChangeEvent struct {
+	GroupAddedUserIds   []int  `json:"groupAddedUserIds" xml:"groupAddedUserIds"`
+	GroupRemovedUserIds []int  `json:"groupRemovedUserIds" xml:"groupRemovedUserIds"`
+	JssID               int    `json:"jssid" xml:"jssid"`
+	Name                string `json:"name" xml:"name"`
+	SmartGroup          bool   `json:"smartGroup" xml:"smartGroup"`
+}
+
+// EventType implementations
+
+func (*ComputerAddedEvent) EventType() string              { return EventComputerAdded }
+func (*ComputerCheckInEvent) EventType() string            { return EventComputerCheckIn }
+func (*ComputerInventoryCompletedEvent) EventType() string { return EventComputerInventoryCompleted }
+func (*ComputerPatchPolicyCompletedEvent) EventType() string {
+	return EventComputerPatchPolicyCompleted
+}
+func (*ComputerPolicyFinishedEvent) EventType() string { return EventComputerPolicyFinished }
+func (*ComputerPushCapabilityChangedEvent) EventType() string {
+	return EventComputerPushCapabilityChanged
+}
+func (*DeviceAddedToDEPEvent) EventType() string    { return EventDeviceAddedToDEP }
+func (*JSSShutdownEvent) EventType() string         { return EventJSSShutdown }
+func (*JSSStartupEvent) EventType() string          { return EventJSSStartup }
+func (*MobileDeviceCheckInEvent) EventType() string { return EventMobileDeviceCheckIn }
+func (*MobileDeviceCommandCompletedEvent) EventType() string {
+	return EventMobileDeviceCommandCompleted
+}
+func (*MobileDeviceEnrolledEvent) EventType() string { return EventMobileDeviceEnrolled }
+func (*MobileDeviceInventoryCompletedEvent) EventType() string {
+	return EventMobileDeviceInventoryCompleted
+}
+func (*MobileDevicePushSentEvent) EventType() string      { return EventMobileDevicePushSent }
+func (*MobileDeviceUnEnrolledEvent) EventType() string    { return EventMobileDeviceUnEnrolled }
+func (*PatchSoftwareTitleUpdatedEvent) EventType() string { return EventPatchSoftwareTitleUpdated }
+func (*PushSentEvent) EventType() string                  { return EventPushSent }
+func (*RestAPIOperationEvent) EventType() string          { return EventRestAPIOperation }
+func (*SCEPChallengeEvent) EventType() string             { return EventSCEPChallenge }
+func (*SmartGroupComputerMembershipChangeEvent) EventType() string {
+	return EventSmartGroupComputerMembershipChange
+}
+func (*SmartGroupMobileDeviceMembershipChangeEvent) EventType() string {
+	return EventSmartGroupMobileDeviceMembershipChange
+}
+func (*SmartGroupUserMembershipChangeEvent) EventType() string {
+	return EventSmartGroupUserMembershipChange
+}
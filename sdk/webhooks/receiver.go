@@ -0,0 +1,184 @@
+// receiver.go
+// Receiver is an http.Handler which authenticates Jamf Pro webhook deliveries, decodes them into typed
+// events and dispatches them to registered handlers.
+
+package webhooks
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+const defaultMaxBodyBytes = 1 << 20
+
+// EventHandler handles a decoded webhook event.
+type EventHandler func(ctx context.Context, event *Event) error
+
+// Authenticator validates the authentication Jamf Pro attaches to webhook requests.
+type Authenticator interface {
+	Authenticate(r *http.Request) bool
+}
+
+// BasicAuth validates the credentials of a webhook using AuthenticationType "BASIC".
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Authenticate compares the request's basic auth credentials in constant time.
+func (a BasicAuth) Authenticate(r *http.Request) bool {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	usernameMatch := subtle.ConstantTimeCompare([]byte(username), []byte(a.Username)) == 1
+	passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(a.Password)) == 1
+	return usernameMatch && passwordMatch
+}
+
+// HeaderAuth validates a webhook using header authentication, where Jamf Pro sends a fixed header value.
+type HeaderAuth struct {
+	Name  string
+	Value string
+}
+
+// Authenticate compares the configured header in constant time.
+func (a HeaderAuth) Authenticate(r *http.Request) bool {
+	value := r.Header.Get(a.Name)
+	return value != "" && subtle.ConstantTimeCompare([]byte(value), []byte(a.Value)) == 1
+}
+
+// Receiver receives Jamf Pro webhook deliveries.
+type Receiver struct {
+	// Auth validates incoming requests. A nil Auth accepts every request.
+	Auth Authenticator
+
+	// MaxBodyBytes limits the size of a request body. Defaults to 1 MiB.
+	MaxBodyBytes int64
+
+	// OnError is called with requests which are rejected or whose handlers fail.
+	OnError func(r *http.Request, err error)
+
+	mu          sync.RWMutex
+	handlers    map[string][]EventHandler
+	anyHandlers []EventHandler
+}
+
+// NewReceiver creates a Receiver which validates requests with auth.
+func NewReceiver(auth Authenticator) *Receiver {
+	return &Receiver{Auth: auth}
+}
+
+// HandleEvent registers a handler for a single event type.
+func (r *Receiver) HandleEvent(eventType string, handler EventHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.handlers == nil {
+		r.handlers = map[string][]EventHandler{}
+	}
+	r.handlers[eventType] = append(r.handlers[eventType], handler)
+}
+
+// HandleAny registers a handler for every event, including event types without a typed payload.
+func (r *Receiver) HandleAny(handler EventHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.anyHandlers = append(r.anyHandlers, handler)
+}
+
+// On registers a typed handler. The event type is taken from the handler's payload type, e.g.
+//
+//	webhooks.On(receiver, func(ctx context.Context, webhook webhooks.Webhook, event *webhooks.ComputerAddedEvent) error {
+//		...
+//	})
+func On[T any, P interface {
+	*T
+	Payload
+}](r *Receiver, handler func(ctx context.Context, webhook Webhook, event P) error) {
+	var zero T
+	eventType := P(&zero).EventType()
+
+	r.HandleEvent(eventType, func(ctx context.Context, event *Event) error {
+		payload, ok := event.Payload.(P)
+		if !ok {
+			return fmt.Errorf("unexpected payload type %T for %s event", event.Payload, eventType)
+		}
+		return handler(ctx, event.Webhook, payload)
+	})
+}
+
+// ServeHTTP authenticates, decodes and dispatches a webhook delivery.
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		r.reject(w, req, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", req.Method))
+		return
+	}
+
+	if r.Auth != nil && !r.Auth.Authenticate(req) {
+		if _, ok := r.Auth.(BasicAuth); ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="jamf-webhooks"`)
+		}
+		r.reject(w, req, http.StatusUnauthorized, errors.New("webhook authentication failed"))
+		return
+	}
+
+	maxBodyBytes := r.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxBodyBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			r.reject(w, req, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		r.reject(w, req, http.StatusBadRequest, err)
+		return
+	}
+
+	event, err := Decode(req.Header.Get("Content-Type"), body)
+	if err != nil {
+		r.reject(w, req, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := r.Dispatch(req.Context(), event); err != nil {
+		r.reject(w, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Dispatch runs the handlers registered for the event's type followed by those registered with HandleAny,
+// stopping at the first error.
+func (r *Receiver) Dispatch(ctx context.Context, event *Event) error {
+	r.mu.RLock()
+	handlers := append(append([]EventHandler{}, r.handlers[event.Type()]...), r.anyHandlers...)
+	r.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			return fmt.Errorf("%s handler failed: %w", event.Type(), err)
+		}
+	}
+
+	return nil
+}
+
+// reject reports an error and writes the status code.
+func (r *Receiver) reject(w http.ResponseWriter, req *http.Request, status int, err error) {
+	if r.OnError != nil {
+		r.OnError(req, err)
+	}
+	http.Error(w, http.StatusText(status), status)
+}
@@ -0,0 +1,306 @@
+// reconcile.go
+// Reconcile brings the webhooks registered in Jamf Pro in line with the events a receiver handles,
+// creating and updating webhooks which point at the receiver and disabling or deleting stale ones.
+
+package webhooks
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deploymenttheory/go-api-sdk-jamfpro/sdk/jamfpro"
+)
+
+const defaultNamePrefix = "go-jamfpro: "
+
+// Reconcile actions reported in ReconcileAction.Action
+const (
+	ReconcileCreate    = "create"
+	ReconcileUpdate    = "update"
+	ReconcileUnchanged = "unchanged"
+	ReconcileDisable   = "disable"
+	ReconcileDelete    = "delete"
+)
+
+// StaleAction selects what happens to webhooks which are no longer wanted.
+type StaleAction int
+
+const (
+	// StaleDisable disables stale webhooks, leaving them in Jamf Pro.
+	StaleDisable StaleAction = iota
+	// StaleDelete deletes stale webhooks.
+	StaleDelete
+	// StaleIgnore leaves stale webhooks untouched.
+	StaleIgnore
+)
+
+// ReconcileSpec describes the desired webhook registrations.
+type ReconcileSpec struct {
+	// URL is the receiver endpoint every managed webhook delivers to.
+	URL string
+
+	// Events lists the event types to register, e.g. Receiver.EventTypes().
+	Events []string
+
+	// Auth is the authenticator used by the receiver. BasicAuth maps to AuthenticationType "BASIC" and nil to
+	// "NONE". HeaderAuth cannot be reconciled, as the Classic API does not expose header authentication;
+	// configure header authentication in Jamf Pro and reconcile with a nil Auth, or use BasicAuth.
+	Auth Authenticator
+
+	// ContentType is ContentTypeJSON (default) or ContentTypeXML.
+	ContentType string
+
+	// SmartGroupIDs lists the smart groups to register for each smart group membership change event.
+	// One webhook is registered per group.
+	SmartGroupIDs map[string][]int
+
+	// ConnectionTimeout and ReadTimeout are in seconds. Jamf Pro defaults are used when zero.
+	ConnectionTimeout int
+	ReadTimeout       int
+
+	// NamePrefix identifies managed webhooks by name. Defaults to "go-jamfpro: ".
+	NamePrefix string
+
+	// StaleURLPrefixes marks unmanaged webhooks as stale when their URL starts with one of these prefixes
+	// but differs from URL, e.g. the base URL of a previous receiver deployment.
+	StaleURLPrefixes []string
+
+	// Stale selects what happens to managed webhooks which are no longer wanted and to webhooks
+	// matched by StaleURLPrefixes.
+	Stale StaleAction
+
+	// DryRun reports the actions without making any changes.
+	DryRun bool
+}
+
+// ReconcileAction describes a change made, or planned in a dry run, to a single webhook.
+type ReconcileAction struct {
+	Action    string
+	WebhookID int
+	Name      string
+	Event     string
+	URL       string
+	Err       error
+}
+
+// EventTypes returns the event types with at least one registered typed or event-type handler, sorted by name.
+func (r *Receiver) EventTypes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var types []string
+	for eventType := range r.handlers {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Reconcile compares the webhooks in Jamf Pro with the spec and applies the differences. Every change is
+// attempted; the returned actions carry individual errors. An error is returned only when the existing
+// webhooks cannot be read or the spec is invalid.
+func Reconcile(client *jamfpro.Client, spec ReconcileSpec) ([]ReconcileAction, error) {
+	desired, err := spec.desiredWebhooks()
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := client.GetWebhooks()
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]*jamfpro.ResourceWebhook{}
+	var unmatched []*jamfpro.ResourceWebhook
+	for _, item := range list.Webhooks {
+		webhook, err := client.GetWebhookByID(strconv.Itoa(item.ID))
+		if err != nil {
+			return nil, err
+		}
+		if _, wanted := desired[webhook.Name]; wanted {
+			existing[webhook.Name] = webhook
+			continue
+		}
+		unmatched = append(unmatched, webhook)
+	}
+
+	var actions []ReconcileAction
+
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		want := desired[name]
+		action := ReconcileAction{Name: name, Event: want.Event, URL: want.URL}
+
+		current, found := existing[name]
+		switch {
+		case !found:
+			action.Action = ReconcileCreate
+			if !spec.DryRun {
+				created, err := client.CreateWebhook(want)
+				action.Err = err
+				if created != nil {
+					action.WebhookID = created.ID
+				}
+			}
+		case webhookMatches(current, want):
+			action.Action = ReconcileUnchanged
+			action.WebhookID = current.ID
+		default:
+			action.Action = ReconcileUpdate
+			action.WebhookID = current.ID
+			if !spec.DryRun {
+				want.ID = current.ID
+				_, action.Err = client.UpdateWebhookByID(strconv.Itoa(current.ID), want)
+			}
+		}
+
+		actions = append(actions, action)
+	}
+
+	if spec.Stale == StaleIgnore {
+		return actions, nil
+	}
+
+	for _, webhook := range unmatched {
+		if !spec.isStale(webhook) {
+			continue
+		}
+
+		action := ReconcileAction{WebhookID: webhook.ID, Name: webhook.Name, Event: webhook.Event, URL: webhook.URL}
+		if spec.Stale == StaleDelete {
+			action.Action = ReconcileDelete
+			if !spec.DryRun {
+				action.Err = client.DeleteWebhookByID(strconv.Itoa(webhook.ID))
+			}
+		} else {
+			if !webhook.Enabled {
+				continue
+			}
+			action.Action = ReconcileDisable
+			if !spec.DryRun {
+				action.Err = client.SetWebhookEnabledByID(strconv.Itoa(webhook.ID), false)
+			}
+		}
+
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
+
+// desiredWebhooks builds the webhook definitions required by the spec, keyed by name.
+func (spec ReconcileSpec) desiredWebhooks() (map[string]*jamfpro.ResourceWebhook, error) {
+	if spec.URL == "" {
+		return nil, fmt.Errorf("reconcile spec has no URL")
+	}
+
+	contentType := spec.ContentType
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+	if contentType != ContentTypeJSON && contentType != ContentTypeXML {
+		return nil, fmt.Errorf("unsupported webhook content type: %s", contentType)
+	}
+
+	template := jamfpro.ResourceWebhook{
+		Enabled:            true,
+		URL:                spec.URL,
+		ContentType:        contentType,
+		ConnectionTimeout:  spec.ConnectionTimeout,
+		ReadTimeout:        spec.ReadTimeout,
+		AuthenticationType: "NONE",
+	}
+
+	switch auth := spec.Auth.(type) {
+	case nil:
+	case BasicAuth:
+		template.AuthenticationType = "BASIC"
+		template.Username = auth.Username
+		template.Password = auth.Password
+	case *BasicAuth:
+		template.AuthenticationType = "BASIC"
+		template.Username = auth.Username
+		template.Password = auth.Password
+	case HeaderAuth, *HeaderAuth:
+		return nil, fmt.Errorf("header authentication cannot be configured through the Classic API; reconcile with a nil Auth and set the header in Jamf Pro, or use BasicAuth")
+	default:
+		return nil, fmt.Errorf("authenticator %T cannot be configured through the Classic API", spec.Auth)
+	}
+
+	desired := map[string]*jamfpro.ResourceWebhook{}
+	for _, event := range spec.Events {
+		if !isSmartGroupEvent(event) {
+			webhook := template
+			webhook.Event = event
+			webhook.Name = spec.namePrefix() + event
+			desired[webhook.Name] = &webhook
+			continue
+		}
+
+		groupIDs := spec.SmartGroupIDs[event]
+		if len(groupIDs) == 0 {
+			return nil, fmt.Errorf("event %s requires at least one smart group ID", event)
+		}
+		for _, groupID := range groupIDs {
+			webhook := template
+			webhook.Event = event
+			webhook.SmartGroupID = groupID
+			webhook.Name = fmt.Sprintf("%s%s #%d", spec.namePrefix(), event, groupID)
+			desired[webhook.Name] = &webhook
+		}
+	}
+
+	return desired, nil
+}
+
+// namePrefix returns the prefix identifying managed webhooks.
+func (spec ReconcileSpec) namePrefix() string {
+	if spec.NamePrefix == "" {
+		return defaultNamePrefix
+	}
+	return spec.NamePrefix
+}
+
+// isStale reports whether an unwanted webhook is managed by the spec or points at an old receiver URL.
+func (spec ReconcileSpec) isStale(webhook *jamfpro.ResourceWebhook) bool {
+	if strings.HasPrefix(webhook.Name, spec.namePrefix()) {
+		return true
+	}
+	for _, prefix := range spec.StaleURLPrefixes {
+		if strings.HasPrefix(webhook.URL, prefix) && webhook.URL != spec.URL {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookMatches compares the fields managed by Reconcile. Jamf Pro does not return passwords, so a webhook
+// using basic authentication never matches and is updated on every run, which pushes a rotated password.
+func webhookMatches(current, want *jamfpro.ResourceWebhook) bool {
+	if want.AuthenticationType == "BASIC" && current.Password != want.Password {
+		return false
+	}
+	return current.Enabled == want.Enabled &&
+		current.URL == want.URL &&
+		current.ContentType == want.ContentType &&
+		current.Event == want.Event &&
+		current.AuthenticationType == want.AuthenticationType &&
+		current.Username == want.Username &&
+		current.SmartGroupID == want.SmartGroupID &&
+		(want.ConnectionTimeout == 0 || current.ConnectionTimeout == want.ConnectionTimeout) &&
+		(want.ReadTimeout == 0 || current.ReadTimeout == want.ReadTimeout)
+}
+
+// isSmartGroupEvent reports whether an event type requires a smart group ID.
+func isSmartGroupEvent(event string) bool {
+	return event == EventSmartGroupComputerMembershipChange ||
+		event == EventSmartGroupMobileDeviceMembershipChange ||
+		event == EventSmartGroupUserMembershipChange
+}